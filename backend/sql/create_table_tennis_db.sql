@@ -1,3 +1,7 @@
+-- NOTE: this script is kept for reference only. The schema and the achievement
+-- rows are now managed by the numbered migrations in backend/src/internal/migrations,
+-- which are applied automatically when the backend starts.
+
 -- MySQL Workbench Forward Engineering
 
 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0;
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jda5/luinc-pong/src/internal/utils"
+)
+
+// runExport writes an archive of the configured store to a file, or to standard output:
+//
+//	go run . export [-o luinc-pong.json]
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flags.String("o", "", "file to write the archive to (default: standard output)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: export [-o FILE]")
+	}
+
+	archive, err := utils.CreateArchive(createStore())
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(archive); err != nil {
+		return fmt.Errorf("error writing archive: %v", err)
+	}
+
+	fmt.Fprintf(
+		os.Stderr, "exported %d players, %d games and %d doubles games\n",
+		len(archive.Players), len(archive.Games), len(archive.DoublesGames),
+	)
+	return nil
+}
+
+// runRestore loads an archive into the configured store, which must not have any players:
+//
+//	go run . restore luinc-pong.json
+func runRestore(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: restore FILE")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive, err := utils.ReadArchive(file)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.RestoreArchive(createStore(), cfg, archive); err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"restored %d players, %d games and %d doubles games\n",
+		len(archive.Players), len(archive.Games), len(archive.DoublesGames),
+	)
+	return nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/utils"
+)
+
+// runRecalculate replays every game, as GET /recalculate does:
+//
+//	go run . recalculate
+func runRecalculate(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: recalculate")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	s := createStore()
+	if err := utils.RecalculateRatings(s, cfg); err != nil {
+		return err
+	}
+
+	if err := s.InsertAuditLogEntry(models.AuditLogEntry{Action: models.AUDIT_RECALCULATE, Actor: "cli"}); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to record the recalculation in the audit log: %v\n", err)
+	}
+	fmt.Println("elo ratings recalculated successfully")
+	return nil
+}
+
+// runRebuildAchievements works out every player's achievements again from their whole
+// history:
+//
+//	go run . rebuild-achievements
+func runRebuildAchievements(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: rebuild-achievements")
+	}
+
+	if err := utils.RebuildAchievements(createStore()); err != nil {
+		return err
+	}
+	fmt.Println("achievements rebuilt successfully")
+	return nil
+}
+
+// runAddPlayer adds a player at the configured starting rating:
+//
+//	go run . add-player Alice
+func runAddPlayer(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: add-player NAME")
+	}
+	name := strings.TrimSpace(args[0])
+	if name == "" || len(name) > 63 {
+		return errors.New("a player's name must be from 1 to 63 characters long")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	id, err := createStore().InsertPlayer(name, cfg.Ratings.Elo.StartRating)
+	if err != nil {
+		return fmt.Errorf("error adding player `%s`: %v", name, err)
+	}
+	fmt.Printf("added %v with id %d\n", name, id)
+	return nil
+}
+
+// runAddGame records a confirmed singles game. Players are given by id or by name, and
+// the scores may be left out:
+//
+//	go run . add-game [-played-at "2024-03-01 12:30"] Alice Bob 11 7
+func runAddGame(args []string) error {
+	flags := flag.NewFlagSet("add-game", flag.ContinueOnError)
+	playedAt := flags.String("played-at", "", "when the game was played, as an RFC 3339 time or YYYY-MM-DD HH:MM[:SS] in UTC (default: now)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 && flags.NArg() != 4 {
+		return errors.New("usage: add-game [-played-at TIME] WINNER LOSER [WINNER_SCORE LOSER_SCORE]")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	s := createStore()
+	players, err := s.GetPlayerBasicInfo()
+	if err != nil {
+		return err
+	}
+	winner, err := findPlayer(players, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	loser, err := findPlayer(players, flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if winner.ID == loser.ID {
+		return errors.New("the winner and loser cannot be the same player")
+	}
+
+	result := models.GameResult{WinnerID: winner.ID, LoserID: loser.ID}
+	if flags.NArg() == 4 {
+		for i, score := range []**int{&result.WinnerScore, &result.LoserScore} {
+			n, err := strconv.Atoi(flags.Arg(2 + i))
+			if err != nil {
+				return fmt.Errorf("'%s' is not a valid score", flags.Arg(2+i))
+			}
+			*score = &n
+		}
+	}
+	if *playedAt != "" {
+		t, err := utils.ParseGameTime(*playedAt)
+		if err != nil {
+			return err
+		}
+		result.PlayedAt = &t
+	}
+
+	err = utils.ValidateGameResult(result, cfg)
+	var validationErr *exceptions.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, f := range validationErr.Fields {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", f.Field, f.Message)
+		}
+		return errors.New("the game was not recorded")
+	}
+	if err != nil {
+		return err
+	}
+
+	oldRatings, err := s.GetPlayerEloRatings([2]int{winner.ID, loser.ID})
+	if err != nil {
+		return err
+	}
+
+	id, err := s.InsertGameResult(result)
+	if err != nil {
+		return err
+	}
+	gameType, gameID := models.SINGLES, int(id)
+	err = s.InsertAuditLogEntry(models.AuditLogEntry{Action: models.AUDIT_CREATE, Actor: "cli", GameType: &gameType, GameID: &gameID})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to record the game in the audit log: %v\n", err)
+	}
+
+	// the game may have been played before others, so the ratings are replayed rather
+	// than updated from their current values
+	err = utils.RecalculateEloRatings(s, cfg)
+	if err != nil {
+		return err
+	}
+	err = utils.RecalculateGlickoRatings(s, cfg)
+	if err != nil {
+		return err
+	}
+
+	newRatings, err := s.GetPlayerEloRatings([2]int{winner.ID, loser.ID})
+	if err != nil {
+		return err
+	}
+	// there is nobody to tell about the unlocks outside the server
+	err = utils.UpdatePlayerAchievements(s, result, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("recorded game %d\n", id)
+	for _, p := range []models.PlayerBasicInfo{winner, loser} {
+		fmt.Printf("%v: %.0f (%+.0f)\n", p.Name, newRatings[p.ID], newRatings[p.ID]-oldRatings[p.ID])
+	}
+	return nil
+}
+
+// findPlayer looks a player up by id, or by name ignoring case.
+func findPlayer(players []models.PlayerBasicInfo, value string) (models.PlayerBasicInfo, error) {
+	id, err := strconv.Atoi(value)
+	for _, p := range players {
+		if (err == nil && p.ID == id) || strings.EqualFold(p.Name, value) {
+			return p, nil
+		}
+	}
+	return models.PlayerBasicInfo{}, fmt.Errorf("no player with the id or name '%v'", value)
+}
+
+// runLeaderboard prints the leaderboard as a table:
+//
+//	go run . leaderboard [-all] [-doubles] [-sort rating|achievements]
+func runLeaderboard(args []string) error {
+	flags := flag.NewFlagSet("leaderboard", flag.ContinueOnError)
+	all := flags.Bool("all", false, "include players who have not played recently")
+	doubles := flags.Bool("doubles", false, "print the doubles leaderboard")
+	sortBy := flags.String("sort", string(utils.SORT_BY_RATING), "order the singles leaderboard by rating or achievements")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: leaderboard [-all] [-doubles] [-sort rating|achievements]")
+	}
+	sort, err := utils.ParseLeaderboardSort(*sortBy)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	data, err := utils.GetIndexPageData(createStore(), cfg, *all, sort)
+	if err != nil {
+		return err
+	}
+	rows := data.Leaderboard
+	if *doubles {
+		rows = data.DoublesLeaderboard
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tID\tNAME\tELO\tGLICKO-2\tPOINTS")
+	for i, row := range rows {
+		glicko := "-"
+		if row.Glicko != nil {
+			glicko = fmt.Sprintf("%.0f ± %.0f", row.Glicko.Rating, row.Glicko.Interval[1]-row.Glicko.Rating)
+		}
+		fmt.Fprintf(w, "%d\t%d\t%v\t%.0f\t%v\t%d\n", i+1, row.ID, row.Name, row.EloRating, glicko, row.AchievementScore)
+	}
+	return w.Flush()
+}
+
+// runStats prints totals for the whole record:
+//
+//	go run . stats
+func runStats(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: stats")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	s := createStore()
+	players, err := s.GetPlayerBasicInfo()
+	if err != nil {
+		return err
+	}
+	active, err := utils.GetIndexPageData(s, cfg, false, utils.SORT_BY_RATING)
+	if err != nil {
+		return err
+	}
+	doublesGames, err := s.GetDoublesGameResults()
+	if err != nil {
+		return err
+	}
+	pending, err := s.GetSubmittedGames(models.PENDING)
+	if err != nil {
+		return err
+	}
+	disputed, err := s.GetSubmittedGames(models.DISPUTED)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.GetDeletedGames()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "players\t%d\n", len(players))
+	fmt.Fprintf(w, "active players\t%d\n", len(active.Leaderboard))
+	fmt.Fprintf(w, "games\t%d\n", active.GlobalStats.TotalGames)
+	fmt.Fprintf(w, "points\t%d\n", active.GlobalStats.TotalPoints)
+	fmt.Fprintf(w, "doubles games\t%d\n", len(doublesGames))
+	fmt.Fprintf(w, "pending games\t%d\n", len(pending))
+	fmt.Fprintf(w, "disputed games\t%d\n", len(disputed))
+	fmt.Fprintf(w, "deleted games\t%d\n", len(deleted))
+	return w.Flush()
+}
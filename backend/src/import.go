@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/utils"
+)
+
+// runImport loads historical games from a CSV or JSON file into the configured store:
+//
+//	go run . import [-format csv|json] games.csv
+//
+// The format defaults to the file's extension.
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "format of the file: csv or json (default: from the file extension)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: import [-format csv|json] FILE")
+	}
+	path := flags.Arg(0)
+
+	if *format == "" {
+		*format = path
+	}
+	importFormat, err := utils.ParseImportFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	games, err := utils.ReadImport(file, importFormat, cfg)
+	var validationErr *exceptions.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, f := range validationErr.Fields {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", f.Field, f.Message)
+		}
+		return fmt.Errorf("%d problems found in %v, nothing was imported", len(validationErr.Fields), path)
+	}
+	if err != nil {
+		return err
+	}
+
+	s := createStore()
+	summary, err := utils.ImportGames(s, cfg, games)
+	if err != nil {
+		return err
+	}
+
+	reason := utils.DescribeImport(summary)
+	if err := s.InsertAuditLogEntry(models.AuditLogEntry{Action: models.AUDIT_IMPORT, Actor: "cli", Reason: reason}); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to record the import in the audit log: %v\n", err)
+	}
+	fmt.Println(reason)
+	return nil
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// the gin context key the authenticated caller is stored under
+const principalKey = "principal"
+
+// Principal is the caller a request was authenticated as. Name is the name of its token.
+// TokenID is zero for ADMIN_TOKEN, and PlayerID is nil for admin tokens that do not
+// belong to a player, including ADMIN_TOKEN.
+type Principal struct {
+	TokenID  int
+	Name     string
+	Role     models.Role
+	PlayerID *int
+}
+
+// -------------------------------------------------------------------------------- tokens
+
+// GenerateToken returns a new random API token.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the form a token is stored and looked up in.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// -------------------------------------------------------------------------------- middleware
+
+// Authenticate identifies the caller from an "Authorization: Bearer <token>" header.
+// Requests without the header continue anonymously, so read-only routes stay public;
+// requests with an unknown token are rejected outright.
+func Authenticate(s models.Store, adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			unauthorized(c, "expected an Authorization header of the form 'Bearer <token>'")
+			return
+		}
+
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			c.Set(principalKey, Principal{Name: "ADMIN_TOKEN", Role: models.ROLE_ADMIN})
+			c.Next()
+			return
+		}
+
+		t, err := s.GetAPIToken(HashToken(token))
+		if errors.Is(err, exceptions.ErrTokenNotFound) {
+			unauthorized(c, "invalid token")
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.Set(principalKey, Principal{TokenID: t.ID, Name: t.Name, Role: t.Role, PlayerID: t.PlayerID})
+		c.Next()
+	}
+}
+
+// Require rejects requests that were not authenticated with at least the given role.
+// Admins may do anything a player may.
+func Require(role models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := GetPrincipal(c)
+		if !ok {
+			unauthorized(c, "authentication required")
+			return
+		}
+		if role == models.ROLE_ADMIN && p.Role != models.ROLE_ADMIN {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "admin role required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetPrincipal returns the caller of an authenticated request.
+func GetPrincipal(c *gin.Context) (Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	p, ok := value.(Principal)
+	return p, ok
+}
+
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", "Bearer")
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": message})
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/stores"
+)
+
+// -------------------------------------------------------------------------------- Test Helpers
+
+const testAdminToken = "test-admin-token-0123456789"
+
+// createRouter serves a public, a player and an admin route behind the middleware.
+func createRouter(s models.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Authenticate(s, testAdminToken))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/public", ok)
+	router.POST("/player", Require(models.ROLE_PLAYER), ok)
+	router.POST("/admin", Require(models.ROLE_ADMIN), ok)
+	return router
+}
+
+// issueToken stores a new token with the given role and returns it.
+func issueToken(t *testing.T, s models.Store, role models.Role, playerID *int) string {
+	t.Helper()
+	token, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.InsertAPIToken(HashToken(token), models.NewAPIToken{Name: "test", Role: role, PlayerID: playerID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return token
+}
+
+func request(router *gin.Engine, method string, path string, authorization string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+// -------------------------------------------------------------------------------- Tests
+
+func TestRoutesByRole(t *testing.T) {
+	s := stores.CreateMemoryStore()
+	playerID, err := s.InsertPlayer("Alice", 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	id := int(playerID)
+	router := createRouter(s)
+
+	playerToken := "Bearer " + issueToken(t, s, models.ROLE_PLAYER, &id)
+	adminToken := "Bearer " + issueToken(t, s, models.ROLE_ADMIN, nil)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		expected      int
+	}{
+		{"anonymous read", http.MethodGet, "/public", "", http.StatusOK},
+		{"anonymous write", http.MethodPost, "/player", "", http.StatusUnauthorized},
+		{"unknown token", http.MethodGet, "/public", "Bearer not-a-token", http.StatusUnauthorized},
+		{"not a bearer token", http.MethodPost, "/player", "Basic YWxpY2U6c2VjcmV0", http.StatusUnauthorized},
+		{"player write", http.MethodPost, "/player", playerToken, http.StatusOK},
+		{"player admin action", http.MethodPost, "/admin", playerToken, http.StatusForbidden},
+		{"admin write", http.MethodPost, "/player", adminToken, http.StatusOK},
+		{"admin action", http.MethodPost, "/admin", adminToken, http.StatusOK},
+		{"configured admin token", http.MethodPost, "/admin", "Bearer " + testAdminToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if code := request(router, tt.method, tt.path, tt.authorization); code != tt.expected {
+			t.Errorf("%v: expected status %d, got %d", tt.name, tt.expected, code)
+		}
+	}
+}
+
+func TestRevokedTokenIsRejected(t *testing.T) {
+	s := stores.CreateMemoryStore()
+	router := createRouter(s)
+	token := issueToken(t, s, models.ROLE_ADMIN, nil)
+
+	tokens, err := s.GetAPITokens()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tokens) != 1 {
+		t.Fatalf("expected 1 token, got %d", len(tokens))
+	}
+	if err := s.DeleteAPIToken(tokens[0].ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if code := request(router, http.MethodPost, "/admin", "Bearer "+token); code != http.StatusUnauthorized {
+		t.Errorf("expected a revoked token to be rejected, got status %d", code)
+	}
+}
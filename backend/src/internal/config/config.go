@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// -------------------------------------------------------------------------------- types
+
+// Config holds the behaviour settings read from the environment at startup.
+type Config struct {
+	Doubles      DoublesConfig      `json:"doubles"`
+	Matches      MatchesConfig      `json:"matches"`
+	Scoring      ScoringConfig      `json:"scoring"`
+	Ratings      RatingsConfig      `json:"ratings"`
+	Confirmation ConfirmationConfig `json:"confirmation"`
+	Backdating   BackdatingConfig   `json:"backdating"`
+	Auth         AuthConfig         `json:"-"`
+}
+
+type ConfirmationConfig struct {
+	// When set, singles games, matches and doubles games recorded with a player token are
+	// pending until an opponent confirms them.
+	Required bool `json:"required"`
+
+	// How long the opponent has to confirm or dispute a game before it is confirmed for them.
+	WindowHours int `json:"windowHours"`
+}
+
+type BackdatingConfig struct {
+	// How far in the past a game's played-at time may be when it is recorded.
+	WindowHours int `json:"windowHours"`
+}
+
+type AuthConfig struct {
+	// A token that always has the admin role, used to issue the first API tokens. When
+	// empty, only tokens issued through the API are accepted.
+	AdminToken string
+}
+
+type DoublesConfig struct {
+	// When set, doubles games also move the players' singles ratings.
+	AffectsSingles bool `json:"affectsSingles"`
+}
+
+type MatchRatingMode string
+
+const (
+	RATE_PER_MATCH MatchRatingMode = "match"
+	RATE_PER_SET   MatchRatingMode = "set"
+)
+
+type MatchesConfig struct {
+	// Whether a best-of-N match is rated as a single result or as one result per set.
+	RatingMode MatchRatingMode `json:"ratingMode"`
+}
+
+type ScoringConfig struct {
+	// The points needed to win a game: 11, or 21 for old-school games.
+	Target int `json:"target"`
+}
+
+type RatingSystem string
+
+const (
+	ELO     RatingSystem = "elo"
+	GLICKO2 RatingSystem = "glicko2"
+)
+
+type RatingsConfig struct {
+	// The rating system the singles leaderboard is ordered by. Both are always kept up to
+	// date so they can be compared before switching.
+	System RatingSystem `json:"system"`
+
+	// The length of a Glicko-2 rating period. Every game in a period is rated together,
+	// and a player's deviation grows for each period they sit out.
+	GlickoPeriodDays int `json:"glickoPeriodDays"`
+
+	Elo EloConfig `json:"elo"`
+}
+
+// EloConfig sets the rules of the Elo rating. The K-factor a player's rating moves by is
+// chosen in order: ProvisionalK for their first ProvisionalGames games, HighRatingK once
+// they reach HighRatingThreshold, and K otherwise. A zero ProvisionalGames or
+// HighRatingThreshold turns that step off. These steps and MarginOfVictory apply to singles
+// games only: a doubles game always moves ratings by K, including the singles ratings when
+// doubles games affect them.
+type EloConfig struct {
+	StartRating         float64 `json:"startRating"`
+	K                   float64 `json:"k"`
+	ProvisionalGames    int     `json:"provisionalGames"`
+	ProvisionalK        float64 `json:"provisionalK"`
+	HighRatingThreshold float64 `json:"highRatingThreshold"`
+	HighRatingK         float64 `json:"highRatingK"`
+
+	// When set, the K-factor is scaled by the margin of victory of games with scores.
+	MarginOfVictory bool `json:"marginOfVictory"`
+}
+
+// -------------------------------------------------------------------------------- public functions
+
+// Default returns the configuration used when no environment variables are set.
+func Default() Config {
+	return Config{
+		Doubles: DoublesConfig{AffectsSingles: false},
+		Matches: MatchesConfig{RatingMode: RATE_PER_MATCH},
+		Scoring: ScoringConfig{Target: 11},
+		Confirmation: ConfirmationConfig{
+			Required:    true,
+			WindowHours: 48,
+		},
+		Backdating: BackdatingConfig{WindowHours: 168},
+		Ratings: RatingsConfig{
+			System:           ELO,
+			GlickoPeriodDays: 7,
+			Elo: EloConfig{
+				StartRating:         1000,
+				K:                   40,
+				ProvisionalGames:    0,
+				ProvisionalK:        60,
+				HighRatingThreshold: 0,
+				HighRatingK:         20,
+				MarginOfVictory:     false,
+			},
+		},
+	}
+}
+
+// Load reads the configuration from the environment, falling back to Default for unset values.
+func Load() (Config, error) {
+	cfg := Default()
+
+	var err error
+	if cfg.Doubles.AffectsSingles, err = getBool("DOUBLES_AFFECTS_SINGLES", cfg.Doubles.AffectsSingles); err != nil {
+		return cfg, err
+	}
+
+	mode, err := getOneOf("MATCH_RATING_MODE", string(cfg.Matches.RatingMode), string(RATE_PER_MATCH), string(RATE_PER_SET))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Matches.RatingMode = MatchRatingMode(mode)
+
+	target, err := getOneOf("SCORE_TARGET", strconv.Itoa(cfg.Scoring.Target), "11", "21")
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Scoring.Target, _ = strconv.Atoi(target)
+
+	system, err := getOneOf("RATING_SYSTEM", string(cfg.Ratings.System), string(ELO), string(GLICKO2))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Ratings.System = RatingSystem(system)
+
+	if cfg.Ratings.GlickoPeriodDays, err = getPositiveInt("GLICKO_PERIOD_DAYS", cfg.Ratings.GlickoPeriodDays); err != nil {
+		return cfg, err
+	}
+
+	elo := &cfg.Ratings.Elo
+	if elo.StartRating, err = getPositiveFloat("ELO_START_RATING", elo.StartRating); err != nil {
+		return cfg, err
+	}
+	if elo.K, err = getPositiveFloat("ELO_K", elo.K); err != nil {
+		return cfg, err
+	}
+	if elo.ProvisionalGames, err = getNonNegativeInt("ELO_PROVISIONAL_GAMES", elo.ProvisionalGames); err != nil {
+		return cfg, err
+	}
+	if elo.ProvisionalK, err = getPositiveFloat("ELO_PROVISIONAL_K", elo.ProvisionalK); err != nil {
+		return cfg, err
+	}
+	if elo.HighRatingThreshold, err = getNonNegativeFloat("ELO_HIGH_RATING_THRESHOLD", elo.HighRatingThreshold); err != nil {
+		return cfg, err
+	}
+	if elo.HighRatingK, err = getPositiveFloat("ELO_HIGH_RATING_K", elo.HighRatingK); err != nil {
+		return cfg, err
+	}
+	if elo.MarginOfVictory, err = getBool("ELO_MARGIN_OF_VICTORY", elo.MarginOfVictory); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Confirmation.Required, err = getBool("GAME_CONFIRMATION", cfg.Confirmation.Required); err != nil {
+		return cfg, err
+	}
+	if cfg.Confirmation.WindowHours, err = getPositiveInt("CONFIRMATION_WINDOW_HOURS", cfg.Confirmation.WindowHours); err != nil {
+		return cfg, err
+	}
+	if cfg.Backdating.WindowHours, err = getPositiveInt("BACKDATE_WINDOW_HOURS", cfg.Backdating.WindowHours); err != nil {
+		return cfg, err
+	}
+
+	// a short token could be guessed, and it grants every permission
+	cfg.Auth.AdminToken = os.Getenv("ADMIN_TOKEN")
+	if cfg.Auth.AdminToken != "" && len(cfg.Auth.AdminToken) < 16 {
+		return cfg, fmt.Errorf("invalid ADMIN_TOKEN: expected at least 16 characters")
+	}
+
+	return cfg, nil
+}
+
+// -------------------------------------------------------------------------------- private functions
+
+func getBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid %v '%v': expected true or false", key, value)
+	}
+	return b, nil
+}
+
+func getPositiveInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fallback, fmt.Errorf("invalid %v '%v': expected a positive whole number", key, value)
+	}
+	return n, nil
+}
+
+func getNonNegativeInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fallback, fmt.Errorf("invalid %v '%v': expected zero or a positive whole number", key, value)
+	}
+	return n, nil
+}
+
+func getPositiveFloat(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f <= 0 {
+		return fallback, fmt.Errorf("invalid %v '%v': expected a positive number", key, value)
+	}
+	return f, nil
+}
+
+func getNonNegativeFloat(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return fallback, fmt.Errorf("invalid %v '%v': expected zero or a positive number", key, value)
+	}
+	return f, nil
+}
+
+func getOneOf(key string, fallback string, allowed ...string) (string, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	if !slices.Contains(allowed, value) {
+		return fallback, fmt.Errorf("invalid %v '%v': expected one of %v", key, value, allowed)
+	}
+	return value, nil
+}
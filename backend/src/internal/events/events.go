@@ -0,0 +1,148 @@
+package events
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+type EventType string
+
+const (
+	GAME_RECORDED        EventType = "gameRecorded"
+	GAME_DELETED         EventType = "gameDeleted"
+	RATINGS_RECALCULATED EventType = "ratingsRecalculated"
+	ACHIEVEMENT_UNLOCKED EventType = "achievementUnlocked"
+	LEADERBOARD_CHANGED  EventType = "leaderboardChanged"
+)
+
+// the number of events a subscriber can fall behind by before further events are dropped
+// for it
+const BUFFER_SIZE = 32
+
+type Event struct {
+	Type EventType
+	Data any
+}
+
+// A game that was recorded or deleted.
+type Game struct {
+	GameType models.GameType `json:"gameType"`
+	ID       int             `json:"id"`
+}
+
+// An achievement awarded to a player for a game they have just played.
+type AchievementUnlock struct {
+	Player      models.Player      `json:"player"`
+	Achievement models.Achievement `json:"achievement"`
+	EarnedAt    time.Time          `json:"earnedAt"`
+}
+
+// A player who moved on the singles leaderboard. Positions count from 1, and are nil for a
+// player who has joined or dropped off the leaderboard.
+type PositionChange struct {
+	Player models.Player `json:"player"`
+	From   *int          `json:"from"`
+	To     *int          `json:"to"`
+}
+
+// Broker passes the events published to it on to every subscriber. It also remembers the
+// leaderboard positions, so that it can tell when they change.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]bool
+	leaderboard []models.LeaderboardRow
+}
+
+func CreateBroker() *Broker {
+	return &Broker{subscribers: make(map[chan Event]bool)}
+}
+
+// Subscribe returns a channel that receives every event published from now on, and a
+// function that unsubscribes and closes it.
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, BUFFER_SIZE)
+
+	b.mu.Lock()
+	b.subscribers[ch] = true
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends an event to every subscriber. It never waits for a subscriber: one that
+// has fallen BUFFER_SIZE events behind misses the event. Publishing to a nil broker does
+// nothing, so that code run outside the server need not have one.
+func (b *Broker) Publish(t EventType, data any) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- Event{Type: t, Data: data}:
+		default:
+			log.Printf("WARNING: dropped a '%v' event for a subscriber that is falling behind", t)
+		}
+	}
+}
+
+// PublishLeaderboard compares the singles leaderboard with the one it was last given, and
+// publishes the players whose position has changed. The first leaderboard it is given is
+// only remembered.
+func (b *Broker) PublishLeaderboard(rows []models.LeaderboardRow) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	previous := b.leaderboard
+	b.leaderboard = append(make([]models.LeaderboardRow, 0, len(rows)), rows...)
+	b.mu.Unlock()
+	if previous == nil {
+		return
+	}
+
+	changes := make([]PositionChange, 0)
+	for i, row := range rows {
+		from := slices.IndexFunc(previous, func(p models.LeaderboardRow) bool { return p.ID == row.ID })
+		if from != i {
+			changes = append(changes, positionChange(row, from, i))
+		}
+	}
+	for i, row := range previous {
+		if !slices.ContainsFunc(rows, func(r models.LeaderboardRow) bool { return r.ID == row.ID }) {
+			changes = append(changes, positionChange(row, i, -1))
+		}
+	}
+
+	if len(changes) > 0 {
+		b.Publish(LEADERBOARD_CHANGED, changes)
+	}
+}
+
+// positionChange describes a player moving between two indexes of the leaderboard, where
+// -1 is off it.
+func positionChange(row models.LeaderboardRow, from int, to int) PositionChange {
+	position := func(i int) *int {
+		if i == -1 {
+			return nil
+		}
+		i++
+		return &i
+	}
+	return PositionChange{Player: models.Player{ID: row.ID, Name: row.Name}, From: position(from), To: position(to)}
+}
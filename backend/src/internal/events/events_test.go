@@ -0,0 +1,110 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// -------------------------------------------------------------------------------- Test Helpers
+
+// leaderboard lists the players in the order given.
+func leaderboard(names ...string) []models.LeaderboardRow {
+	rows := make([]models.LeaderboardRow, 0, len(names))
+	for i, name := range names {
+		rows = append(rows, models.LeaderboardRow{ID: i + 1, Name: name})
+	}
+	return rows
+}
+
+// received returns the events waiting on a subscription.
+func received(subscription <-chan Event) []Event {
+	events := make([]Event, 0)
+	for {
+		select {
+		case e := <-subscription:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+// -------------------------------------------------------------------------------- Tests
+
+func TestPublishReachesEverySubscriber(t *testing.T) {
+	b := CreateBroker()
+	first, _ := b.Subscribe()
+	second, unsubscribe := b.Subscribe()
+	unsubscribe()
+
+	b.Publish(GAME_RECORDED, Game{GameType: models.SINGLES, ID: 7})
+
+	if events := received(first); len(events) != 1 || events[0].Type != GAME_RECORDED || events[0].Data != (Game{GameType: models.SINGLES, ID: 7}) {
+		t.Errorf("expected the game to be published, got %+v", events)
+	}
+	if _, ok := <-second; ok {
+		t.Errorf("expected the unsubscribed channel to be closed")
+	}
+}
+
+func TestPublishDropsEventsForSlowSubscribers(t *testing.T) {
+	b := CreateBroker()
+	subscription, _ := b.Subscribe()
+
+	for i := range BUFFER_SIZE + 5 {
+		b.Publish(GAME_DELETED, Game{GameType: models.SINGLES, ID: i + 1})
+	}
+
+	if events := received(subscription); len(events) != BUFFER_SIZE {
+		t.Errorf("expected %d events to be kept, got %d", BUFFER_SIZE, len(events))
+	}
+}
+
+func TestPublishLeaderboardSendsPositionChanges(t *testing.T) {
+	b := CreateBroker()
+	subscription, _ := b.Subscribe()
+
+	b.PublishLeaderboard(leaderboard("Alice", "Bob", "Carol"))
+	if events := received(subscription); len(events) != 0 {
+		t.Fatalf("expected the first leaderboard only to be remembered, got %+v", events)
+	}
+
+	b.PublishLeaderboard(leaderboard("Alice", "Bob", "Carol"))
+	if events := received(subscription); len(events) != 0 {
+		t.Fatalf("expected an unchanged leaderboard not to be published, got %+v", events)
+	}
+
+	// Carol overtakes Bob, Dana joins and Alice drops off
+	rows := []models.LeaderboardRow{{ID: 3, Name: "Carol"}, {ID: 2, Name: "Bob"}, {ID: 4, Name: "Dana"}}
+	b.PublishLeaderboard(rows)
+
+	events := received(subscription)
+	if len(events) != 1 || events[0].Type != LEADERBOARD_CHANGED {
+		t.Fatalf("expected a single leaderboard change, got %+v", events)
+	}
+	changes := events[0].Data.([]PositionChange)
+	expected := []struct {
+		name     string
+		from, to int
+	}{
+		{"Carol", 3, 1},
+		{"Dana", 0, 3},
+		{"Alice", 1, 0},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d position changes, got %+v", len(expected), changes)
+	}
+	position := func(p *int) int {
+		if p == nil {
+			return 0
+		}
+		return *p
+	}
+	for i, e := range expected {
+		c := changes[i]
+		if c.Player.Name != e.name || position(c.From) != e.from || position(c.To) != e.to {
+			t.Errorf("expected %v to move from %d to %d, got %+v from %d to %d", e.name, e.from, e.to, c.Player, position(c.From), position(c.To))
+		}
+	}
+}
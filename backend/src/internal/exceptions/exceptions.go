@@ -3,3 +3,11 @@ package exceptions
 import "errors"
 
 var ErrNoGamesPlayed = errors.New("no games played yet")
+
+var ErrGameNotFound = errors.New("game not found")
+
+var ErrTokenNotFound = errors.New("token not found")
+
+var ErrAchievementNotFound = errors.New("achievement not found")
+
+var ErrStoreNotEmpty = errors.New("an archive can only be restored into a store without players")
@@ -0,0 +1,36 @@
+package exceptions
+
+import "strings"
+
+// FieldError describes what is wrong with a single field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every problem found with a request body, so a client can be
+// told about all of them at once.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, f.Field+": "+f.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Add(field string, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// ErrOrNil returns nil when no problems were found. Returning the *ValidationError
+// directly would produce a non-nil error interface holding a nil pointer.
+func (e *ValidationError) ErrOrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
@@ -3,22 +3,281 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jda5/luinc-pong/src/internal/auth"
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/events"
 	"github.com/jda5/luinc-pong/src/internal/exceptions"
 	"github.com/jda5/luinc-pong/src/internal/models"
 	"github.com/jda5/luinc-pong/src/internal/utils"
 )
 
+// how often an idle event stream is sent a comment to keep it open
+const HEARTBEAT_INTERVAL = 30 * time.Second
+
 type APIHandler struct {
 	models.Store
+	Config config.Config
+	Events *events.Broker
 }
 
 // ---------------------------------------- internal helpers
 
+// invalidRequest responds with 400 Bad Request, listing each field error when the
+// request failed validation.
+func invalidRequest(c *gin.Context, err error) {
+	var validationErr *exceptions.ValidationError
+	if errors.As(err, &validationErr) {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "invalid request", "errors": validationErr.Fields})
+		return
+	}
+	c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+}
+
+// updatePlayerAchievements is run in the background once a result has been recorded.
+func (h *APIHandler) updatePlayerAchievements(result models.GameResult) {
+
+	// Recover is a built-in function that regains control of a panicking goroutine.
+	// Recover is only useful inside deferred functions.
+	// 	// During normal execution, a call to recover will return nil and have no other effect.
+	// 	// If the current goroutine is panicking, a call to recover will capture the value given
+	// 	// to panic and resume normal execution.
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC recovered in UpdatePlayerAchievements: %v", r)
+		}
+	}()
+
+	err := utils.UpdatePlayerAchievements(h.Store, result, h.Events)
+	if err != nil {
+		log.Printf("ERROR: background update of player achievements failed: %v", err)
+	}
+}
+
+// publishLeaderboard tells subscribers which players have moved on the singles leaderboard
+// once the ratings have changed. The change has already been made, so a failure to work
+// out the leaderboard is logged rather than failing the request.
+func (h *APIHandler) publishLeaderboard() {
+	data, err := utils.GetIndexPageData(h.Store, h.Config, false, utils.SORT_BY_RATING)
+	if err != nil {
+		log.Printf("ERROR: failed to publish the leaderboard: %v", err)
+		return
+	}
+	h.Events.PublishLeaderboard(data.Leaderboard)
+}
+
+// submitter decides whether a result must be confirmed by the opponent. It returns the
+// ID of the player recording it when it must, or nil when it counts straight away because
+// confirmation is turned off or the caller is an admin. Players may only record results
+// they took part in; otherwise it responds with 403 Forbidden and returns false.
+func (h *APIHandler) submitter(c *gin.Context, playerIDs ...int) (*int, bool) {
+	p, _ := auth.GetPrincipal(c)
+	if !h.Config.Confirmation.Required || p.Role == models.ROLE_ADMIN {
+		return nil, true
+	}
+	if p.PlayerID == nil || !slices.Contains(playerIDs, *p.PlayerID) {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"message": "players can only record games they played in"})
+		return nil, false
+	}
+	return p.PlayerID, true
+}
+
+// canRespond reports whether the caller may confirm or dispute a pending game: admins
+// always may, and otherwise only a player on the other side from the one who recorded it.
+func canRespond(p auth.Principal, g models.SubmittedGame) bool {
+	if p.Role == models.ROLE_ADMIN {
+		return true
+	}
+	if p.PlayerID == nil || (g.SubmittedBy != nil && *g.SubmittedBy == *p.PlayerID) {
+		return false
+	}
+
+	winners, losers := []int{g.Winner.ID}, []int{g.Loser.ID}
+	if g.WinnerPartner != nil {
+		winners = append(winners, g.WinnerPartner.ID)
+	}
+	if g.LoserPartner != nil {
+		losers = append(losers, g.LoserPartner.ID)
+	}
+	switch {
+	case g.SubmittedBy != nil && slices.Contains(winners, *g.SubmittedBy):
+		return slices.Contains(losers, *p.PlayerID)
+	case g.SubmittedBy != nil && slices.Contains(losers, *g.SubmittedBy):
+		return slices.Contains(winners, *p.PlayerID)
+	default:
+		return slices.Contains(winners, *p.PlayerID) || slices.Contains(losers, *p.PlayerID)
+	}
+}
+
+// getSubmittedGame fetches a singles or doubles game with its confirmation state.
+func (h *APIHandler) getSubmittedGame(gameType models.GameType, id int) (models.SubmittedGame, error) {
+	if gameType == models.DOUBLES {
+		return h.Store.GetSubmittedDoublesGame(id)
+	}
+	return h.Store.GetSubmittedGame(id)
+}
+
+// updateGameStatus confirms or disputes a singles or doubles game.
+func (h *APIHandler) updateGameStatus(gameType models.GameType, id int, status models.GameStatus) error {
+	if gameType == models.DOUBLES {
+		return h.Store.UpdateDoublesGameStatus(id, status)
+	}
+	return h.Store.UpdateGameStatus(id, status)
+}
+
+// confirmBy returns when a pending game will be confirmed automatically. The window runs
+// from when the game was recorded rather than when it was played, so that a backdated game
+// still gives the opponent the whole window to respond.
+func (h *APIHandler) confirmBy(g models.SubmittedGame) time.Time {
+	return g.SubmittedAt.Add(time.Duration(h.Config.Confirmation.WindowHours) * time.Hour)
+}
+
+// rateConfirmedGames brings the ratings up to date once games have been confirmed. A
+// confirmed game may have been played before others that were already rated, so every
+// game is replayed in the order it was played and the achievements are rebuilt.
+func (h *APIHandler) rateConfirmedGames(games []models.SubmittedGame) error {
+	singles := slices.ContainsFunc(games, func(g models.SubmittedGame) bool { return g.Type == models.SINGLES })
+	doubles := slices.ContainsFunc(games, func(g models.SubmittedGame) bool { return g.Type == models.DOUBLES })
+
+	if doubles {
+		if err := h.replayDoublesRatings(); err != nil {
+			return err
+		}
+	}
+	if !singles {
+		return nil
+	}
+	return h.replaySinglesRatings()
+}
+
+// isBackdated reports whether a game played at playedAt comes before the latest game that
+// moves the same ratings, in which case they are replayed from the start rather than
+// updated from their current values.
+func (h *APIHandler) isBackdated(gameType models.GameType, playedAt *time.Time) (bool, error) {
+	if playedAt == nil {
+		return false, nil
+	}
+
+	gameTypes := []models.GameType{gameType}
+	if h.Config.Doubles.AffectsSingles {
+		gameTypes = []models.GameType{models.SINGLES, models.DOUBLES}
+	}
+	for _, t := range gameTypes {
+		latest, err := h.Store.GetLatestGameTime(t)
+		if err != nil {
+			return false, err
+		}
+		if playedAt.Before(latest) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// respondWithSubmittedGames lists the games with the given confirmation state.
+func (h *APIHandler) respondWithSubmittedGames(c *gin.Context, status models.GameStatus) {
+	games, err := h.Store.GetSubmittedGames(status)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	if status == models.PENDING {
+		for i := range games {
+			confirmBy := h.confirmBy(games[i])
+			games[i].ConfirmBy = &confirmBy
+		}
+	}
+	c.IndentedJSON(http.StatusOK, games)
+}
+
+// audit records a change to the games in the audit log, made by the caller of the request.
+func (h *APIHandler) audit(c *gin.Context, e models.AuditLogEntry) {
+	p, _ := auth.GetPrincipal(c)
+	e.Actor = p.Name
+	if p.TokenID != 0 {
+		e.TokenID = &p.TokenID
+	}
+	h.recordAudit(e)
+}
+
+// auditGame records a change to a single game in the audit log.
+func (h *APIHandler) auditGame(c *gin.Context, action models.AuditAction, gameType models.GameType, id int, reason string) {
+	h.audit(c, models.AuditLogEntry{Action: action, GameType: &gameType, GameID: &id, Reason: reason})
+}
+
+// recordAudit stores an audit log entry. The change it records has already been made, so
+// a failure to record it is logged rather than failing the request.
+func (h *APIHandler) recordAudit(e models.AuditLogEntry) {
+	if err := h.Store.InsertAuditLogEntry(e); err != nil {
+		log.Printf("ERROR: failed to record '%v' by %v in the audit log: %v", e.Action, e.Actor, err)
+	}
+}
+
+// replaySinglesRatings recalculates the singles ratings after a game has been confirmed,
+// removed, put back or corrected, then rebuilds the achievements so that those the game
+// earned are taken away or given back.
+func (h *APIHandler) replaySinglesRatings() error {
+	err := utils.RecalculateEloRatings(h.Store, h.Config)
+	if err != nil {
+		return err
+	}
+
+	err = utils.RecalculateGlickoRatings(h.Store, h.Config)
+	if err != nil {
+		return err
+	}
+	h.publishLeaderboard()
+	return utils.RebuildAchievements(h.Store)
+}
+
+// replayDoublesRatings recalculates the doubles ratings after a doubles game has been
+// confirmed, removed, put back or recorded out of order. When doubles games count towards
+// the singles ratings, those are recalculated too and the rating milestones rebuilt.
+func (h *APIHandler) replayDoublesRatings() error {
+	err := utils.RecalculateDoublesEloRatings(h.Store, h.Config)
+	if err != nil {
+		return err
+	}
+	if !h.Config.Doubles.AffectsSingles {
+		return nil
+	}
+
+	err = utils.RecalculateEloRatings(h.Store, h.Config)
+	if err != nil {
+		return err
+	}
+	h.publishLeaderboard()
+	return utils.RebuildAchievements(h.Store)
+}
+
+// bindDeleteRequest reads the reason a game is being deleted.
+func bindDeleteRequest(c *gin.Context) (models.DeleteRequest, bool) {
+	var request models.DeleteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "a reason for deleting the game is required"})
+		return request, false
+	}
+	return request, true
+}
+
+// respondToGameChange responds to a delete or restore: 404 Not Found when there was no
+// game to change, otherwise the error.
+func respondToGameChange(c *gin.Context, err error) {
+	if errors.Is(err, exceptions.ErrGameNotFound) {
+		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+}
+
 func parsePositiveInteger(idString string) (int, error) {
 	if idString == "" {
 		return 0, fmt.Errorf("missing required parameter")
@@ -36,188 +295,985 @@ func parsePositiveInteger(idString string) (int, error) {
 	return id, nil
 }
 
+// parseTimeParameter reads an optional RFC 3339 time or YYYY-MM-DD date (in UTC). A date
+// given as the end of a range covers the whole of that day.
+func parseTimeParameter(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return t, fmt.Errorf("'%s' is not a valid date or time", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
 // ---------------------------------------- public API
 
-func (h *APIHandler) DeleteGame(c *gin.Context) {
-	gameId, err := parsePositiveInteger(c.Param("id"))
+// ConfirmExpiredGames confirms every pending game whose opponent has not responded within
+// the confirmation window. It is run periodically in the background.
+func (h *APIHandler) ConfirmExpiredGames() error {
+	pending, err := h.Store.GetSubmittedGames(models.PENDING)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
-		return
+		return err
+	}
+
+	expired := make([]models.SubmittedGame, 0)
+	for _, g := range pending {
+		if time.Now().After(h.confirmBy(g)) {
+			expired = append(expired, g)
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	for _, g := range expired {
+		err = h.updateGameStatus(g.Type, g.ID, models.CONFIRMED)
+		if err != nil {
+			return err
+		}
+
+		gameType, id := g.Type, g.ID
+		h.recordAudit(models.AuditLogEntry{
+			Action: models.AUDIT_CONFIRM, Actor: "system", GameType: &gameType, GameID: &id,
+			Reason: "not disputed within the confirmation window",
+		})
+		h.Events.Publish(events.GAME_RECORDED, events.Game{GameType: gameType, ID: id})
 	}
+	return h.rateConfirmedGames(expired)
+}
+
+func (h *APIHandler) ConfirmGame(c *gin.Context) {
+	h.confirmGame(c, models.SINGLES)
+}
 
-	err = h.Store.DeleteGame(gameId)
+func (h *APIHandler) ConfirmDoublesGame(c *gin.Context) {
+	h.confirmGame(c, models.DOUBLES)
+}
+
+// confirmGame confirms a pending or disputed game, which is then rated.
+func (h *APIHandler) confirmGame(c *gin.Context, gameType models.GameType) {
+	id, err := parsePositiveInteger(c.Param("id"))
 	if err != nil {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	err = utils.RecalculateEloRatings(h.Store)
+	game, err := h.getSubmittedGame(gameType, id)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		if errors.Is(err, exceptions.ErrGameNotFound) {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, gin.H{"message": "game deleted successfully"})
-}
+	p, _ := auth.GetPrincipal(c)
+	switch {
+	case game.Status == models.CONFIRMED:
+		c.IndentedJSON(http.StatusConflict, gin.H{"message": "game is already confirmed"})
+		return
+	case game.Status == models.DISPUTED && p.Role != models.ROLE_ADMIN:
+		c.IndentedJSON(http.StatusForbidden, gin.H{"message": "only an admin can confirm a disputed game"})
+		return
+	case !canRespond(p, game):
+		c.IndentedJSON(http.StatusForbidden, gin.H{"message": "only the opponent can confirm this game"})
+		return
+	}
 
-func (h *APIHandler) GetAchievements(c *gin.Context) {
-	achievements, err := h.Store.GetAchievements()
+	err = h.updateGameStatus(gameType, id, models.CONFIRMED)
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	c.IndentedJSON(http.StatusOK, achievements)
+	h.auditGame(c, models.AUDIT_CONFIRM, gameType, id, "")
+	h.Events.Publish(events.GAME_RECORDED, events.Game{GameType: gameType, ID: id})
+
+	err = h.rateConfirmedGames([]models.SubmittedGame{game})
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "game confirmed successfully"})
 }
 
-func (h *APIHandler) GetIndexPage(c *gin.Context) {
+func (h *APIHandler) DisputeGame(c *gin.Context) {
+	h.disputeGame(c, models.SINGLES)
+}
 
-	includeInactiveParam := c.DefaultQuery("includeInactive", "false")
-	includeInactive, err := strconv.ParseBool(includeInactiveParam)
+func (h *APIHandler) DisputeDoublesGame(c *gin.Context) {
+	h.disputeGame(c, models.DOUBLES)
+}
+
+// disputeGame marks a pending game as disputed, leaving it for an admin to confirm, correct
+// or delete.
+func (h *APIHandler) disputeGame(c *gin.Context, gameType models.GameType) {
+	id, err := parsePositiveInteger(c.Param("id"))
 	if err != nil {
-		c.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
 	}
 
-	data, err := h.Store.GetIndexPageData(includeInactive)
+	game, err := h.getSubmittedGame(gameType, id)
 	if err != nil {
+		if errors.Is(err, exceptions.ErrGameNotFound) {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	c.IndentedJSON(http.StatusOK, data)
+
+	if game.Status != models.PENDING {
+		c.IndentedJSON(http.StatusConflict, gin.H{"message": fmt.Sprintf("only pending games can be disputed, this game is %v", game.Status)})
+		return
+	}
+	if p, _ := auth.GetPrincipal(c); !canRespond(p, game) {
+		c.IndentedJSON(http.StatusForbidden, gin.H{"message": "only the opponent can dispute this game"})
+		return
+	}
+
+	err = h.updateGameStatus(gameType, id, models.DISPUTED)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	h.auditGame(c, models.AUDIT_DISPUTE, gameType, id, "")
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "game disputed successfully"})
 }
 
-func (h *APIHandler) GetPlayerProfile(c *gin.Context) {
+func (h *APIHandler) DeleteAPIToken(c *gin.Context) {
 	id, err := parsePositiveInteger(c.Param("id"))
 	if err != nil {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	profile, err := h.Store.GetPlayerProfile(id)
+	err = h.Store.DeleteAPIToken(id)
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": "player not found"})
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	c.IndentedJSON(http.StatusOK, profile)
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "token revoked successfully"})
 }
 
-func (h *APIHandler) GetGames(c *gin.Context) {
-	page, err := parsePositiveInteger(c.Query("page"))
+func (h *APIHandler) DeleteGame(c *gin.Context) {
+	gameId, err := parsePositiveInteger(c.Param("id"))
 	if err != nil {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	games, err := h.Store.GetGames(page)
+	request, ok := bindDeleteRequest(c)
+	if !ok {
+		return
+	}
+
+	err = h.Store.DeleteGame(gameId, request.Reason)
+	if err != nil {
+		respondToGameChange(c, err)
+		return
+	}
+	h.auditGame(c, models.AUDIT_DELETE, models.SINGLES, gameId, request.Reason)
+	h.Events.Publish(events.GAME_DELETED, events.Game{GameType: models.SINGLES, ID: gameId})
+
+	err = h.replaySinglesRatings()
 	if err != nil {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, games)
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "game deleted successfully"})
 }
 
-func (h *APIHandler) GetHeadToHead(c *gin.Context) {
-	p1, err := parsePositiveInteger(c.Query("p1"))
+func (h *APIHandler) DeleteDoublesGame(c *gin.Context) {
+	gameId, err := parsePositiveInteger(c.Param("id"))
 	if err != nil {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	p2, err := parsePositiveInteger(c.Query("p2"))
+	request, ok := bindDeleteRequest(c)
+	if !ok {
+		return
+	}
+
+	err = h.Store.DeleteDoublesGame(gameId, request.Reason)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		respondToGameChange(c, err)
 		return
 	}
+	h.auditGame(c, models.AUDIT_DELETE, models.DOUBLES, gameId, request.Reason)
+	h.Events.Publish(events.GAME_DELETED, events.Game{GameType: models.DOUBLES, ID: gameId})
 
-	if p1 == p2 {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "p1 and p2 must be different players"})
+	err = h.replayDoublesRatings()
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	headToHead, err := h.Store.GetHeadToHead(p1, p2)
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "doubles game deleted successfully"})
+}
+
+// ExportArchive downloads a copy of every player, game and achievement, for backups and
+// for moving between storage backends.
+func (h *APIHandler) ExportArchive(c *gin.Context) {
+	archive, err := utils.CreateArchive(h.Store)
 	if err != nil {
-		if errors.Is(err, exceptions.ErrNoGamesPlayed) {
-			c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
-			return
-		}
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, headToHead)
+	filename := fmt.Sprintf("luinc-pong-%v.json", archive.ExportedAt.Format(time.DateOnly))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.IndentedJSON(http.StatusOK, archive)
 }
 
-func (h *APIHandler) InsertPlayer(c *gin.Context) {
-	var name models.Name
-	err := c.BindJSON(&name)
+func (h *APIHandler) GetAchievements(c *gin.Context) {
+	achievements, err := utils.GetAchievementStats(h.Store)
 	if err != nil {
-		c.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	c.IndentedJSON(http.StatusOK, achievements)
+}
 
-	id, err := h.Store.InsertPlayer(name.Name)
+func (h *APIHandler) GetAchievement(c *gin.Context) {
+	id, err := parsePositiveInteger(c.Param("id"))
 	if err != nil {
-		c.IndentedJSON(
-			http.StatusBadRequest,
-			gin.H{"message": fmt.Sprintf("a player with name `%s` already exists", name.Name)},
-		)
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
-	c.IndentedJSON(http.StatusCreated, gin.H{"id": id})
-}
 
-func (h *APIHandler) RecalculateElo(c *gin.Context) {
-	err := utils.RecalculateEloRatings(h.Store)
+	achievement, err := utils.GetAchievementDetail(h.Store, id)
+	if errors.Is(err, exceptions.ErrAchievementNotFound) {
+		c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	c.IndentedJSON(http.StatusOK, gin.H{"message": "elo ratings recalculated successfully"})
+	c.IndentedJSON(http.StatusOK, achievement)
 }
 
-func (h *APIHandler) InsertGame(c *gin.Context) {
-	var result models.GameResult
-	err := c.BindJSON(&result)
+func (h *APIHandler) GetAPITokens(c *gin.Context) {
+	tokens, err := h.Store.GetAPITokens()
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	c.IndentedJSON(http.StatusOK, tokens)
+}
 
-	if result.WinnerID == result.LoserID {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "winnerId and loserId cannot be the same"})
+func (h *APIHandler) GetAuditLog(c *gin.Context) {
+	page := 1
+	if c.Query("page") != "" {
+		var err error
+		if page, err = parsePositiveInteger(c.Query("page")); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	filter, err := utils.ParseAuditLogFilter(c.Query("action"), c.Query("gameType"), c.Query("gameId"))
+	if err != nil {
+		invalidRequest(c, err)
 		return
 	}
 
-	id, err := h.Store.InsertGameResult(result)
+	entries, err := h.Store.GetAuditLog(filter, page)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	oldRatings, newRatings, err := utils.UpdatePlayersEloRating(h.Store, result.WinnerID, result.LoserID)
+	c.IndentedJSON(http.StatusOK, entries)
+}
+
+func (h *APIHandler) GetDeletedGames(c *gin.Context) {
+	games, err := h.Store.GetDeletedGames()
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
 
-	go func() {
+	c.IndentedJSON(http.StatusOK, games)
+}
 
-		// Recover is a built-in function that regains control of a panicking goroutine.
-		// Recover is only useful inside deferred functions.
-		// 	// During normal execution, a call to recover will return nil and have no other effect.
-		// 	// If the current goroutine is panicking, a call to recover will capture the value given
-		// 	// to panic and resume normal execution.
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC recovered in UpdatePlayerAchievements: %v", r)
-			}
-		}()
+func (h *APIHandler) GetIndexPage(c *gin.Context) {
 
-		err := utils.UpdatePlayerAchievements(h.Store, result, oldRatings, newRatings)
-		if err != nil {
-			log.Printf("ERROR: background update of player achievements failed: %v", err)
-		}
-	}()
+	includeInactiveParam := c.DefaultQuery("includeInactive", "false")
+	includeInactive, err := strconv.ParseBool(includeInactiveParam)
+	if err != nil {
+		c.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+	}
+
+	sort, err := utils.ParseLeaderboardSort(c.Query("sort"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	data, err := utils.GetIndexPageData(h.Store, h.Config, includeInactive, sort)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, data)
+}
+
+func (h *APIHandler) GetRatingConfig(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, h.Config.Ratings)
+}
+
+func (h *APIHandler) GetPlayerProfile(c *gin.Context) {
+	id, err := parsePositiveInteger(c.Param("id"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	profile, err := h.Store.GetPlayerProfile(id)
+	if err != nil {
+		c.IndentedJSON(http.StatusNotFound, gin.H{"message": "player not found"})
+		return
+	}
+
+	progress, ok := h.achievementProgress(c)
+	if !ok {
+		return
+	}
+	profile.AchievementProgress = slices.DeleteFunc(progress, func(p models.AchievementProgress) bool {
+		return p.Unlocked
+	})
+
+	c.IndentedJSON(http.StatusOK, profile)
+}
+
+// achievementProgress works out how far the player named in the URL has got towards every
+// achievement. It responds with an error and returns false if there is no such player.
+func (h *APIHandler) achievementProgress(c *gin.Context) ([]models.AchievementProgress, bool) {
+	id, err := parsePositiveInteger(c.Param("id"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return nil, false
+	}
+
+	players, err := h.Store.GetPlayerBasicInfo()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return nil, false
+	}
+	if !slices.ContainsFunc(players, func(p models.PlayerBasicInfo) bool { return p.ID == id }) {
+		c.IndentedJSON(http.StatusNotFound, gin.H{"message": "player not found"})
+		return nil, false
+	}
+
+	progress, err := utils.GetAchievementProgress(h.Store, id, h.Config.Ratings.Elo.StartRating)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return nil, false
+	}
+	return progress, true
+}
+
+func (h *APIHandler) GetPlayerAchievements(c *gin.Context) {
+	progress, ok := h.achievementProgress(c)
+	if !ok {
+		return
+	}
+	c.IndentedJSON(http.StatusOK, progress)
+}
+
+func (h *APIHandler) GetPlayerAchievementFamilies(c *gin.Context) {
+	progress, ok := h.achievementProgress(c)
+	if !ok {
+		return
+	}
+	c.IndentedJSON(http.StatusOK, utils.GroupAchievementFamilies(progress))
+}
+
+func (h *APIHandler) GetRatingHistory(c *gin.Context) {
+	id, err := parsePositiveInteger(c.Param("id"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	from, err := parseTimeParameter(c.Query("from"), false)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	to, err := parseTimeParameter(c.Query("to"), true)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	bucket, err := utils.ParseHistoryBucket(c.Query("bucket"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	changes, err := h.Store.GetRatingHistory(id)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, utils.BuildRatingHistory(changes, bucket, from, to))
+}
+
+func (h *APIHandler) GetGames(c *gin.Context) {
+	page, err := parsePositiveInteger(c.Query("page"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	games, err := h.Store.GetGames(page)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, games)
+}
+
+func (h *APIHandler) GetPendingGames(c *gin.Context) {
+	h.respondWithSubmittedGames(c, models.PENDING)
+}
+
+func (h *APIHandler) GetDisputedGames(c *gin.Context) {
+	h.respondWithSubmittedGames(c, models.DISPUTED)
+}
+
+func (h *APIHandler) GetHeadToHead(c *gin.Context) {
+	p1, err := parsePositiveInteger(c.Query("p1"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	p2, err := parsePositiveInteger(c.Query("p2"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if p1 == p2 {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "p1 and p2 must be different players"})
+		return
+	}
+
+	headToHead, err := h.Store.GetHeadToHead(p1, p2)
+	if err != nil {
+		if errors.Is(err, exceptions.ErrNoGamesPlayed) {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, headToHead)
+}
+
+func (h *APIHandler) InsertAPIToken(c *gin.Context) {
+	var t models.NewAPIToken
+	err := c.BindJSON(&t)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = utils.ValidateNewAPIToken(t)
+	if err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	token, err := auth.GenerateToken()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	id, err := h.Store.InsertAPIToken(auth.HashToken(token), t)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	// the token cannot be recovered from its hash, so this is the only time it is shown
+	c.IndentedJSON(http.StatusCreated, gin.H{"id": id, "token": token})
+}
+
+// ImportGames loads historical singles games from a CSV or JSON body, as given by its
+// Content-Type. Nothing is saved unless every game in the file is valid.
+func (h *APIHandler) ImportGames(c *gin.Context) {
+	format, err := utils.ParseImportFormat(c.ContentType())
+	if err != nil {
+		c.IndentedJSON(http.StatusUnsupportedMediaType, gin.H{"message": err.Error()})
+		return
+	}
+
+	games, err := utils.ReadImport(c.Request.Body, format, h.Config)
+	if err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	summary, err := utils.ImportGames(h.Store, h.Config, games)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	h.audit(c, models.AuditLogEntry{Action: models.AUDIT_IMPORT, Reason: utils.DescribeImport(summary)})
+	h.Events.Publish(events.RATINGS_RECALCULATED, struct{}{})
+	h.publishLeaderboard()
+
+	c.IndentedJSON(http.StatusCreated, summary)
+}
+
+func (h *APIHandler) InsertPlayer(c *gin.Context) {
+	var name models.Name
+	err := c.BindJSON(&name)
+	if err != nil {
+		c.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	id, err := h.Store.InsertPlayer(name.Name, h.Config.Ratings.Elo.StartRating)
+	if err != nil {
+		c.IndentedJSON(
+			http.StatusBadRequest,
+			gin.H{"message": fmt.Sprintf("a player with name `%s` already exists", name.Name)},
+		)
+		return
+	}
+	h.publishLeaderboard()
+
+	c.IndentedJSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// RebuildAchievements works out every player's achievements again from their whole history.
+func (h *APIHandler) RebuildAchievements(c *gin.Context) {
+	err := utils.RebuildAchievements(h.Store)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "achievements rebuilt successfully"})
+}
+
+func (h *APIHandler) RecalculateElo(c *gin.Context) {
+	err := utils.RecalculateRatings(h.Store, h.Config)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	h.audit(c, models.AuditLogEntry{Action: models.AUDIT_RECALCULATE})
+	h.Events.Publish(events.RATINGS_RECALCULATED, struct{}{})
+	h.publishLeaderboard()
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "elo ratings recalculated successfully"})
+}
+
+func (h *APIHandler) RestoreGame(c *gin.Context) {
+	gameId, err := parsePositiveInteger(c.Param("id"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.Store.RestoreGame(gameId)
+	if err != nil {
+		respondToGameChange(c, err)
+		return
+	}
+	h.auditGame(c, models.AUDIT_RESTORE, models.SINGLES, gameId, "")
+
+	err = h.replaySinglesRatings()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "game restored successfully"})
+}
+
+// RestoreArchive loads an archive made by ExportArchive into a store without players.
+func (h *APIHandler) RestoreArchive(c *gin.Context) {
+	archive, err := utils.ReadArchive(c.Request.Body)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = utils.RestoreArchive(h.Store, h.Config, archive)
+	var validationErr *exceptions.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		invalidRequest(c, err)
+		return
+	case errors.Is(err, exceptions.ErrStoreNotEmpty):
+		c.IndentedJSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	case err != nil:
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	h.Events.Publish(events.RATINGS_RECALCULATED, struct{}{})
+	h.publishLeaderboard()
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "archive restored successfully"})
+}
+
+func (h *APIHandler) RestoreDoublesGame(c *gin.Context) {
+	gameId, err := parsePositiveInteger(c.Param("id"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = h.Store.RestoreDoublesGame(gameId)
+	if err != nil {
+		respondToGameChange(c, err)
+		return
+	}
+	h.auditGame(c, models.AUDIT_RESTORE, models.DOUBLES, gameId, "")
+
+	err = h.replayDoublesRatings()
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "doubles game restored successfully"})
+}
+
+// StreamEvents sends the published events to the client as server-sent events until it
+// disconnects. A comment is sent every HEARTBEAT_INTERVAL so that idle connections are
+// not closed by proxies along the way.
+func (h *APIHandler) StreamEvents(c *gin.Context) {
+	subscription, unsubscribe := h.Events.Subscribe()
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(HEARTBEAT_INTERVAL)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case e, ok := <-subscription:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(e.Type), e.Data)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func (h *APIHandler) UpdateGame(c *gin.Context) {
+	id, err := parsePositiveInteger(c.Param("id"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	var update models.GameUpdate
+	err = c.BindJSON(&update)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	current, err := h.Store.GetSubmittedGame(id)
+	if err != nil {
+		if errors.Is(err, exceptions.ErrGameNotFound) {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	game, err := utils.ApplyGameUpdate(current.Game, update, h.Config)
+	if err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	changes := utils.DiffGames(utils.ToBaseGame(current.Game), game)
+	if len(changes) == 0 {
+		c.IndentedJSON(http.StatusOK, gin.H{"message": "game is unchanged"})
+		return
+	}
+
+	err = h.Store.UpdateGameResult(game)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	gameType := models.SINGLES
+	h.audit(c, models.AuditLogEntry{Action: models.AUDIT_UPDATE, GameType: &gameType, GameID: &id, Reason: update.Reason, Changes: changes})
+
+	// games awaiting confirmation have not been rated yet, and a corrected score may no
+	// longer earn what the recorded one did
+	if current.Status == models.CONFIRMED {
+		err = h.replaySinglesRatings()
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "game updated successfully"})
+}
+
+func (h *APIHandler) InsertGame(c *gin.Context) {
+	var result models.GameResult
+	err := c.BindJSON(&result)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if result.WinnerID == result.LoserID {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "winnerId and loserId cannot be the same"})
+		return
+	}
+
+	err = utils.ValidateGameResult(result, h.Config)
+	if err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	submittedBy, ok := h.submitter(c, result.WinnerID, result.LoserID)
+	if !ok {
+		return
+	}
+	if submittedBy != nil {
+		id, err := h.Store.InsertPendingGameResult(result, *submittedBy)
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		h.auditGame(c, models.AUDIT_CREATE, models.SINGLES, int(id), "")
+		c.IndentedJSON(http.StatusAccepted, gin.H{"id": id, "status": models.PENDING})
+		return
+	}
+
+	backdated, err := h.isBackdated(models.SINGLES, result.PlayedAt)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	id, err := h.Store.InsertGameResult(result)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	h.auditGame(c, models.AUDIT_CREATE, models.SINGLES, int(id), "")
+	h.Events.Publish(events.GAME_RECORDED, events.Game{GameType: models.SINGLES, ID: int(id)})
+
+	// every later game is replayed, so the achievements are rebuilt from the whole history
+	// rather than only checked for this game
+	if backdated {
+		err = h.replaySinglesRatings()
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusCreated, gin.H{"id": id})
+		return
+	}
+
+	_, _, err = utils.UpdatePlayersEloRating(h.Store, h.Config, int(id), result)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = utils.RecalculateGlickoRatings(h.Store, h.Config)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	h.publishLeaderboard()
+
+	go h.updatePlayerAchievements(result)
+
+	c.IndentedJSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *APIHandler) InsertMatch(c *gin.Context) {
+	var result models.MatchResult
+	err := c.BindJSON(&result)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if result.WinnerID == result.LoserID {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "winnerId and loserId cannot be the same"})
+		return
+	}
+
+	err = utils.ValidateMatch(result, h.Config)
+	if err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	submittedBy, ok := h.submitter(c, result.WinnerID, result.LoserID)
+	if !ok {
+		return
+	}
+	if submittedBy != nil {
+		id, err := h.Store.InsertPendingMatchResult(result, *submittedBy)
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		h.auditGame(c, models.AUDIT_CREATE, models.SINGLES, int(id), "")
+		c.IndentedJSON(http.StatusAccepted, gin.H{"id": id, "status": models.PENDING})
+		return
+	}
+
+	backdated, err := h.isBackdated(models.SINGLES, result.PlayedAt)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	id, err := h.Store.InsertMatchResult(result)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	h.auditGame(c, models.AUDIT_CREATE, models.SINGLES, int(id), "")
+	h.Events.Publish(events.GAME_RECORDED, events.Game{GameType: models.SINGLES, ID: int(id)})
+
+	// a match has no overall score, so score-based achievements are left to single games
+	game := models.GameResult{WinnerID: result.WinnerID, LoserID: result.LoserID}
+
+	if backdated {
+		err = h.replaySinglesRatings()
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusCreated, gin.H{"id": id})
+		return
+	}
+
+	_, _, err = utils.UpdatePlayersMatchEloRating(h.Store, h.Config, int(id), result)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = utils.RecalculateGlickoRatings(h.Store, h.Config)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	h.publishLeaderboard()
+
+	go h.updatePlayerAchievements(game)
+
+	c.IndentedJSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *APIHandler) InsertDoublesGame(c *gin.Context) {
+	var result models.DoublesGameResult
+	err := c.BindJSON(&result)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	players := slices.Concat(result.WinnerIDs, result.LoserIDs)
+	slices.Sort(players)
+	if len(slices.Compact(players)) != 4 {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "a doubles game needs four different players"})
+		return
+	}
+
+	scores := models.GameResult{WinnerScore: result.WinnerScore, LoserScore: result.LoserScore, PlayedAt: result.PlayedAt}
+	err = utils.ValidateGameResult(scores, h.Config)
+	if err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	submittedBy, ok := h.submitter(c, players...)
+	if !ok {
+		return
+	}
+	if submittedBy != nil {
+		id, err := h.Store.InsertPendingDoublesGameResult(result, *submittedBy)
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		h.auditGame(c, models.AUDIT_CREATE, models.DOUBLES, int(id), "")
+		c.IndentedJSON(http.StatusAccepted, gin.H{"id": id, "status": models.PENDING})
+		return
+	}
+
+	backdated, err := h.isBackdated(models.DOUBLES, result.PlayedAt)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	id, err := h.Store.InsertDoublesGameResult(result)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	h.auditGame(c, models.AUDIT_CREATE, models.DOUBLES, int(id), "")
+	h.Events.Publish(events.GAME_RECORDED, events.Game{GameType: models.DOUBLES, ID: int(id)})
+
+	if backdated {
+		err = h.replayDoublesRatings()
+	} else {
+		err = utils.UpdatePlayersDoublesEloRating(h.Store, h.Config, int(id), result)
+	}
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if !backdated && h.Config.Doubles.AffectsSingles {
+		h.publishLeaderboard()
+	}
 
 	c.IndentedJSON(http.StatusCreated, gin.H{"id": id})
 }
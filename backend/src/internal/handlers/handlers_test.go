@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/stores"
+)
+
+// -------------------------------------------------------------------------------- Test Helpers
+
+func intPointer(i int) *int {
+	return &i
+}
+
+// pendingGames returns the IDs of the games awaiting confirmation.
+func pendingGames(t *testing.T, h *APIHandler) []int {
+	t.Helper()
+	games, err := h.Store.GetSubmittedGames(models.PENDING)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := make([]int, 0, len(games))
+	for _, g := range games {
+		ids = append(ids, g.ID)
+	}
+	return ids
+}
+
+// -------------------------------------------------------------------------------- Tests
+
+func TestConfirmExpiredGamesKeepsBackdatedGamesPending(t *testing.T) {
+	h := &APIHandler{Store: stores.CreateMemoryStore(), Config: config.Default()}
+	alice, _ := h.Store.InsertPlayer("Alice", 1000)
+	bob, _ := h.Store.InsertPlayer("Bob", 1000)
+
+	// played longer ago than the confirmation window, but only just recorded
+	playedAt := time.Now().Add(-time.Duration(h.Config.Confirmation.WindowHours+12) * time.Hour)
+	result := models.GameResult{WinnerID: int(alice), LoserID: int(bob), WinnerScore: intPointer(11), LoserScore: intPointer(7), PlayedAt: &playedAt}
+	id, err := h.Store.InsertPendingGameResult(result, int(alice))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := h.ConfirmExpiredGames(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pending := pendingGames(t, h); len(pending) != 1 || pending[0] != int(id) {
+		t.Errorf("expected the backdated game to stay pending, got %v", pending)
+	}
+}
+
+func TestConfirmExpiredGamesConfirmsGamesPastTheWindow(t *testing.T) {
+	h := &APIHandler{Store: stores.CreateMemoryStore(), Config: config.Default()}
+
+	submittedAt := time.Now().Add(-time.Duration(h.Config.Confirmation.WindowHours+1) * time.Hour).UTC()
+	archive := models.Archive{
+		Players: []models.ArchivePlayer{{ID: 1, Name: "Alice", CreatedAt: submittedAt}, {ID: 2, Name: "Bob", CreatedAt: submittedAt}},
+		Games: []models.ArchiveGame{
+			{ID: 1, WinnerID: 1, LoserID: 2, Status: models.PENDING, SubmittedBy: intPointer(1), SubmittedAt: submittedAt, CreatedAt: submittedAt},
+		},
+	}
+	if err := h.Store.RestoreArchive(archive, h.Config.Ratings.Elo.StartRating); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := h.ConfirmExpiredGames(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pending := pendingGames(t, h); len(pending) != 0 {
+		t.Errorf("expected the game to be confirmed once the window has passed, got %v pending", pending)
+	}
+}
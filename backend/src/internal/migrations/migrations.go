@@ -0,0 +1,363 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// -------------------------------------------------------------------------------- constants & types
+
+// Migrations live in a directory per SQL dialect and are named
+// NNNN_description.up.sql / NNNN_description.down.sql.
+//
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Dialect string
+
+const (
+	MYSQL  Dialect = "mysql"
+	SQLITE Dialect = "sqlite"
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies the embedded migrations for a dialect to a database and keeps
+// the `achievement` table in step with the achievement catalogue.
+type Migrator struct {
+	DB           *sql.DB
+	Dialect      Dialect
+	Achievements []models.Achievement
+
+	// When DryRun is set, pending changes are logged but never applied.
+	DryRun bool
+}
+
+// -------------------------------------------------------------------------------- queries
+
+var CREATE_MIGRATIONS_TABLE_QUERY = map[Dialect]string{
+	MYSQL: `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (version))
+ENGINE = InnoDB;
+`,
+	SQLITE: `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`,
+}
+
+const SELECT_APPLIED_VERSIONS_QUERY string = `
+SELECT
+	version
+FROM
+	schema_migrations
+ORDER BY version ASC;
+`
+
+const INSERT_MIGRATION_QUERY string = `
+INSERT INTO schema_migrations (version, name)
+VALUES (?, ?);
+`
+
+const DELETE_MIGRATION_QUERY string = `
+DELETE FROM schema_migrations
+WHERE
+	version = ?;
+`
+
+const SELECT_ACHIEVEMENT_ROWS_QUERY string = `
+SELECT
+	id, title, description, family, tier, points
+FROM
+	achievement;
+`
+
+var UPSERT_ACHIEVEMENT_QUERY = map[Dialect]string{
+	MYSQL: `
+INSERT INTO achievement (id, title, description, family, tier, points)
+VALUES (?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+	title = VALUES(title),
+	description = VALUES(description),
+	family = VALUES(family),
+	tier = VALUES(tier),
+	points = VALUES(points);
+`,
+	SQLITE: `
+INSERT INTO achievement (id, title, description, family, tier, points)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+	title = excluded.title,
+	description = excluded.description,
+	family = excluded.family,
+	tier = excluded.tier,
+	points = excluded.points;
+`,
+}
+
+// -------------------------------------------------------------------------------- public functions
+
+// Load reads the embedded migrations for a dialect, ordered by version.
+func Load(dialect Dialect) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, string(dialect))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %v migrations: %v", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name '%v'", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		contents, err := fs.ReadFile(files, path.Join(string(dialect), entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration '%v': %v", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names '%v' and '%v'", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%v has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+	return migrations, nil
+}
+
+// Pending returns the migrations that have not been applied yet, oldest first.
+func (m *Migrator) Pending() ([]Migration, error) {
+	migrations, applied, err := m.state()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0)
+	for _, migration := range migrations {
+		if !slices.Contains(applied, migration.Version) {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in version order, then synchronises the
+// achievement catalogue. Each migration runs in its own transaction; note that
+// MySQL implicitly commits DDL statements, so a failing MySQL migration may need
+// to be cleaned up by hand.
+func (m *Migrator) Up() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range pending {
+		if m.DryRun {
+			log.Printf("migrations: would apply %04d_%v", migration.Version, migration.Name)
+			continue
+		}
+		err := m.run(migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(INSERT_MIGRATION_QUERY, migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error applying migration %04d_%v: %v", migration.Version, migration.Name, err)
+		}
+		log.Printf("migrations: applied %04d_%v", migration.Version, migration.Name)
+	}
+
+	return m.syncAchievements()
+}
+
+// Down reverts the most recently applied migrations, newest first.
+func (m *Migrator) Down(steps int) error {
+	migrations, applied, err := m.state()
+	if err != nil {
+		return err
+	}
+
+	for i := len(applied) - 1; i >= 0 && steps > 0; i-- {
+		idx := slices.IndexFunc(migrations, func(migration Migration) bool {
+			return migration.Version == applied[i]
+		})
+		if idx == -1 {
+			return fmt.Errorf("applied migration %d is not embedded in this binary", applied[i])
+		}
+		migration := migrations[idx]
+		steps--
+
+		if m.DryRun {
+			log.Printf("migrations: would revert %04d_%v", migration.Version, migration.Name)
+			continue
+		}
+		if migration.Down == "" {
+			return fmt.Errorf("migration %04d_%v has no down script", migration.Version, migration.Name)
+		}
+		err := m.run(migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(DELETE_MIGRATION_QUERY, migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error reverting migration %04d_%v: %v", migration.Version, migration.Name, err)
+		}
+		log.Printf("migrations: reverted %04d_%v", migration.Version, migration.Name)
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------------------- private functions
+
+// state returns every embedded migration and the versions already applied.
+func (m *Migrator) state() ([]Migration, []int, error) {
+	migrations, err := Load(m.Dialect)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := m.DB.Exec(CREATE_MIGRATIONS_TABLE_QUERY[m.Dialect]); err != nil {
+		return nil, nil, fmt.Errorf("error creating schema_migrations table: %v", err)
+	}
+
+	rows, err := m.DB.Query(SELECT_APPLIED_VERSIONS_QUERY)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching applied migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make([]int, 0)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, nil, fmt.Errorf("error fetching applied migrations: %v", err)
+		}
+		applied = append(applied, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error fetching applied migrations: %v", err)
+	}
+
+	return migrations, applied, nil
+}
+
+// run executes a migration script and its bookkeeping in a single transaction.
+func (m *Migrator) run(script string, record func(tx *sql.Tx) error) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// syncAchievements inserts achievements added to the catalogue and updates any
+// that have changed.
+func (m *Migrator) syncAchievements() error {
+	existing := make(map[int]models.Achievement)
+
+	rows, err := m.DB.Query(SELECT_ACHIEVEMENT_ROWS_QUERY)
+	if err != nil {
+		if m.DryRun {
+			// the achievement table may not exist until the pending migrations are applied
+			log.Printf("migrations: would seed %d achievements", len(m.Achievements))
+			return nil
+		}
+		return fmt.Errorf("error fetching achievements: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Achievement
+		if err := rows.Scan(&a.ID, &a.Title, &a.Description, &a.Family, &a.Tier, &a.Points); err != nil {
+			return fmt.Errorf("error fetching achievements: %v", err)
+		}
+		existing[a.ID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error fetching achievements: %v", err)
+	}
+
+	changed := make([]models.Achievement, 0)
+	for _, a := range m.Achievements {
+		if current, ok := existing[a.ID]; !ok || current != a {
+			changed = append(changed, a)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if m.DryRun {
+		for _, a := range changed {
+			log.Printf("migrations: would seed achievement %d '%v'", a.ID, a.Title)
+		}
+		return nil
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error seeding achievements: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, a := range changed {
+		if _, err := tx.Exec(UPSERT_ACHIEVEMENT_QUERY[m.Dialect], a.ID, a.Title, a.Description, a.Family, a.Tier, a.Points); err != nil {
+			return fmt.Errorf("error seeding achievement %d: %v", a.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error seeding achievements: %v", err)
+	}
+	log.Printf("migrations: seeded %d achievements", len(changed))
+	return nil
+}
@@ -0,0 +1,164 @@
+package migrations
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/jda5/luinc-pong/src/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+// -------------------------------------------------------------------------------- Test Helpers
+
+func createMigrator(t *testing.T, achievements []models.Achievement) *Migrator {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("unexpected error opening database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return &Migrator{DB: db, Dialect: SQLITE, Achievements: achievements}
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return count == 1
+}
+
+var achievements = []models.Achievement{
+	{ID: 1, Title: "Warming Up", Description: "Play your first game"},
+	{ID: 2, Title: "Minimum Viable Pong", Description: "Play 10 games"},
+}
+
+// -------------------------------------------------------------------------------- Tests
+
+func TestDialectsHaveMatchingVersions(t *testing.T) {
+	mysql, err := Load(MYSQL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sqlite, err := Load(SQLITE)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mysql) != len(sqlite) {
+		t.Fatalf("expected the same number of migrations, got %d mysql and %d sqlite", len(mysql), len(sqlite))
+	}
+	for i := range mysql {
+		if mysql[i].Version != sqlite[i].Version || mysql[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d differs between dialects: %v vs %v", i, mysql[i].Name, sqlite[i].Name)
+		}
+		if mysql[i].Down == "" || sqlite[i].Down == "" {
+			t.Errorf("migration %04d_%v is missing a down script", mysql[i].Version, mysql[i].Name)
+		}
+	}
+}
+
+func TestUpAppliesPendingMigrationsOnce(t *testing.T) {
+	m := createMigrator(t, achievements)
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !tableExists(t, m.DB, "games") {
+		t.Errorf("expected the games table to exist")
+	}
+
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected no pending migrations, got %d", len(pending))
+	}
+
+	// running again is a no-op
+	if err := m.Up(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDryRunDoesNotApply(t *testing.T) {
+	m := createMigrator(t, achievements)
+	m.DryRun = true
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tableExists(t, m.DB, "games") {
+		t.Errorf("expected the games table not to be created in dry-run mode")
+	}
+}
+
+func TestDownRevertsMigrations(t *testing.T) {
+	m := createMigrator(t, achievements)
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	migrations, err := Load(SQLITE)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Down(len(migrations)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tableExists(t, m.DB, "games") {
+		t.Errorf("expected the games table to be dropped")
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != len(migrations) {
+		t.Errorf("expected every migration to be pending, got %d", len(pending))
+	}
+}
+
+func TestUpSynchronisesAchievements(t *testing.T) {
+	m := createMigrator(t, achievements)
+	if err := m.Up(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a new achievement is added and an existing one renamed and given points
+	m.Achievements = []models.Achievement{
+		{ID: 1, Title: "Warm Up", Description: "Play your first game", Family: "gameCount", Tier: 1, Points: 5},
+		achievements[1],
+		{ID: 3, Title: "Regular", Description: "Play 50 games"},
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := m.DB.Query(SELECT_ACHIEVEMENT_ROWS_QUERY)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[int]models.Achievement)
+	for rows.Next() {
+		var a models.Achievement
+		if err := rows.Scan(&a.ID, &a.Title, &a.Description, &a.Family, &a.Tier, &a.Points); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		stored[a.ID] = a
+	}
+
+	if len(stored) != 3 {
+		t.Errorf("expected 3 achievements, got %d", len(stored))
+	}
+	if stored[1] != m.Achievements[0] {
+		t.Errorf("expected achievement 1 to be updated, got %+v", stored[1])
+	}
+}
@@ -0,0 +1,66 @@
+CREATE TABLE IF NOT EXISTS `players` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(63) NOT NULL,
+  `elo_rating` DOUBLE NOT NULL DEFAULT 1000,
+  `highest_elo` DOUBLE NOT NULL DEFAULT 1000,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `name_UNIQUE` (`name` ASC) VISIBLE,
+  INDEX `idx_elo_rating` (`elo_rating` ASC) COMMENT 'For quick sorting' VISIBLE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+CREATE TABLE IF NOT EXISTS `games` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `winner_id` INT NOT NULL,
+  `loser_id` INT NOT NULL,
+  `winner_score` TINYINT UNSIGNED NULL,
+  `loser_score` TINYINT UNSIGNED NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `fk_player_winner_id_idx` (`winner_id` ASC) VISIBLE,
+  INDEX `fk_player_loser_id_idx` (`loser_id` ASC) VISIBLE,
+  INDEX `idx_created_at` (`created_at` ASC) COMMENT 'Speeds up look-up on game history' VISIBLE,
+  INDEX `idx_game_players` (`winner_id` ASC, `loser_id` ASC) COMMENT 'For fast lookups on games involving two players.' VISIBLE,
+  CONSTRAINT `fk_player_winner_id`
+    FOREIGN KEY (`winner_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `fk_player_loser_id`
+    FOREIGN KEY (`loser_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+CREATE TABLE IF NOT EXISTS `achievement` (
+  `id` INT NOT NULL,
+  `title` VARCHAR(63) NOT NULL,
+  `description` VARCHAR(255) NOT NULL,
+  PRIMARY KEY (`id`))
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+CREATE TABLE IF NOT EXISTS `player_achievement` (
+  `player_id` INT NOT NULL,
+  `achievement_id` INT NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX `fk_player_achievements_players1_idx` (`player_id` ASC) VISIBLE,
+  INDEX `fk_player_achievements_achievement1_idx` (`achievement_id` ASC) VISIBLE,
+  UNIQUE INDEX `UNIQUE_ player_achievement_id` (`player_id` ASC, `achievement_id` ASC) VISIBLE,
+  INDEX `idx_achievement_created_at` (`created_at` ASC) COMMENT 'For fast sorting!' VISIBLE,
+  CONSTRAINT `fk_player_achievements_players1`
+    FOREIGN KEY (`player_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION,
+  CONSTRAINT `fk_player_achievements_achievement1`
+    FOREIGN KEY (`achievement_id`)
+    REFERENCES `achievement` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
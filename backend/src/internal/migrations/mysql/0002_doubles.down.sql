@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `doubles_games`;
+
+ALTER TABLE `players`
+  DROP COLUMN `doubles_elo_rating`;
@@ -0,0 +1,40 @@
+ALTER TABLE `players`
+  ADD COLUMN `doubles_elo_rating` DOUBLE NOT NULL DEFAULT 1000 AFTER `highest_elo`;
+
+CREATE TABLE IF NOT EXISTS `doubles_games` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `winner1_id` INT NOT NULL,
+  `winner2_id` INT NOT NULL,
+  `loser1_id` INT NOT NULL,
+  `loser2_id` INT NOT NULL,
+  `winner_score` TINYINT UNSIGNED NULL,
+  `loser_score` TINYINT UNSIGNED NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `fk_doubles_winner1_id_idx` (`winner1_id` ASC) VISIBLE,
+  INDEX `fk_doubles_winner2_id_idx` (`winner2_id` ASC) VISIBLE,
+  INDEX `fk_doubles_loser1_id_idx` (`loser1_id` ASC) VISIBLE,
+  INDEX `fk_doubles_loser2_id_idx` (`loser2_id` ASC) VISIBLE,
+  INDEX `idx_doubles_created_at` (`created_at` ASC) VISIBLE,
+  CONSTRAINT `fk_doubles_winner1_id`
+    FOREIGN KEY (`winner1_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `fk_doubles_winner2_id`
+    FOREIGN KEY (`winner2_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `fk_doubles_loser1_id`
+    FOREIGN KEY (`loser1_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `fk_doubles_loser2_id`
+    FOREIGN KEY (`loser2_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
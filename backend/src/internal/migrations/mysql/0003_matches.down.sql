@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `game_sets`;
+
+ALTER TABLE `games`
+  DROP COLUMN `best_of`;
@@ -0,0 +1,16 @@
+ALTER TABLE `games`
+  ADD COLUMN `best_of` TINYINT UNSIGNED NULL AFTER `loser_score`;
+
+CREATE TABLE IF NOT EXISTS `game_sets` (
+  `game_id` INT NOT NULL,
+  `set_number` TINYINT UNSIGNED NOT NULL,
+  `winner_score` TINYINT UNSIGNED NOT NULL COMMENT 'Points scored by the match winner',
+  `loser_score` TINYINT UNSIGNED NOT NULL COMMENT 'Points scored by the match loser',
+  PRIMARY KEY (`game_id`, `set_number`),
+  CONSTRAINT `fk_game_sets_game_id`
+    FOREIGN KEY (`game_id`)
+    REFERENCES `games` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
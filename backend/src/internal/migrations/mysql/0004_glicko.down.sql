@@ -0,0 +1,4 @@
+ALTER TABLE `players`
+  DROP COLUMN `glicko_volatility`,
+  DROP COLUMN `glicko_deviation`,
+  DROP COLUMN `glicko_rating`;
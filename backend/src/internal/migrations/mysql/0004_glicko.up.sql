@@ -0,0 +1,4 @@
+ALTER TABLE `players`
+  ADD COLUMN `glicko_rating` DOUBLE NOT NULL DEFAULT 1000 AFTER `doubles_elo_rating`,
+  ADD COLUMN `glicko_deviation` DOUBLE NOT NULL DEFAULT 350 AFTER `glicko_rating`,
+  ADD COLUMN `glicko_volatility` DOUBLE NOT NULL DEFAULT 0.06 AFTER `glicko_deviation`;
@@ -0,0 +1,30 @@
+-- one row per player per game that moved their singles Elo rating; doubles games only
+-- appear when they are configured to affect singles ratings
+CREATE TABLE IF NOT EXISTS `rating_history` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `player_id` INT NOT NULL,
+  `game_id` INT NULL,
+  `doubles_game_id` INT NULL,
+  `rating_before` DOUBLE NOT NULL,
+  `rating_after` DOUBLE NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `fk_rating_history_player_id_idx` (`player_id` ASC) VISIBLE,
+  INDEX `fk_rating_history_game_id_idx` (`game_id` ASC) VISIBLE,
+  INDEX `fk_rating_history_doubles_game_id_idx` (`doubles_game_id` ASC) VISIBLE,
+  CONSTRAINT `fk_rating_history_player_id`
+    FOREIGN KEY (`player_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `fk_rating_history_game_id`
+    FOREIGN KEY (`game_id`)
+    REFERENCES `games` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `fk_rating_history_doubles_game_id`
+    FOREIGN KEY (`doubles_game_id`)
+    REFERENCES `doubles_games` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
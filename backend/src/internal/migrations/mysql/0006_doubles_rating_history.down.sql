@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `doubles_rating_history`;
@@ -0,0 +1,22 @@
+-- one row per player per doubles game, recording how it moved their doubles Elo rating
+CREATE TABLE IF NOT EXISTS `doubles_rating_history` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `player_id` INT NOT NULL,
+  `doubles_game_id` INT NOT NULL,
+  `rating_before` DOUBLE NOT NULL,
+  `rating_after` DOUBLE NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `fk_doubles_rating_history_player_id_idx` (`player_id` ASC) VISIBLE,
+  INDEX `fk_doubles_rating_history_doubles_game_id_idx` (`doubles_game_id` ASC) VISIBLE,
+  CONSTRAINT `fk_doubles_rating_history_player_id`
+    FOREIGN KEY (`player_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `fk_doubles_rating_history_doubles_game_id`
+    FOREIGN KEY (`doubles_game_id`)
+    REFERENCES `doubles_games` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
@@ -0,0 +1,19 @@
+-- API tokens are stored as SHA-256 hashes, so a leaked database does not leak tokens.
+-- Player tokens act on behalf of their player; admin tokens may belong to no player.
+CREATE TABLE IF NOT EXISTS `api_tokens` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `token_hash` CHAR(64) NOT NULL,
+  `name` VARCHAR(63) NOT NULL,
+  `role` VARCHAR(15) NOT NULL,
+  `player_id` INT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `token_hash_UNIQUE` (`token_hash` ASC) VISIBLE,
+  INDEX `fk_api_tokens_player_id_idx` (`player_id` ASC) VISIBLE,
+  CONSTRAINT `fk_api_tokens_player_id`
+    FOREIGN KEY (`player_id`)
+    REFERENCES `players` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
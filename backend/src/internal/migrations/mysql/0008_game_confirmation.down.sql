@@ -0,0 +1,6 @@
+ALTER TABLE `games`
+  DROP FOREIGN KEY `fk_games_submitted_by`,
+  DROP INDEX `fk_games_submitted_by_idx`,
+  DROP INDEX `idx_status`,
+  DROP COLUMN `submitted_by`,
+  DROP COLUMN `status`;
@@ -0,0 +1,12 @@
+-- games recorded by a player stay pending until their opponent confirms them, and only
+-- confirmed games count; games recorded before confirmation existed are confirmed
+ALTER TABLE `games`
+  ADD COLUMN `status` VARCHAR(15) NOT NULL DEFAULT 'confirmed' AFTER `best_of`,
+  ADD COLUMN `submitted_by` INT NULL AFTER `status`,
+  ADD INDEX `idx_status` (`status` ASC) VISIBLE,
+  ADD INDEX `fk_games_submitted_by_idx` (`submitted_by` ASC) VISIBLE,
+  ADD CONSTRAINT `fk_games_submitted_by`
+    FOREIGN KEY (`submitted_by`)
+    REFERENCES `players` (`id`)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE;
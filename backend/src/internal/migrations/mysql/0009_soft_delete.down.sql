@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS `audit_log`;
+
+ALTER TABLE `doubles_games`
+  DROP COLUMN `delete_reason`,
+  DROP COLUMN `deleted_at`;
+
+ALTER TABLE `games`
+  DROP COLUMN `delete_reason`,
+  DROP COLUMN `deleted_at`;
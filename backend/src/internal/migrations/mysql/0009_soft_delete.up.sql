@@ -0,0 +1,24 @@
+-- deleted games are kept, with the reason they were deleted, so they can be restored
+ALTER TABLE `games`
+  ADD COLUMN `deleted_at` TIMESTAMP NULL AFTER `submitted_by`,
+  ADD COLUMN `delete_reason` VARCHAR(255) NULL AFTER `deleted_at`;
+
+ALTER TABLE `doubles_games`
+  ADD COLUMN `deleted_at` TIMESTAMP NULL AFTER `loser_score`,
+  ADD COLUMN `delete_reason` VARCHAR(255) NULL AFTER `deleted_at`;
+
+-- every change to the games is recorded along with who made it. The actor is kept as
+-- text rather than a reference to a token, so entries outlive revoked tokens.
+CREATE TABLE IF NOT EXISTS `audit_log` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `action` VARCHAR(15) NOT NULL,
+  `actor` VARCHAR(63) NOT NULL,
+  `token_id` INT NULL,
+  `game_type` VARCHAR(15) NULL,
+  `game_id` INT NULL,
+  `reason` VARCHAR(255) NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_game` (`game_type` ASC, `game_id` ASC) VISIBLE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
@@ -0,0 +1,4 @@
+-- corrections to a game keep its previous values in the audit log, as a JSON object
+-- from each changed field to its old and new value
+ALTER TABLE `audit_log`
+  ADD COLUMN `changes` TEXT NULL AFTER `reason`;
@@ -0,0 +1,4 @@
+ALTER TABLE `achievement`
+  DROP COLUMN `points`,
+  DROP COLUMN `tier`,
+  DROP COLUMN `family`;
@@ -0,0 +1,6 @@
+-- the achievements of a family are the tiers of one progression, and every achievement
+-- is worth points towards a player's achievement score
+ALTER TABLE `achievement`
+  ADD COLUMN `family` VARCHAR(63) NOT NULL DEFAULT '' AFTER `description`,
+  ADD COLUMN `tier` INT NOT NULL DEFAULT 0 AFTER `family`,
+  ADD COLUMN `points` INT NOT NULL DEFAULT 0 AFTER `tier`;
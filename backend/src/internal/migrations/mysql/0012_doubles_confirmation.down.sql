@@ -0,0 +1,6 @@
+ALTER TABLE `doubles_games`
+  DROP FOREIGN KEY `fk_doubles_submitted_by`,
+  DROP INDEX `fk_doubles_submitted_by_idx`,
+  DROP INDEX `idx_doubles_status`,
+  DROP COLUMN `submitted_by`,
+  DROP COLUMN `status`;
@@ -0,0 +1,12 @@
+-- doubles games recorded by a player stay pending until one of their opponents confirms
+-- them, as singles games do; games recorded before then are confirmed
+ALTER TABLE `doubles_games`
+  ADD COLUMN `status` VARCHAR(15) NOT NULL DEFAULT 'confirmed' AFTER `loser_score`,
+  ADD COLUMN `submitted_by` INT NULL AFTER `status`,
+  ADD INDEX `idx_doubles_status` (`status` ASC) VISIBLE,
+  ADD INDEX `fk_doubles_submitted_by_idx` (`submitted_by` ASC) VISIBLE,
+  ADD CONSTRAINT `fk_doubles_submitted_by`
+    FOREIGN KEY (`submitted_by`)
+    REFERENCES `players` (`id`)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE `doubles_games` DROP COLUMN `submitted_at`;
+ALTER TABLE `games` DROP COLUMN `submitted_at`;
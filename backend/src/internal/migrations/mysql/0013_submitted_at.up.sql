@@ -0,0 +1,10 @@
+-- created_at is when a game was played, which may be backdated, so the confirmation window
+-- is measured from when it was submitted instead; existing games were submitted when played
+ALTER TABLE `games`
+  ADD COLUMN `submitted_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP AFTER `submitted_by`;
+
+ALTER TABLE `doubles_games`
+  ADD COLUMN `submitted_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP AFTER `submitted_by`;
+
+UPDATE `games` SET `submitted_at` = `created_at`;
+UPDATE `doubles_games` SET `submitted_at` = `created_at`;
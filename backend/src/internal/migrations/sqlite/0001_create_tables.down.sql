@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS player_achievement;
+DROP TABLE IF EXISTS achievement;
+DROP TABLE IF EXISTS games;
+DROP TABLE IF EXISTS players;
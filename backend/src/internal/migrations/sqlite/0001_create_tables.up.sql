@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS players (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(63) NOT NULL COLLATE NOCASE UNIQUE,
+  elo_rating DOUBLE NOT NULL DEFAULT 1000,
+  highest_elo DOUBLE NOT NULL DEFAULT 1000,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_elo_rating ON players (elo_rating);
+
+CREATE TABLE IF NOT EXISTS games (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  winner_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  loser_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  winner_score TINYINT UNSIGNED NULL CHECK (winner_score BETWEEN 0 AND 255),
+  loser_score TINYINT UNSIGNED NULL CHECK (loser_score BETWEEN 0 AND 255),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS fk_player_winner_id_idx ON games (winner_id);
+CREATE INDEX IF NOT EXISTS fk_player_loser_id_idx ON games (loser_id);
+CREATE INDEX IF NOT EXISTS idx_created_at ON games (created_at);
+CREATE INDEX IF NOT EXISTS idx_game_players ON games (winner_id, loser_id);
+
+CREATE TABLE IF NOT EXISTS achievement (
+  id INTEGER NOT NULL PRIMARY KEY,
+  title VARCHAR(63) NOT NULL,
+  description VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS player_achievement (
+  player_id INTEGER NOT NULL REFERENCES players (id),
+  achievement_id INTEGER NOT NULL REFERENCES achievement (id),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (player_id, achievement_id)
+);
+
+CREATE INDEX IF NOT EXISTS fk_player_achievements_players1_idx ON player_achievement (player_id);
+CREATE INDEX IF NOT EXISTS fk_player_achievements_achievement1_idx ON player_achievement (achievement_id);
+CREATE INDEX IF NOT EXISTS idx_achievement_created_at ON player_achievement (created_at);
@@ -0,0 +1,18 @@
+ALTER TABLE players ADD COLUMN doubles_elo_rating DOUBLE NOT NULL DEFAULT 1000;
+
+CREATE TABLE IF NOT EXISTS doubles_games (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  winner1_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  winner2_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  loser1_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  loser2_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  winner_score TINYINT UNSIGNED NULL CHECK (winner_score BETWEEN 0 AND 255),
+  loser_score TINYINT UNSIGNED NULL CHECK (loser_score BETWEEN 0 AND 255),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS fk_doubles_winner1_id_idx ON doubles_games (winner1_id);
+CREATE INDEX IF NOT EXISTS fk_doubles_winner2_id_idx ON doubles_games (winner2_id);
+CREATE INDEX IF NOT EXISTS fk_doubles_loser1_id_idx ON doubles_games (loser1_id);
+CREATE INDEX IF NOT EXISTS fk_doubles_loser2_id_idx ON doubles_games (loser2_id);
+CREATE INDEX IF NOT EXISTS idx_doubles_created_at ON doubles_games (created_at);
@@ -0,0 +1,10 @@
+ALTER TABLE games ADD COLUMN best_of TINYINT UNSIGNED NULL;
+
+-- scores are from the point of view of the match winner and loser
+CREATE TABLE IF NOT EXISTS game_sets (
+  game_id INTEGER NOT NULL REFERENCES games (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  set_number TINYINT UNSIGNED NOT NULL,
+  winner_score TINYINT UNSIGNED NOT NULL CHECK (winner_score BETWEEN 0 AND 255),
+  loser_score TINYINT UNSIGNED NOT NULL CHECK (loser_score BETWEEN 0 AND 255),
+  PRIMARY KEY (game_id, set_number)
+);
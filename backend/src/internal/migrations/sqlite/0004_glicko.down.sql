@@ -0,0 +1,3 @@
+ALTER TABLE players DROP COLUMN glicko_volatility;
+ALTER TABLE players DROP COLUMN glicko_deviation;
+ALTER TABLE players DROP COLUMN glicko_rating;
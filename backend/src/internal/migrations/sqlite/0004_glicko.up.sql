@@ -0,0 +1,3 @@
+ALTER TABLE players ADD COLUMN glicko_rating DOUBLE NOT NULL DEFAULT 1000;
+ALTER TABLE players ADD COLUMN glicko_deviation DOUBLE NOT NULL DEFAULT 350;
+ALTER TABLE players ADD COLUMN glicko_volatility DOUBLE NOT NULL DEFAULT 0.06;
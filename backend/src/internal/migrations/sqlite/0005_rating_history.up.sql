@@ -0,0 +1,14 @@
+-- one row per player per game that moved their singles Elo rating; doubles games only
+-- appear when they are configured to affect singles ratings
+CREATE TABLE IF NOT EXISTS rating_history (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  player_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  game_id INTEGER NULL REFERENCES games (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  doubles_game_id INTEGER NULL REFERENCES doubles_games (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  rating_before DOUBLE NOT NULL,
+  rating_after DOUBLE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS fk_rating_history_player_id_idx ON rating_history (player_id);
+CREATE INDEX IF NOT EXISTS fk_rating_history_game_id_idx ON rating_history (game_id);
+CREATE INDEX IF NOT EXISTS fk_rating_history_doubles_game_id_idx ON rating_history (doubles_game_id);
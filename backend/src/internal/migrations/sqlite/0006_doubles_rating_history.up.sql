@@ -0,0 +1,11 @@
+-- one row per player per doubles game, recording how it moved their doubles Elo rating
+CREATE TABLE IF NOT EXISTS doubles_rating_history (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  player_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  doubles_game_id INTEGER NOT NULL REFERENCES doubles_games (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  rating_before DOUBLE NOT NULL,
+  rating_after DOUBLE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS fk_doubles_rating_history_player_id_idx ON doubles_rating_history (player_id);
+CREATE INDEX IF NOT EXISTS fk_doubles_rating_history_doubles_game_id_idx ON doubles_rating_history (doubles_game_id);
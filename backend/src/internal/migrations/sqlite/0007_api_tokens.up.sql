@@ -0,0 +1,12 @@
+-- API tokens are stored as SHA-256 hashes, so a leaked database does not leak tokens.
+-- Player tokens act on behalf of their player; admin tokens may belong to no player.
+CREATE TABLE IF NOT EXISTS api_tokens (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  name VARCHAR(63) NOT NULL,
+  role VARCHAR(15) NOT NULL,
+  player_id INTEGER NULL REFERENCES players (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS fk_api_tokens_player_id_idx ON api_tokens (player_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_status;
+
+ALTER TABLE games DROP COLUMN submitted_by;
+ALTER TABLE games DROP COLUMN status;
@@ -0,0 +1,7 @@
+-- games recorded by a player stay pending until their opponent confirms them, and only
+-- confirmed games count; games recorded before confirmation existed are confirmed.
+-- SQLite cannot drop a column with a foreign key, so submitted_by has none.
+ALTER TABLE games ADD COLUMN status VARCHAR(15) NOT NULL DEFAULT 'confirmed';
+ALTER TABLE games ADD COLUMN submitted_by INTEGER NULL;
+
+CREATE INDEX IF NOT EXISTS idx_status ON games (status);
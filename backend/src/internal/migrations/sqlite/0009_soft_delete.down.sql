@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE doubles_games DROP COLUMN delete_reason;
+ALTER TABLE doubles_games DROP COLUMN deleted_at;
+
+ALTER TABLE games DROP COLUMN delete_reason;
+ALTER TABLE games DROP COLUMN deleted_at;
@@ -0,0 +1,21 @@
+-- deleted games are kept, with the reason they were deleted, so they can be restored
+ALTER TABLE games ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE games ADD COLUMN delete_reason VARCHAR(255) NULL;
+
+ALTER TABLE doubles_games ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE doubles_games ADD COLUMN delete_reason VARCHAR(255) NULL;
+
+-- every change to the games is recorded along with who made it. The actor is kept as
+-- text rather than a reference to a token, so entries outlive revoked tokens.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  action VARCHAR(15) NOT NULL,
+  actor VARCHAR(63) NOT NULL,
+  token_id INTEGER NULL,
+  game_type VARCHAR(15) NULL,
+  game_id INTEGER NULL,
+  reason VARCHAR(255) NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_game ON audit_log (game_type, game_id);
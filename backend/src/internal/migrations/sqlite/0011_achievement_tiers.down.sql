@@ -0,0 +1,3 @@
+ALTER TABLE achievement DROP COLUMN points;
+ALTER TABLE achievement DROP COLUMN tier;
+ALTER TABLE achievement DROP COLUMN family;
@@ -0,0 +1,5 @@
+-- the achievements of a family are the tiers of one progression, and every achievement
+-- is worth points towards a player's achievement score
+ALTER TABLE achievement ADD COLUMN family VARCHAR(63) NOT NULL DEFAULT '';
+ALTER TABLE achievement ADD COLUMN tier INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE achievement ADD COLUMN points INTEGER NOT NULL DEFAULT 0;
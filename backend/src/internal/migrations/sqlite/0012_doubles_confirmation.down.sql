@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_doubles_status;
+
+ALTER TABLE doubles_games DROP COLUMN submitted_by;
+ALTER TABLE doubles_games DROP COLUMN status;
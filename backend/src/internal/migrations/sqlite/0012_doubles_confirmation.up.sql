@@ -0,0 +1,7 @@
+-- doubles games recorded by a player stay pending until one of their opponents confirms
+-- them, as singles games do; games recorded before then are confirmed.
+-- SQLite cannot drop a column with a foreign key, so submitted_by has none.
+ALTER TABLE doubles_games ADD COLUMN status VARCHAR(15) NOT NULL DEFAULT 'confirmed';
+ALTER TABLE doubles_games ADD COLUMN submitted_by INTEGER NULL;
+
+CREATE INDEX IF NOT EXISTS idx_doubles_status ON doubles_games (status);
@@ -0,0 +1,8 @@
+-- created_at is when a game was played, which may be backdated, so the confirmation window
+-- is measured from when it was submitted instead; existing games were submitted when played.
+-- SQLite cannot add a column defaulting to the current time, so every insert sets it.
+ALTER TABLE games ADD COLUMN submitted_at TIMESTAMP NULL;
+ALTER TABLE doubles_games ADD COLUMN submitted_at TIMESTAMP NULL;
+
+UPDATE games SET submitted_at = created_at;
+UPDATE doubles_games SET submitted_at = created_at;
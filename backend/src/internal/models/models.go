@@ -4,10 +4,13 @@ import "time"
 
 // ---------------------------------------- index page
 
+// Glicko is only set on the singles leaderboard.
 type LeaderboardRow struct {
-	ID        int     `json:"id"`
-	Name      string  `json:"name"`
-	EloRating float64 `json:"eloRating"`
+	ID               int           `json:"id"`
+	Name             string        `json:"name"`
+	EloRating        float64       `json:"eloRating"`
+	Glicko           *GlickoRating `json:"glicko,omitempty"`
+	AchievementScore int           `json:"achievementScore"`
 }
 
 type GlobalStats struct {
@@ -15,9 +18,73 @@ type GlobalStats struct {
 	TotalPoints int `json:"totalPoints"`
 }
 
+// RatingSystem names the rating the singles leaderboard is ordered by.
 type IndexPageData struct {
-	Leaderboard []LeaderboardRow `json:"leaderboard"`
-	GlobalStats GlobalStats      `json:"globalStats"`
+	RatingSystem       string           `json:"ratingSystem"`
+	Sort               string           `json:"sort"`
+	Leaderboard        []LeaderboardRow `json:"leaderboard"`
+	DoublesLeaderboard []LeaderboardRow `json:"doublesLeaderboard"`
+	GlobalStats        GlobalStats      `json:"globalStats"`
+}
+
+// ---------------------------------------- ratings
+
+// A Glicko-2 rating on the familiar Elo scale. Interval is the 95% confidence interval
+// of the rating: the rating plus or minus 1.96 deviations.
+type GlickoRating struct {
+	Rating     float64    `json:"rating"`
+	Deviation  float64    `json:"deviation"`
+	Volatility float64    `json:"volatility"`
+	Interval   [2]float64 `json:"interval"`
+}
+
+// RatingChange records how one game moved a player's singles Elo rating, or their doubles
+// Elo rating in the doubles rating history.
+type RatingChange struct {
+	PlayerID     int       `json:"playerId"`
+	GameType     GameType  `json:"gameType"`
+	GameID       int       `json:"gameId"`
+	RatingBefore float64   `json:"ratingBefore"`
+	RatingAfter  float64   `json:"ratingAfter"`
+	PlayedAt     time.Time `json:"playedAt"`
+}
+
+// A point on a player's rating graph. Per-game points identify their game; daily and
+// weekly points start at the beginning of their bucket and sum up every game in it.
+type RatingHistoryPoint struct {
+	Time     time.Time `json:"time"`
+	Rating   float64   `json:"rating"`
+	Change   float64   `json:"change"`
+	Games    int       `json:"games"`
+	GameType GameType  `json:"gameType,omitempty"`
+	GameID   int       `json:"gameId,omitempty"`
+}
+
+// ---------------------------------------- auth
+
+// Role decides what an API token may do. Players may record results and add players;
+// admins may also delete games, recalculate ratings and manage tokens.
+type Role string
+
+const (
+	ROLE_PLAYER Role = "player"
+	ROLE_ADMIN  Role = "admin"
+)
+
+// APIToken describes an issued token. The token itself is only shown once, when it is
+// created, and only its hash is stored.
+type APIToken struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	PlayerID  *int      `json:"playerId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type NewAPIToken struct {
+	Name     string `json:"name" binding:"required,min=1,max=63"`
+	Role     Role   `json:"role" binding:"required,oneof=player admin"`
+	PlayerID *int   `json:"playerId,omitempty" binding:"omitempty,min=1"`
 }
 
 // ---------------------------------------- achievements
@@ -26,10 +93,84 @@ type Achievement struct {
 	ID          int    `json:"id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
+
+	// The achievements of a family are the tiers of one progression, from tier 1 up.
+	Family string `json:"family,omitempty"`
+	Tier   int    `json:"tier,omitempty"`
+
+	// What the achievement adds to a player's achievement score.
+	Points int `json:"points"`
 }
 
 type AchievementID int
 
+// An achievement on a player's profile, with when they earned it.
+type EarnedAchievement struct {
+	Achievement
+	EarnedAt time.Time `json:"earnedAt"`
+}
+
+type Rarity string
+
+const (
+	COMMON    Rarity = "common"
+	RARE      Rarity = "rare"
+	EPIC      Rarity = "epic"
+	LEGENDARY Rarity = "legendary"
+)
+
+// A player who holds an achievement, and when they unlocked it.
+type AchievementHolder struct {
+	Player
+	EarnedAt time.Time `json:"earnedAt"`
+}
+
+// An achievement with how many players have unlocked it. The percentage is of the players
+// active on the leaderboard who hold it, and the rarity follows from it.
+type AchievementStats struct {
+	Achievement
+	HolderCount      int                `json:"holderCount"`
+	ActivePercentage float64            `json:"activePercentage"`
+	Rarity           Rarity             `json:"rarity"`
+	FirstUnlock      *AchievementHolder `json:"firstUnlock"`
+	LatestUnlock     *AchievementHolder `json:"latestUnlock"`
+}
+
+// An achievement with every player who holds it, in the order they unlocked it.
+type AchievementDetail struct {
+	AchievementStats
+	Holders []AchievementHolder `json:"holders"`
+}
+
+// How far a player has got towards an achievement. Achievements counted up to, such as a
+// number of games or a rating, have the best value the player has reached, the target and
+// the percentage of the way there; the rest are only unlocked or not.
+type AchievementProgress struct {
+	Achievement
+	Unlocked   bool       `json:"unlocked"`
+	EarnedAt   *time.Time `json:"earnedAt,omitempty"`
+	Current    *float64   `json:"current,omitempty"`
+	Target     *float64   `json:"target,omitempty"`
+	Percentage *float64   `json:"percentage,omitempty"`
+}
+
+// A player's standing in an achievement family: the highest tier they have unlocked, if
+// any, and their progress towards the next, unless they have unlocked every tier.
+type AchievementFamily struct {
+	Family  string               `json:"family"`
+	Tier    int                  `json:"tier"`
+	Tiers   int                  `json:"tiers"`
+	Highest *AchievementProgress `json:"highest"`
+	Next    *AchievementProgress `json:"next"`
+}
+
+// An achievement awarded to a player, and when they earned it.
+type PlayerAchievement struct {
+	PlayerID      int       `json:"playerId"`
+	AchievementID int       `json:"achievementId"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
 // ---------------------------------------- players
 
 type PlayerID struct {
@@ -52,23 +193,33 @@ type PlayerBasicInfo struct {
 }
 
 type PlayerProfile struct {
-	ID           int           `json:"id"`
-	Name         string        `json:"name"`
-	EloRating    float64       `json:"eloRating"`
-	HighestElo   float64       `json:"highestElo"`
-	CreatedAt    time.Time     `json:"createdAt"`
-	GamesPlayed  int           `json:"gamesPlayed"`
-	GamesWon     int           `json:"gamesWon"`
-	RecentGames  []Game        `json:"recentGames"`
-	Achievements []Achievement `json:"achievements"`
+	ID               int                 `json:"id"`
+	Name             string              `json:"name"`
+	EloRating        float64             `json:"eloRating"`
+	HighestElo       float64             `json:"highestElo"`
+	DoublesEloRating float64             `json:"doublesEloRating"`
+	Glicko           GlickoRating        `json:"glicko"`
+	CreatedAt        time.Time           `json:"createdAt"`
+	GamesPlayed      int                 `json:"gamesPlayed"`
+	GamesWon         int                 `json:"gamesWon"`
+	RecentGames      []Game              `json:"recentGames"`
+	Achievements     []EarnedAchievement `json:"achievements"`
+	AchievementScore int                 `json:"achievementScore"`
+
+	// the achievements the player has yet to unlock
+	AchievementProgress []AchievementProgress `json:"achievementProgress"`
 }
 
 // ---------------------------------------- games
 
 type BaseGame struct {
-	WinnerID  int
-	LoserID   int
-	CreatedAt time.Time
+	ID          int
+	WinnerID    int
+	LoserID     int
+	WinnerScore *int
+	LoserScore  *int
+	Sets        []SetScore
+	CreatedAt   time.Time
 }
 
 // Pointer values encode as the value pointed to. A nil pointer encodes as the null JSON object.
@@ -76,19 +227,245 @@ type BaseGame struct {
 
 // Also see: https://www.sohamkamani.com/golang/omitempty/
 type GameResult struct {
-	WinnerID    int  `json:"winnerId" binding:"required"`
-	LoserID     int  `json:"loserId" binding:"required"`
-	WinnerScore *int `json:"winnerScore,omitempty" binding:"omitempty,min=0,max=255"`
-	LoserScore  *int `json:"loserScore,omitempty" binding:"omitempty,min=0,max=255"`
+	WinnerID    int        `json:"winnerId" binding:"required"`
+	LoserID     int        `json:"loserId" binding:"required"`
+	WinnerScore *int       `json:"winnerScore,omitempty" binding:"omitempty,min=0,max=255"`
+	LoserScore  *int       `json:"loserScore,omitempty" binding:"omitempty,min=0,max=255"`
+	PlayedAt    *time.Time `json:"playedAt,omitempty"`
 }
 
+type GameType string
+
+const (
+	SINGLES GameType = "singles"
+	DOUBLES GameType = "doubles"
+)
+
+// The partner fields are only set on doubles games, and BestOf and Sets only on
+// singles games recorded as a best-of-N match.
+//
+// The rating changes are in the game's own rating: singles Elo for singles games and
+// doubles Elo for doubles games. WinProbability is the winner's (or winning team's) chance
+// of winning beforehand. They are null for games that have no rating history.
 type Game struct {
-	ID          int       `json:"id"`
-	Winner      Player    `json:"winner"`
-	Loser       Player    `json:"loser"`
-	WinnerScore *int      `json:"winnerScore"`
-	LoserScore  *int      `json:"loserScore"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ID                        int        `json:"id"`
+	Type                      GameType   `json:"type"`
+	Winner                    Player     `json:"winner"`
+	WinnerPartner             *Player    `json:"winnerPartner,omitempty"`
+	Loser                     Player     `json:"loser"`
+	LoserPartner              *Player    `json:"loserPartner,omitempty"`
+	WinnerScore               *int       `json:"winnerScore"`
+	LoserScore                *int       `json:"loserScore"`
+	BestOf                    *int       `json:"bestOf,omitempty"`
+	Sets                      []SetScore `json:"sets,omitempty"`
+	WinnerRatingChange        *float64   `json:"winnerRatingChange"`
+	WinnerPartnerRatingChange *float64   `json:"winnerPartnerRatingChange,omitempty"`
+	LoserRatingChange         *float64   `json:"loserRatingChange"`
+	LoserPartnerRatingChange  *float64   `json:"loserPartnerRatingChange,omitempty"`
+	WinProbability            *float64   `json:"winProbability"`
+	CreatedAt                 time.Time  `json:"createdAt"`
+}
+
+// A game, match or doubles game recorded by a player is pending until an opponent confirms
+// or disputes it. Only confirmed games are rated or shown in game lists and statistics.
+type GameStatus string
+
+const (
+	PENDING   GameStatus = "pending"
+	CONFIRMED GameStatus = "confirmed"
+	DISPUTED  GameStatus = "disputed"
+)
+
+// SubmittedGame is a game with its confirmation state. SubmittedBy is the player who
+// recorded it, or nil if it was recorded by an admin. SubmittedAt is when it was recorded,
+// which is later than CreatedAt for a backdated game. ConfirmBy is when a pending game
+// will be confirmed automatically.
+type SubmittedGame struct {
+	Game
+	Status      GameStatus `json:"status"`
+	SubmittedBy *int       `json:"submittedBy"`
+	SubmittedAt time.Time  `json:"submittedAt"`
+	ConfirmBy   *time.Time `json:"confirmBy,omitempty"`
+}
+
+// DeletedGame is a game that was deleted and can still be restored.
+type DeletedGame struct {
+	Game
+	DeletedAt    time.Time `json:"deletedAt"`
+	DeleteReason string    `json:"deleteReason"`
+}
+
+type DeleteRequest struct {
+	Reason string `json:"reason" binding:"required,min=1,max=255"`
+}
+
+// GameUpdate corrects a recorded singles game or match. Fields left out keep their value.
+// Scores are corrected with winnerScore and loserScore for a game, and with sets for a
+// match.
+type GameUpdate struct {
+	WinnerID    *int       `json:"winnerId" binding:"omitempty,min=1"`
+	LoserID     *int       `json:"loserId" binding:"omitempty,min=1"`
+	WinnerScore *int       `json:"winnerScore" binding:"omitempty,min=0,max=255"`
+	LoserScore  *int       `json:"loserScore" binding:"omitempty,min=0,max=255"`
+	Sets        []SetScore `json:"sets" binding:"omitempty,min=1,dive"`
+	CreatedAt   *time.Time `json:"createdAt"`
+	Reason      string     `json:"reason" binding:"max=255"`
+}
+
+// ---------------------------------------- audit log
+
+type AuditAction string
+
+const (
+	AUDIT_CREATE      AuditAction = "create"
+	AUDIT_CONFIRM     AuditAction = "confirm"
+	AUDIT_DISPUTE     AuditAction = "dispute"
+	AUDIT_UPDATE      AuditAction = "update"
+	AUDIT_DELETE      AuditAction = "delete"
+	AUDIT_RESTORE     AuditAction = "restore"
+	AUDIT_RECALCULATE AuditAction = "recalculate"
+	AUDIT_IMPORT      AuditAction = "import"
+)
+
+// AuditLogEntry records a change to the games. Actor names the token that made it, and
+// TokenID is nil for ADMIN_TOKEN and for changes the server made itself. GameType and
+// GameID are nil for actions that affect every game, such as a recalculation. Changes
+// holds the old and new value of each field an update changed.
+type AuditLogEntry struct {
+	ID        int                    `json:"id"`
+	Action    AuditAction            `json:"action"`
+	Actor     string                 `json:"actor"`
+	TokenID   *int                   `json:"tokenId"`
+	GameType  *GameType              `json:"gameType"`
+	GameID    *int                   `json:"gameId"`
+	Reason    string                 `json:"reason,omitempty"`
+	Changes   map[string]FieldChange `json:"changes,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// AuditLogFilter narrows the audit log. Nil fields match every entry.
+type AuditLogFilter struct {
+	Action   *AuditAction
+	GameType *GameType
+	GameID   *int
+}
+
+// ---------------------------------------- import
+
+// ImportGame is a historical singles game read from an import file, with its players
+// given by name. Line is where the game appears in the file.
+type ImportGame struct {
+	Line        int
+	Winner      string
+	Loser       string
+	WinnerScore *int
+	LoserScore  *int
+	PlayedAt    time.Time
+}
+
+// ImportSummary reports what an import added: the number of games, and the names of the
+// players it created.
+type ImportSummary struct {
+	Games   int      `json:"games"`
+	Players []string `json:"players"`
+}
+
+// ---------------------------------------- archive
+
+const ARCHIVE_FORMAT = "luinc-pong-archive"
+
+// ARCHIVE_VERSION is the version of the archive layout written by this build. It goes up
+// whenever a field is added, removed or changes meaning.
+const ARCHIVE_VERSION = 3
+
+// Archive is a full copy of the record: every player, game and achievement, including
+// games awaiting confirmation and deleted games. Ratings and rating history are left
+// out, as they are replayed from the games on restore. API tokens and the audit log are
+// not kept.
+type Archive struct {
+	Format             string               `json:"format"`
+	Version            int                  `json:"version"`
+	ExportedAt         time.Time            `json:"exportedAt"`
+	Players            []ArchivePlayer      `json:"players"`
+	Games              []ArchiveGame        `json:"games"`
+	DoublesGames       []ArchiveDoublesGame `json:"doublesGames"`
+	Achievements       []Achievement        `json:"achievements"`
+	PlayerAchievements []PlayerAchievement  `json:"playerAchievements"`
+}
+
+type ArchivePlayer struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ArchiveGame struct {
+	ID           int        `json:"id"`
+	WinnerID     int        `json:"winnerId"`
+	LoserID      int        `json:"loserId"`
+	WinnerScore  *int       `json:"winnerScore"`
+	LoserScore   *int       `json:"loserScore"`
+	BestOf       *int       `json:"bestOf,omitempty"`
+	Sets         []SetScore `json:"sets,omitempty"`
+	Status       GameStatus `json:"status"`
+	SubmittedBy  *int       `json:"submittedBy"`
+	SubmittedAt  time.Time  `json:"submittedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
+	DeleteReason string     `json:"deleteReason,omitempty"`
+}
+
+type ArchiveDoublesGame struct {
+	ID           int        `json:"id"`
+	WinnerIDs    [2]int     `json:"winnerIds"`
+	LoserIDs     [2]int     `json:"loserIds"`
+	WinnerScore  *int       `json:"winnerScore"`
+	LoserScore   *int       `json:"loserScore"`
+	Status       GameStatus `json:"status"`
+	SubmittedBy  *int       `json:"submittedBy"`
+	SubmittedAt  time.Time  `json:"submittedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
+	DeleteReason string     `json:"deleteReason,omitempty"`
+}
+
+// ---------------------------------------- matches
+
+// The scores of a single set, from the point of view of the match winner: a set the
+// match loser took has a LoserScore higher than its WinnerScore.
+type SetScore struct {
+	WinnerScore int `json:"winnerScore" binding:"min=0,max=255"`
+	LoserScore  int `json:"loserScore" binding:"min=0,max=255"`
+}
+
+type MatchResult struct {
+	WinnerID int        `json:"winnerId" binding:"required"`
+	LoserID  int        `json:"loserId" binding:"required"`
+	BestOf   int        `json:"bestOf" binding:"required,oneof=3 5 7"`
+	Sets     []SetScore `json:"sets" binding:"required,min=1,dive"`
+	PlayedAt *time.Time `json:"playedAt,omitempty"`
+}
+
+// ---------------------------------------- doubles
+
+type BaseDoublesGame struct {
+	ID        int
+	WinnerIDs [2]int
+	LoserIDs  [2]int
+	CreatedAt time.Time
+}
+
+type DoublesGameResult struct {
+	WinnerIDs   []int      `json:"winnerIds" binding:"required,len=2,dive,min=1"`
+	LoserIDs    []int      `json:"loserIds" binding:"required,len=2,dive,min=1"`
+	WinnerScore *int       `json:"winnerScore,omitempty" binding:"omitempty,min=0,max=255"`
+	LoserScore  *int       `json:"loserScore,omitempty" binding:"omitempty,min=0,max=255"`
+	PlayedAt    *time.Time `json:"playedAt,omitempty"`
 }
 
 // ---------------------------------------- head-to-head
@@ -7,21 +7,60 @@ import (
 // map from player ID to their Elo Rating
 type EloRatings map[int]float64
 
+// map from player ID to their Glicko-2 rating
+type GlickoRatings map[int]GlickoRating
+
 type Store interface {
-	DeleteGame(id int) error
+	DeleteAPIToken(id int) error
+	DeleteDoublesGame(id int, reason string) error
+	DeleteGame(id int, reason string) error
+	ExportArchive() (Archive, error)
 	GetAchievements() ([]Achievement, error)
+	GetAPIToken(hash string) (APIToken, error)
+	GetAPITokens() ([]APIToken, error)
+	GetAuditLog(filter AuditLogFilter, page int) ([]AuditLogEntry, error)
+	GetDeletedGames() ([]DeletedGame, error)
+	GetDoublesGameResults() ([]BaseDoublesGame, error)
 	GetGameResults() ([]BaseGame, error)
 	GetGames(page int) ([]Game, error)
 	GetHeadToHead(p1 int, p2 int) (HeadToHead, error)
 	GetIndexPageData(showFull bool) (IndexPageData, error)
+	GetLatestGameTime(gameType GameType) (time.Time, error)
 	GetPlayerBasicInfo() ([]PlayerBasicInfo, error)
+	GetPlayerAchievements() ([]PlayerAchievement, error)
+	GetPlayerDoublesEloRatings(ids [4]int) (EloRatings, error)
 	GetPlayerEloRatings(ids [2]int) (EloRatings, error)
 	GetPlayerGames(id int, limit int) ([]Game, error)
 	GetPlayerProfile(id int) (PlayerProfile, error)
+	GetRatingHistory(id int) ([]RatingChange, error)
+	GetSubmittedDoublesGame(id int) (SubmittedGame, error)
+	GetSubmittedGame(id int) (SubmittedGame, error)
+	GetSubmittedGames(status GameStatus) ([]SubmittedGame, error)
+	ImportGames(games []ImportGame, rating float64) (ImportSummary, error)
+	InsertAPIToken(hash string, t NewAPIToken) (int64, error)
+	InsertAuditLogEntry(e AuditLogEntry) error
+	InsertDoublesGameResult(r DoublesGameResult) (int64, error)
+	InsertDoublesRatingHistory(changes []RatingChange) error
 	InsertGameResult(r GameResult) (int64, error)
-	InsertPlayer(name string) (int64, error)
-	InsertPlayerAchievements(id int, achievementIDs []AchievementID) error
+	InsertMatchResult(r MatchResult) (int64, error)
+	InsertPendingDoublesGameResult(r DoublesGameResult, submittedBy int) (int64, error)
+	InsertPendingGameResult(r GameResult, submittedBy int) (int64, error)
+	InsertPendingMatchResult(r MatchResult, submittedBy int) (int64, error)
+	InsertPlayer(name string, rating float64) (int64, error)
+	InsertPlayerAchievements(awards []PlayerAchievement) error
+	InsertRatingHistory(changes []RatingChange) error
+	ReplaceDoublesRatingHistory(changes []RatingChange) error
+	ReplacePlayerAchievements(awards []PlayerAchievement) error
+	ReplaceRatingHistory(changes []RatingChange) error
+	RestoreArchive(a Archive, rating float64) error
+	RestoreDoublesGame(id int) error
+	RestoreGame(id int) error
+	UpdateDoublesEloRatings(players EloRatings) error
+	UpdateDoublesGameStatus(id int, status GameStatus) error
 	UpdateEloRatings(players EloRatings) error
+	UpdateGameResult(g BaseGame) error
+	UpdateGameStatus(id int, status GameStatus) error
+	UpdateGlickoRatings(players GlickoRatings) error
 	UpdateHighestEloRatings(players EloRatings) error
 	UpdatePlayerUpdatedAt(m map[int]time.Time) error
 }
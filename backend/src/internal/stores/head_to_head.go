@@ -0,0 +1,143 @@
+package stores
+
+import "github.com/jda5/luinc-pong/src/internal/models"
+
+// summariseHeadToHead builds the head-to-head statistics between p1 and their opponent
+// from every game the two have played, ordered from most recent to oldest.
+// Win probabilities are left for the caller to fill in from the current ratings.
+func summariseHeadToHead(p1 int, games []models.Game) models.HeadToHead {
+	h := models.HeadToHead{}
+
+	// Initialize tracking variables
+	var (
+		p1Streak      int
+		p2Streak      int
+		recordedCount int
+		gameIndex     int
+		scores        = models.ScoreStats{}
+	)
+
+	for _, game := range games {
+		winner := game.Winner
+		loser := game.Loser
+
+		// Initialize players on first game
+		if gameIndex == 0 {
+			if p1 == winner.ID {
+				h.Player1.ID = winner.ID
+				h.Player1.Name = winner.Name
+				h.Player2.ID = loser.ID
+				h.Player2.Name = loser.Name
+			} else {
+				h.Player1.ID = loser.ID
+				h.Player1.Name = loser.Name
+				h.Player2.ID = winner.ID
+				h.Player2.Name = winner.Name
+			}
+			h.FirstPlayedAt = game.CreatedAt
+		}
+
+		// Track last 30 games
+		if gameIndex < 30 {
+			h.RecentGames = append(h.RecentGames, game)
+		}
+
+		// Update player statistics based on who won
+		if winner.ID == h.Player1.ID {
+			// Player 1 won
+			h.Player1.GamesWon++
+
+			if game.WinnerScore != nil {
+				h.Player1.TotalPoints += *game.WinnerScore
+			}
+			if game.LoserScore != nil {
+				h.Player2.TotalPoints += *game.LoserScore
+			}
+
+			p1Streak++
+			h.Player1.LongestWinStreak = max(h.Player1.LongestWinStreak, p1Streak)
+			p2Streak = 0
+		} else {
+			// Player 2 won
+			h.Player2.GamesWon++
+
+			if game.WinnerScore != nil {
+				h.Player2.TotalPoints += *game.WinnerScore
+			}
+			if game.LoserScore != nil {
+				h.Player1.TotalPoints += *game.LoserScore
+			}
+
+			p2Streak++
+			h.Player2.LongestWinStreak = max(h.Player2.LongestWinStreak, p2Streak)
+			p1Streak = 0
+		}
+
+		// Update score statistics for games with recorded scores
+		if game.WinnerScore != nil && game.LoserScore != nil {
+			recordedCount++
+
+			winnerScore := *game.WinnerScore
+			loserScore := *game.LoserScore
+			scoreDiff := winnerScore - loserScore
+
+			// Track average score differential
+			scores.AvgScoreDifferential += float64(scoreDiff)
+
+			// Track biggest blowout (largest score difference)
+			if scores.BiggestBlowout.WinnerScore == nil {
+				scores.BiggestBlowout = models.GameResult{
+					WinnerID:    game.Winner.ID,
+					LoserID:     game.Loser.ID,
+					WinnerScore: game.WinnerScore,
+					LoserScore:  game.LoserScore,
+				}
+			} else {
+				existingDiff := *scores.BiggestBlowout.WinnerScore - *scores.BiggestBlowout.LoserScore
+				if scoreDiff > existingDiff {
+					scores.BiggestBlowout = models.GameResult{
+						WinnerID:    game.Winner.ID,
+						LoserID:     game.Loser.ID,
+						WinnerScore: game.WinnerScore,
+						LoserScore:  game.LoserScore,
+					}
+				}
+			}
+
+			// Track most competitive game (highest total points)
+			totalPoints := winnerScore + loserScore
+			if scores.MostCompetitive.WinnerScore == nil {
+				scores.MostCompetitive = models.GameResult{
+					WinnerID:    game.Winner.ID,
+					LoserID:     game.Loser.ID,
+					WinnerScore: game.WinnerScore,
+					LoserScore:  game.LoserScore,
+				}
+			} else {
+				existingTotal := *scores.MostCompetitive.WinnerScore + *scores.MostCompetitive.LoserScore
+				if totalPoints > existingTotal {
+					scores.MostCompetitive = models.GameResult{
+						WinnerID:    game.Winner.ID,
+						LoserID:     game.Loser.ID,
+						WinnerScore: game.WinnerScore,
+						LoserScore:  game.LoserScore,
+					}
+				}
+			}
+		}
+
+		gameIndex++
+	}
+
+	// Calculate averages if we have recorded games
+	if recordedCount > 0 {
+		count := float64(recordedCount)
+		scores.AvgScoreDifferential = scores.AvgScoreDifferential / count
+		h.Player1.AvgPointsPerGame = float64(h.Player1.TotalPoints) / count
+		h.Player2.AvgPointsPerGame = float64(h.Player2.TotalPoints) / count
+	}
+	h.ScoreStats = scores
+	h.TotalGameCount = gameIndex
+
+	return h
+}
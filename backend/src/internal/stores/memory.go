@@ -0,0 +1,1353 @@
+package stores
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/utils"
+)
+
+// -------------------------------------------------------------------------------- rows
+
+type memoryPlayer struct {
+	ID               int
+	Name             string
+	EloRating        float64
+	HighestElo       float64
+	DoublesEloRating float64
+	Glicko           models.GlickoRating
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type memoryGame struct {
+	ID           int
+	WinnerID     int
+	LoserID      int
+	WinnerScore  *int
+	LoserScore   *int
+	BestOf       *int
+	Sets         []models.SetScore
+	Status       models.GameStatus
+	SubmittedBy  *int
+	SubmittedAt  time.Time
+	CreatedAt    time.Time
+	DeletedAt    *time.Time
+	DeleteReason string
+}
+
+// counts reports whether a game is part of the record: confirmed and not deleted.
+func (g memoryGame) counts() bool {
+	return g.Status == models.CONFIRMED && g.DeletedAt == nil
+}
+
+type memoryDoublesGame struct {
+	ID           int
+	WinnerIDs    [2]int
+	LoserIDs     [2]int
+	WinnerScore  *int
+	LoserScore   *int
+	Status       models.GameStatus
+	SubmittedBy  *int
+	SubmittedAt  time.Time
+	CreatedAt    time.Time
+	DeletedAt    *time.Time
+	DeleteReason string
+}
+
+// counts reports whether a doubles game is part of the record: confirmed and not deleted.
+func (g memoryDoublesGame) counts() bool {
+	return g.Status == models.CONFIRMED && g.DeletedAt == nil
+}
+
+type memoryAPIToken struct {
+	Hash string
+	models.APIToken
+}
+
+type memoryPlayerAchievement struct {
+	PlayerID      int
+	AchievementID int
+	CreatedAt     time.Time
+}
+
+// -------------------------------------------------------------------------------- store implementation
+
+// MemoryStore keeps every table in process memory. It mirrors the behaviour of
+// MySQLStore so the API can be run locally and in tests without a database.
+type MemoryStore struct {
+	TZ *time.Location
+
+	mu                 sync.RWMutex
+	players            map[int]*memoryPlayer
+	games              []memoryGame
+	doublesGames       []memoryDoublesGame
+	achievements       []models.Achievement
+	playerAchievements []memoryPlayerAchievement
+	ratingHistory      []models.RatingChange
+	doublesHistory     []models.RatingChange
+	apiTokens          []memoryAPIToken
+	auditLog           []models.AuditLogEntry
+	lastAPITokenID     int
+	lastAuditLogID     int
+	lastPlayerID       int
+	lastGameID         int
+	lastDoublesGameID  int
+}
+
+// -------------------------------------------------------------------------------- internal helpers
+
+// comparePlayerAchievements orders awarded achievements by player, then achievement, as
+// the SQL stores return them.
+func comparePlayerAchievements(x, y models.PlayerAchievement) int {
+	if c := cmp.Compare(x.PlayerID, y.PlayerID); c != 0 {
+		return c
+	}
+	return cmp.Compare(x.AchievementID, y.AchievementID)
+}
+
+// ratingChanges returns how a game moved each of its players' ratings. The caller must
+// hold the lock.
+func (s *MemoryStore) ratingChanges(gameType models.GameType, id int) map[int]models.RatingChange {
+	history := s.ratingHistory
+	if gameType == models.DOUBLES {
+		history = s.doublesHistory
+	}
+
+	changes := make(map[int]models.RatingChange)
+	for _, c := range history {
+		if c.GameType == gameType && c.GameID == id {
+			changes[c.PlayerID] = c
+		}
+	}
+	return changes
+}
+
+// toGame joins a game row with its players. The caller must hold the lock.
+func (s *MemoryStore) toGame(g memoryGame) models.Game {
+	game := models.Game{
+		ID:          g.ID,
+		Type:        models.SINGLES,
+		Winner:      s.toPlayer(g.WinnerID),
+		Loser:       s.toPlayer(g.LoserID),
+		WinnerScore: g.WinnerScore,
+		LoserScore:  g.LoserScore,
+		BestOf:      g.BestOf,
+		Sets:        slices.Clone(g.Sets),
+		CreatedAt:   g.CreatedAt.In(s.TZ),
+	}
+	setRatingChanges(&game, s.ratingChanges(models.SINGLES, g.ID))
+	return game
+}
+
+// toDoublesGame joins a doubles game row with its players. The caller must hold the lock.
+func (s *MemoryStore) toDoublesGame(g memoryDoublesGame) models.Game {
+	winnerPartner := s.toPlayer(g.WinnerIDs[1])
+	loserPartner := s.toPlayer(g.LoserIDs[1])
+	game := models.Game{
+		ID:            g.ID,
+		Type:          models.DOUBLES,
+		Winner:        s.toPlayer(g.WinnerIDs[0]),
+		WinnerPartner: &winnerPartner,
+		Loser:         s.toPlayer(g.LoserIDs[0]),
+		LoserPartner:  &loserPartner,
+		WinnerScore:   g.WinnerScore,
+		LoserScore:    g.LoserScore,
+		CreatedAt:     g.CreatedAt.In(s.TZ),
+	}
+	setRatingChanges(&game, s.ratingChanges(models.DOUBLES, g.ID))
+	return game
+}
+
+// toSubmittedGame joins a game row with its players and confirmation state. The caller
+// must hold the lock.
+func (s *MemoryStore) toSubmittedGame(g memoryGame) models.SubmittedGame {
+	return models.SubmittedGame{
+		Game:        s.toGame(g),
+		Status:      g.Status,
+		SubmittedBy: g.SubmittedBy,
+		SubmittedAt: g.SubmittedAt.In(s.TZ),
+	}
+}
+
+// toSubmittedDoublesGame joins a doubles game row with its players and confirmation state.
+// The caller must hold the lock.
+func (s *MemoryStore) toSubmittedDoublesGame(g memoryDoublesGame) models.SubmittedGame {
+	return models.SubmittedGame{
+		Game:        s.toDoublesGame(g),
+		Status:      g.Status,
+		SubmittedBy: g.SubmittedBy,
+		SubmittedAt: g.SubmittedAt.In(s.TZ),
+	}
+}
+
+// toPlayer looks up a player by ID. The caller must hold the lock.
+func (s *MemoryStore) toPlayer(id int) models.Player {
+	if p, ok := s.players[id]; ok {
+		return models.Player{ID: p.ID, Name: p.Name}
+	}
+	return models.Player{}
+}
+
+// gamesNewestFirst returns the confirmed game rows accepted by keep, ordered by
+// created_at DESC. The caller must hold the lock.
+func (s *MemoryStore) gamesNewestFirst(keep func(g memoryGame) bool) []memoryGame {
+	games := make([]memoryGame, 0)
+	for _, g := range s.games {
+		if g.counts() && keep(g) {
+			games = append(games, g)
+		}
+	}
+	slices.SortStableFunc(games, func(a, b memoryGame) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return games
+}
+
+// playedAtOrNow returns the time a game was played in UTC, defaulting to now as the
+// database does.
+func playedAtOrNow(playedAt *time.Time) time.Time {
+	if playedAt == nil {
+		return time.Now().UTC()
+	}
+	return playedAt.UTC()
+}
+
+// addPlayer creates a player and returns their ID. The caller must hold the lock and have
+// checked that the name is free.
+func (s *MemoryStore) addPlayer(name string, rating float64) int {
+	now := time.Now().UTC()
+	s.lastPlayerID++
+	s.players[s.lastPlayerID] = &memoryPlayer{
+		ID:               s.lastPlayerID,
+		Name:             name,
+		EloRating:        rating,
+		HighestElo:       rating,
+		DoublesEloRating: rating,
+		Glicko: utils.NewGlickoRating(
+			utils.GLICKO_START_RATING, utils.GLICKO_START_DEVIATION, utils.GLICKO_START_VOLATILITY,
+		),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return s.lastPlayerID
+}
+
+// insertGameResult records a singles game with the given confirmation state.
+func (s *MemoryStore) insertGameResult(r models.GameResult, status models.GameStatus, submittedBy *int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, winnerExists := s.players[r.WinnerID]
+	_, loserExists := s.players[r.LoserID]
+	if !winnerExists || !loserExists {
+		return 0, fmt.Errorf("error inserting game: unknown player ID")
+	}
+
+	s.lastGameID++
+	s.games = append(s.games, memoryGame{
+		ID:          s.lastGameID,
+		WinnerID:    r.WinnerID,
+		LoserID:     r.LoserID,
+		WinnerScore: r.WinnerScore,
+		LoserScore:  r.LoserScore,
+		Status:      status,
+		SubmittedBy: submittedBy,
+		SubmittedAt: time.Now().UTC(),
+		CreatedAt:   playedAtOrNow(r.PlayedAt),
+	})
+	return int64(s.lastGameID), nil
+}
+
+// insertMatchResult records a best-of-N match with the given confirmation state.
+func (s *MemoryStore) insertMatchResult(r models.MatchResult, status models.GameStatus, submittedBy *int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, winnerExists := s.players[r.WinnerID]
+	_, loserExists := s.players[r.LoserID]
+	if !winnerExists || !loserExists {
+		return 0, fmt.Errorf("error inserting match: unknown player ID")
+	}
+
+	bestOf := r.BestOf
+	s.lastGameID++
+	s.games = append(s.games, memoryGame{
+		ID:          s.lastGameID,
+		WinnerID:    r.WinnerID,
+		LoserID:     r.LoserID,
+		BestOf:      &bestOf,
+		Sets:        slices.Clone(r.Sets),
+		Status:      status,
+		SubmittedBy: submittedBy,
+		SubmittedAt: time.Now().UTC(),
+		CreatedAt:   playedAtOrNow(r.PlayedAt),
+	})
+	return int64(s.lastGameID), nil
+}
+
+// -------------------------------------------------------------------------------- interface implementation
+
+func (s *MemoryStore) DeleteAPIToken(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiTokens = slices.DeleteFunc(s.apiTokens, func(t memoryAPIToken) bool {
+		return t.ID == id
+	})
+	return nil
+}
+
+func (s *MemoryStore) DeleteDoublesGame(id int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.doublesGames {
+		if g := &s.doublesGames[i]; g.ID == id && g.DeletedAt == nil {
+			now := time.Now().UTC()
+			g.DeletedAt = &now
+			g.DeleteReason = reason
+			return nil
+		}
+	}
+	return exceptions.ErrGameNotFound
+}
+
+func (s *MemoryStore) DeleteGame(id int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.games {
+		if g := &s.games[i]; g.ID == id && g.DeletedAt == nil {
+			now := time.Now().UTC()
+			g.DeletedAt = &now
+			g.DeleteReason = reason
+			return nil
+		}
+	}
+	return exceptions.ErrGameNotFound
+}
+
+func (s *MemoryStore) ExportArchive() (models.Archive, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a := models.Archive{
+		Players:            make([]models.ArchivePlayer, 0, len(s.players)),
+		Games:              make([]models.ArchiveGame, 0, len(s.games)),
+		DoublesGames:       make([]models.ArchiveDoublesGame, 0, len(s.doublesGames)),
+		Achievements:       slices.Clone(s.achievements),
+		PlayerAchievements: make([]models.PlayerAchievement, 0, len(s.playerAchievements)),
+	}
+
+	for _, p := range s.players {
+		a.Players = append(a.Players, models.ArchivePlayer{ID: p.ID, Name: p.Name, CreatedAt: p.CreatedAt})
+	}
+	slices.SortFunc(a.Players, func(x, y models.ArchivePlayer) int {
+		return cmp.Compare(x.ID, y.ID)
+	})
+
+	for _, g := range s.games {
+		a.Games = append(a.Games, models.ArchiveGame{
+			ID:           g.ID,
+			WinnerID:     g.WinnerID,
+			LoserID:      g.LoserID,
+			WinnerScore:  g.WinnerScore,
+			LoserScore:   g.LoserScore,
+			BestOf:       g.BestOf,
+			Sets:         slices.Clone(g.Sets),
+			Status:       g.Status,
+			SubmittedBy:  g.SubmittedBy,
+			SubmittedAt:  g.SubmittedAt,
+			CreatedAt:    g.CreatedAt,
+			DeletedAt:    g.DeletedAt,
+			DeleteReason: g.DeleteReason,
+		})
+	}
+
+	for _, g := range s.doublesGames {
+		a.DoublesGames = append(a.DoublesGames, models.ArchiveDoublesGame{
+			ID:           g.ID,
+			WinnerIDs:    g.WinnerIDs,
+			LoserIDs:     g.LoserIDs,
+			WinnerScore:  g.WinnerScore,
+			LoserScore:   g.LoserScore,
+			Status:       g.Status,
+			SubmittedBy:  g.SubmittedBy,
+			SubmittedAt:  g.SubmittedAt,
+			CreatedAt:    g.CreatedAt,
+			DeletedAt:    g.DeletedAt,
+			DeleteReason: g.DeleteReason,
+		})
+	}
+
+	for _, pa := range s.playerAchievements {
+		a.PlayerAchievements = append(a.PlayerAchievements, models.PlayerAchievement{
+			PlayerID: pa.PlayerID, AchievementID: pa.AchievementID, CreatedAt: pa.CreatedAt,
+		})
+	}
+	slices.SortFunc(a.PlayerAchievements, comparePlayerAchievements)
+
+	return a, nil
+}
+
+func (s *MemoryStore) GetAchievements() ([]models.Achievement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.achievements), nil
+}
+
+func (s *MemoryStore) GetAPIToken(hash string) (models.APIToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.apiTokens {
+		if t.Hash == hash {
+			token := t.APIToken
+			token.CreatedAt = token.CreatedAt.In(s.TZ)
+			return token, nil
+		}
+	}
+	return models.APIToken{}, exceptions.ErrTokenNotFound
+}
+
+func (s *MemoryStore) GetAPITokens() ([]models.APIToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]models.APIToken, 0, len(s.apiTokens))
+	for _, t := range s.apiTokens {
+		token := t.APIToken
+		token.CreatedAt = token.CreatedAt.In(s.TZ)
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+func (s *MemoryStore) GetAuditLog(filter models.AuditLogFilter, page int) ([]models.AuditLogEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := make([]models.AuditLogEntry, 0)
+	for _, e := range slices.Backward(s.auditLog) {
+		if filter.Action != nil && e.Action != *filter.Action {
+			continue
+		}
+		if filter.GameType != nil && (e.GameType == nil || *e.GameType != *filter.GameType) {
+			continue
+		}
+		if filter.GameID != nil && (e.GameID == nil || *e.GameID != *filter.GameID) {
+			continue
+		}
+		e.CreatedAt = e.CreatedAt.In(s.TZ)
+		matches = append(matches, e)
+	}
+
+	entries := make([]models.AuditLogEntry, 0)
+	offset := (page - 1) * 50
+	for i := offset; i >= 0 && i < len(matches) && i < offset+50; i++ {
+		entries = append(entries, matches[i])
+	}
+	return entries, nil
+}
+
+func (s *MemoryStore) GetDeletedGames() ([]models.DeletedGame, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deleted := make([]models.DeletedGame, 0)
+	for _, g := range s.games {
+		if g.DeletedAt != nil {
+			deleted = append(deleted, models.DeletedGame{Game: s.toGame(g), DeletedAt: g.DeletedAt.In(s.TZ), DeleteReason: g.DeleteReason})
+		}
+	}
+	for _, g := range s.doublesGames {
+		if g.DeletedAt != nil {
+			deleted = append(deleted, models.DeletedGame{Game: s.toDoublesGame(g), DeletedAt: g.DeletedAt.In(s.TZ), DeleteReason: g.DeleteReason})
+		}
+	}
+	slices.SortStableFunc(deleted, func(a, b models.DeletedGame) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return deleted, nil
+}
+
+func (s *MemoryStore) GetDoublesGameResults() ([]models.BaseDoublesGame, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := slices.DeleteFunc(slices.Clone(s.doublesGames), func(g memoryDoublesGame) bool {
+		return !g.counts()
+	})
+	slices.SortStableFunc(rows, func(a, b memoryDoublesGame) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	results := make([]models.BaseDoublesGame, 0, len(rows))
+	for _, g := range rows {
+		results = append(results, models.BaseDoublesGame{
+			ID:        g.ID,
+			WinnerIDs: g.WinnerIDs,
+			LoserIDs:  g.LoserIDs,
+			CreatedAt: g.CreatedAt,
+		})
+	}
+	return results, nil
+}
+
+func (s *MemoryStore) GetGames(page int) ([]models.Game, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make([]models.Game, 0, len(s.games)+len(s.doublesGames))
+	for _, g := range s.games {
+		if g.counts() {
+			all = append(all, s.toGame(g))
+		}
+	}
+	for _, g := range s.doublesGames {
+		if g.counts() {
+			all = append(all, s.toDoublesGame(g))
+		}
+	}
+	slices.SortStableFunc(all, func(a, b models.Game) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	games := make([]models.Game, 0)
+	offset := (page - 1) * 50
+	for i := offset; i >= 0 && i < len(all) && i < offset+50; i++ {
+		games = append(games, all[i])
+	}
+	return games, nil
+}
+
+func (s *MemoryStore) GetGameResults() ([]models.BaseGame, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := s.gamesNewestFirst(func(memoryGame) bool { return true })
+	slices.Reverse(rows)
+
+	results := make([]models.BaseGame, 0, len(rows))
+	for _, g := range rows {
+		results = append(results, models.BaseGame{
+			ID:          g.ID,
+			WinnerID:    g.WinnerID,
+			LoserID:     g.LoserID,
+			WinnerScore: g.WinnerScore,
+			LoserScore:  g.LoserScore,
+			Sets:        slices.Clone(g.Sets),
+			CreatedAt:   g.CreatedAt,
+		})
+	}
+	return results, nil
+}
+
+func (s *MemoryStore) GetHeadToHead(p1 int, p2 int) (models.HeadToHead, error) {
+	s.mu.RLock()
+	rows := s.gamesNewestFirst(func(g memoryGame) bool {
+		return (g.WinnerID == p1 && g.LoserID == p2) || (g.WinnerID == p2 && g.LoserID == p1)
+	})
+	games := make([]models.Game, 0, len(rows))
+	for _, g := range rows {
+		games = append(games, s.toGame(g))
+	}
+	// release the lock before reading ratings back through the Store interface
+	s.mu.RUnlock()
+
+	h := summariseHeadToHead(p1, games)
+	if len(h.RecentGames) == 0 {
+		return h, exceptions.ErrNoGamesPlayed
+	}
+
+	var err error
+	h.Player1.WinProbability, h.Player2.WinProbability, err = utils.GetWinProbabilities(s, h.Player1.ID, h.Player2.ID)
+	if err != nil {
+		return h, fmt.Errorf("error calculating win probabilities: %v", err)
+	}
+
+	return h, nil
+}
+
+func (s *MemoryStore) GetIndexPageData(includeInactive bool) (models.IndexPageData, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var cutoff time.Time
+	if includeInactive {
+		cutoff = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	} else {
+		cutoff = time.Now().AddDate(0, -2, 0)
+	}
+
+	leaderboard := make([]models.LeaderboardRow, 0)
+	for _, p := range s.players {
+		if p.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		glicko := p.Glicko
+		leaderboard = append(leaderboard, models.LeaderboardRow{ID: p.ID, Name: p.Name, EloRating: p.EloRating, Glicko: &glicko})
+	}
+	slices.SortFunc(leaderboard, func(a, b models.LeaderboardRow) int {
+		if c := cmp.Compare(b.EloRating, a.EloRating); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	// a player is active in doubles if their latest doubles game is after the cutoff
+	lastDoublesGame := make(map[int]time.Time)
+	for _, g := range s.doublesGames {
+		if !g.counts() {
+			continue
+		}
+		for _, id := range []int{g.WinnerIDs[0], g.WinnerIDs[1], g.LoserIDs[0], g.LoserIDs[1]} {
+			if g.CreatedAt.After(lastDoublesGame[id]) {
+				lastDoublesGame[id] = g.CreatedAt
+			}
+		}
+	}
+
+	doublesLeaderboard := make([]models.LeaderboardRow, 0)
+	for id, lastPlayed := range lastDoublesGame {
+		p, ok := s.players[id]
+		if !ok || lastPlayed.Before(cutoff) {
+			continue
+		}
+		doublesLeaderboard = append(doublesLeaderboard, models.LeaderboardRow{ID: p.ID, Name: p.Name, EloRating: p.DoublesEloRating})
+	}
+	slices.SortFunc(doublesLeaderboard, func(a, b models.LeaderboardRow) int {
+		if c := cmp.Compare(b.EloRating, a.EloRating); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	stats := models.GlobalStats{}
+	for _, g := range s.games {
+		if !g.counts() {
+			continue
+		}
+		stats.TotalGames++
+		if g.WinnerScore != nil {
+			stats.TotalPoints += *g.WinnerScore
+		}
+		if g.LoserScore != nil {
+			stats.TotalPoints += *g.LoserScore
+		}
+		for _, set := range g.Sets {
+			stats.TotalPoints += set.WinnerScore + set.LoserScore
+		}
+	}
+
+	return models.IndexPageData{
+		Leaderboard:        leaderboard,
+		DoublesLeaderboard: doublesLeaderboard,
+		GlobalStats:        stats,
+	}, nil
+}
+
+func (s *MemoryStore) GetLatestGameTime(gameType models.GameType) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest time.Time
+	if gameType == models.DOUBLES {
+		for _, g := range s.doublesGames {
+			if g.counts() && g.CreatedAt.After(latest) {
+				latest = g.CreatedAt
+			}
+		}
+		return latest, nil
+	}
+
+	for _, g := range s.games {
+		if g.counts() && g.CreatedAt.After(latest) {
+			latest = g.CreatedAt
+		}
+	}
+	return latest, nil
+}
+
+func (s *MemoryStore) GetPlayerBasicInfo() ([]models.PlayerBasicInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	players := make([]models.PlayerBasicInfo, 0, len(s.players))
+	for _, p := range s.players {
+		players = append(players, models.PlayerBasicInfo{ID: p.ID, Name: p.Name, CreatedAt: p.CreatedAt})
+	}
+	slices.SortFunc(players, func(a, b models.PlayerBasicInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return players, nil
+}
+
+func (s *MemoryStore) GetPlayerAchievements() ([]models.PlayerAchievement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	awards := make([]models.PlayerAchievement, 0, len(s.playerAchievements))
+	for _, pa := range s.playerAchievements {
+		awards = append(awards, models.PlayerAchievement{
+			PlayerID: pa.PlayerID, AchievementID: pa.AchievementID, CreatedAt: pa.CreatedAt,
+		})
+	}
+	slices.SortFunc(awards, comparePlayerAchievements)
+	return awards, nil
+}
+
+func (s *MemoryStore) GetPlayerDoublesEloRatings(ids [4]int) (models.EloRatings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ratings := make(models.EloRatings)
+	for _, id := range ids {
+		if p, ok := s.players[id]; ok {
+			ratings[id] = p.DoublesEloRating
+		}
+	}
+
+	// check if we found the right number of players
+	if len(ratings) != 4 {
+		return nil, fmt.Errorf("expected 4 players, but query found %d", len(ratings))
+	}
+	return ratings, nil
+}
+
+func (s *MemoryStore) GetPlayerEloRatings(ids [2]int) (models.EloRatings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ratings := make(models.EloRatings)
+	for _, id := range ids {
+		if p, ok := s.players[id]; ok {
+			ratings[id] = p.EloRating
+		}
+	}
+
+	// check if we found the right number of players
+	if len(ratings) != 2 {
+		return nil, fmt.Errorf("expected 2 players, but query found %d", len(ratings))
+	}
+	return ratings, nil
+}
+
+func (s *MemoryStore) GetPlayerGames(id int, limit int) ([]models.Game, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := s.gamesNewestFirst(func(g memoryGame) bool {
+		return g.WinnerID == id || g.LoserID == id
+	})
+
+	games := make([]models.Game, 0)
+	for i := 0; i < len(rows) && i < limit; i++ {
+		games = append(games, s.toGame(rows[i]))
+	}
+	return games, nil
+}
+
+func (s *MemoryStore) GetPlayerProfile(id int) (models.PlayerProfile, error) {
+	var profile models.PlayerProfile
+
+	s.mu.RLock()
+	p, ok := s.players[id]
+	if !ok {
+		s.mu.RUnlock()
+		return profile, fmt.Errorf("error fetching profile: player %d not found", id)
+	}
+
+	profile.ID = id
+	profile.Name = p.Name
+	profile.EloRating = p.EloRating
+	profile.HighestElo = p.HighestElo
+	profile.DoublesEloRating = p.DoublesEloRating
+	profile.Glicko = p.Glicko
+	profile.CreatedAt = p.CreatedAt.In(s.TZ)
+
+	for _, g := range s.games {
+		if !g.counts() {
+			continue
+		}
+		if g.WinnerID == id {
+			profile.GamesWon++
+			profile.GamesPlayed++
+		} else if g.LoserID == id {
+			profile.GamesPlayed++
+		}
+	}
+
+	// ---------------------------------------- achievements
+
+	earned := make([]memoryPlayerAchievement, 0)
+	for _, pa := range s.playerAchievements {
+		if pa.PlayerID == id {
+			earned = append(earned, pa)
+		}
+	}
+	slices.SortFunc(earned, func(a, b memoryPlayerAchievement) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.AchievementID, a.AchievementID)
+	})
+
+	profile.Achievements = make([]models.EarnedAchievement, 0)
+	for _, pa := range earned {
+		for _, a := range s.achievements {
+			if a.ID == pa.AchievementID {
+				profile.Achievements = append(profile.Achievements, models.EarnedAchievement{Achievement: a, EarnedAt: pa.CreatedAt.In(s.TZ)})
+				profile.AchievementScore += a.Points
+				break
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	// ---------------------------------------- recent games
+
+	recentGames, err := s.GetPlayerGames(id, 20)
+	if err != nil {
+		return profile, fmt.Errorf("error fetching profile (recent games): %v", err)
+	}
+	profile.RecentGames = recentGames
+
+	return profile, nil
+}
+
+func (s *MemoryStore) GetRatingHistory(id int) ([]models.RatingChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	changes := make([]models.RatingChange, 0)
+	for _, c := range s.ratingHistory {
+		if c.PlayerID != id {
+			continue
+		}
+
+		// the time of a change is the time of its game. Changes from deleted games are left
+		// out until the next replay removes them.
+		if c.GameType == models.DOUBLES {
+			if i := slices.IndexFunc(s.doublesGames, func(g memoryDoublesGame) bool { return g.ID == c.GameID }); i != -1 {
+				if s.doublesGames[i].DeletedAt != nil {
+					continue
+				}
+				c.PlayedAt = s.doublesGames[i].CreatedAt.In(s.TZ)
+			}
+		} else if i := slices.IndexFunc(s.games, func(g memoryGame) bool { return g.ID == c.GameID }); i != -1 {
+			if s.games[i].DeletedAt != nil {
+				continue
+			}
+			c.PlayedAt = s.games[i].CreatedAt.In(s.TZ)
+		}
+		changes = append(changes, c)
+	}
+
+	slices.SortStableFunc(changes, func(a, b models.RatingChange) int {
+		return a.PlayedAt.Compare(b.PlayedAt)
+	})
+	return changes, nil
+}
+
+func (s *MemoryStore) GetSubmittedDoublesGame(id int) (models.SubmittedGame, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := slices.IndexFunc(s.doublesGames, func(g memoryDoublesGame) bool { return g.ID == id && g.DeletedAt == nil })
+	if i == -1 {
+		return models.SubmittedGame{}, exceptions.ErrGameNotFound
+	}
+	return s.toSubmittedDoublesGame(s.doublesGames[i]), nil
+}
+
+func (s *MemoryStore) GetSubmittedGame(id int) (models.SubmittedGame, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := slices.IndexFunc(s.games, func(g memoryGame) bool { return g.ID == id && g.DeletedAt == nil })
+	if i == -1 {
+		return models.SubmittedGame{}, exceptions.ErrGameNotFound
+	}
+	return s.toSubmittedGame(s.games[i]), nil
+}
+
+func (s *MemoryStore) GetSubmittedGames(status models.GameStatus) ([]models.SubmittedGame, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	games := make([]models.SubmittedGame, 0)
+	for _, g := range s.games {
+		if g.Status == status && g.DeletedAt == nil {
+			games = append(games, s.toSubmittedGame(g))
+		}
+	}
+	for _, g := range s.doublesGames {
+		if g.Status == status && g.DeletedAt == nil {
+			games = append(games, s.toSubmittedDoublesGame(g))
+		}
+	}
+	slices.SortStableFunc(games, func(a, b models.SubmittedGame) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return games, nil
+}
+
+func (s *MemoryStore) ImportGames(games []models.ImportGame, rating float64) (models.ImportSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := models.ImportSummary{Players: make([]string, 0)}
+
+	// the MySQL unique index uses a case-insensitive collation
+	ids := make(map[string]int)
+	for _, p := range s.players {
+		ids[strings.ToLower(p.Name)] = p.ID
+	}
+	playerID := func(name string) int {
+		id, ok := ids[strings.ToLower(name)]
+		if !ok {
+			id = s.addPlayer(name, rating)
+			ids[strings.ToLower(name)] = id
+			summary.Players = append(summary.Players, name)
+		}
+		return id
+	}
+
+	for _, g := range games {
+		s.lastGameID++
+		s.games = append(s.games, memoryGame{
+			ID:          s.lastGameID,
+			WinnerID:    playerID(g.Winner),
+			LoserID:     playerID(g.Loser),
+			WinnerScore: g.WinnerScore,
+			LoserScore:  g.LoserScore,
+			Status:      models.CONFIRMED,
+			SubmittedAt: time.Now().UTC(),
+			CreatedAt:   g.PlayedAt.UTC(),
+		})
+	}
+	summary.Games = len(games)
+	return summary, nil
+}
+
+func (s *MemoryStore) InsertAPIToken(hash string, t models.NewAPIToken) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.PlayerID != nil {
+		if _, ok := s.players[*t.PlayerID]; !ok {
+			return 0, fmt.Errorf("error inserting API token: unknown player ID")
+		}
+	}
+	// the token hash has a unique index
+	if slices.ContainsFunc(s.apiTokens, func(existing memoryAPIToken) bool { return existing.Hash == hash }) {
+		return 0, fmt.Errorf("error inserting API token: duplicate token")
+	}
+
+	s.lastAPITokenID++
+	s.apiTokens = append(s.apiTokens, memoryAPIToken{
+		Hash: hash,
+		APIToken: models.APIToken{
+			ID:        s.lastAPITokenID,
+			Name:      t.Name,
+			Role:      t.Role,
+			PlayerID:  t.PlayerID,
+			CreatedAt: time.Now().UTC(),
+		},
+	})
+	return int64(s.lastAPITokenID), nil
+}
+
+func (s *MemoryStore) InsertAuditLogEntry(e models.AuditLogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the changes are stored as JSON, as they are in MySQL, so they read back the same way
+	if len(e.Changes) > 0 {
+		b, err := json.Marshal(e.Changes)
+		if err != nil {
+			return fmt.Errorf("error inserting audit log entry: %v", err)
+		}
+		e.Changes = nil
+		if err := json.Unmarshal(b, &e.Changes); err != nil {
+			return fmt.Errorf("error inserting audit log entry: %v", err)
+		}
+	}
+
+	s.lastAuditLogID++
+	e.ID = s.lastAuditLogID
+	e.CreatedAt = time.Now().UTC()
+	s.auditLog = append(s.auditLog, e)
+	return nil
+}
+
+func (s *MemoryStore) InsertDoublesGameResult(r models.DoublesGameResult) (int64, error) {
+	return s.insertDoublesGameResult(r, models.CONFIRMED, nil)
+}
+
+// insertDoublesGameResult records a doubles game with the given confirmation state.
+func (s *MemoryStore) insertDoublesGameResult(r models.DoublesGameResult, status models.GameStatus, submittedBy *int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range slices.Concat(r.WinnerIDs, r.LoserIDs) {
+		if _, ok := s.players[id]; !ok {
+			return 0, fmt.Errorf("error inserting doubles game: unknown player ID")
+		}
+	}
+
+	s.lastDoublesGameID++
+	s.doublesGames = append(s.doublesGames, memoryDoublesGame{
+		ID:          s.lastDoublesGameID,
+		WinnerIDs:   [2]int{r.WinnerIDs[0], r.WinnerIDs[1]},
+		LoserIDs:    [2]int{r.LoserIDs[0], r.LoserIDs[1]},
+		WinnerScore: r.WinnerScore,
+		LoserScore:  r.LoserScore,
+		Status:      status,
+		SubmittedBy: submittedBy,
+		SubmittedAt: time.Now().UTC(),
+		CreatedAt:   playedAtOrNow(r.PlayedAt),
+	})
+	return int64(s.lastDoublesGameID), nil
+}
+
+func (s *MemoryStore) InsertDoublesRatingHistory(changes []models.RatingChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.doublesHistory = append(s.doublesHistory, changes...)
+	return nil
+}
+
+func (s *MemoryStore) InsertGameResult(r models.GameResult) (int64, error) {
+	return s.insertGameResult(r, models.CONFIRMED, nil)
+}
+
+func (s *MemoryStore) InsertMatchResult(r models.MatchResult) (int64, error) {
+	return s.insertMatchResult(r, models.CONFIRMED, nil)
+}
+
+func (s *MemoryStore) InsertPendingDoublesGameResult(r models.DoublesGameResult, submittedBy int) (int64, error) {
+	return s.insertDoublesGameResult(r, models.PENDING, &submittedBy)
+}
+
+func (s *MemoryStore) InsertPendingGameResult(r models.GameResult, submittedBy int) (int64, error) {
+	return s.insertGameResult(r, models.PENDING, &submittedBy)
+}
+
+func (s *MemoryStore) InsertPendingMatchResult(r models.MatchResult, submittedBy int) (int64, error) {
+	return s.insertMatchResult(r, models.PENDING, &submittedBy)
+}
+
+func (s *MemoryStore) InsertPlayer(name string, rating float64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the MySQL unique index uses a case-insensitive collation
+	for _, p := range s.players {
+		if strings.EqualFold(p.Name, name) {
+			return 0, fmt.Errorf("error inserting player: duplicate name '%s'", name)
+		}
+	}
+
+	return int64(s.addPlayer(name, rating)), nil
+}
+
+func (s *MemoryStore) InsertPlayerAchievements(awards []models.PlayerAchievement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, award := range awards {
+		// behave like INSERT IGNORE: skip duplicates instead of failing
+		exists := slices.ContainsFunc(s.playerAchievements, func(pa memoryPlayerAchievement) bool {
+			return pa.PlayerID == award.PlayerID && pa.AchievementID == award.AchievementID
+		})
+		if exists {
+			continue
+		}
+		s.playerAchievements = append(s.playerAchievements, memoryPlayerAchievement{
+			PlayerID:      award.PlayerID,
+			AchievementID: award.AchievementID,
+			CreatedAt:     award.CreatedAt.UTC(),
+		})
+	}
+	return nil
+}
+
+func (s *MemoryStore) InsertRatingHistory(changes []models.RatingChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ratingHistory = append(s.ratingHistory, changes...)
+	return nil
+}
+
+func (s *MemoryStore) ReplaceDoublesRatingHistory(changes []models.RatingChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.doublesHistory = slices.Clone(changes)
+	return nil
+}
+
+func (s *MemoryStore) ReplacePlayerAchievements(awards []models.PlayerAchievement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.playerAchievements = make([]memoryPlayerAchievement, 0, len(awards))
+	for _, pa := range awards {
+		s.playerAchievements = append(s.playerAchievements, memoryPlayerAchievement{
+			PlayerID: pa.PlayerID, AchievementID: pa.AchievementID, CreatedAt: pa.CreatedAt.UTC(),
+		})
+	}
+	return nil
+}
+
+func (s *MemoryStore) ReplaceRatingHistory(changes []models.RatingChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ratingHistory = slices.Clone(changes)
+	return nil
+}
+
+func (s *MemoryStore) RestoreArchive(a models.Archive, rating float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.players) > 0 {
+		return exceptions.ErrStoreNotEmpty
+	}
+
+	for _, p := range a.Players {
+		s.players[p.ID] = &memoryPlayer{
+			ID:               p.ID,
+			Name:             p.Name,
+			EloRating:        rating,
+			HighestElo:       rating,
+			DoublesEloRating: rating,
+			Glicko: utils.NewGlickoRating(
+				utils.GLICKO_START_RATING, utils.GLICKO_START_DEVIATION, utils.GLICKO_START_VOLATILITY,
+			),
+			CreatedAt: p.CreatedAt.UTC(),
+			UpdatedAt: p.CreatedAt.UTC(),
+		}
+		s.lastPlayerID = max(s.lastPlayerID, p.ID)
+	}
+
+	for _, g := range a.Games {
+		s.games = append(s.games, memoryGame{
+			ID:           g.ID,
+			WinnerID:     g.WinnerID,
+			LoserID:      g.LoserID,
+			WinnerScore:  g.WinnerScore,
+			LoserScore:   g.LoserScore,
+			BestOf:       g.BestOf,
+			Sets:         slices.Clone(g.Sets),
+			Status:       g.Status,
+			SubmittedBy:  g.SubmittedBy,
+			SubmittedAt:  g.SubmittedAt.UTC(),
+			CreatedAt:    g.CreatedAt.UTC(),
+			DeletedAt:    g.DeletedAt,
+			DeleteReason: g.DeleteReason,
+		})
+		s.lastGameID = max(s.lastGameID, g.ID)
+	}
+
+	for _, g := range a.DoublesGames {
+		s.doublesGames = append(s.doublesGames, memoryDoublesGame{
+			ID:           g.ID,
+			WinnerIDs:    g.WinnerIDs,
+			LoserIDs:     g.LoserIDs,
+			WinnerScore:  g.WinnerScore,
+			LoserScore:   g.LoserScore,
+			Status:       g.Status,
+			SubmittedBy:  g.SubmittedBy,
+			SubmittedAt:  g.SubmittedAt.UTC(),
+			CreatedAt:    g.CreatedAt.UTC(),
+			DeletedAt:    g.DeletedAt,
+			DeleteReason: g.DeleteReason,
+		})
+		s.lastDoublesGameID = max(s.lastDoublesGameID, g.ID)
+	}
+
+	for _, pa := range a.PlayerAchievements {
+		s.playerAchievements = append(s.playerAchievements, memoryPlayerAchievement{
+			PlayerID: pa.PlayerID, AchievementID: pa.AchievementID, CreatedAt: pa.CreatedAt.UTC(),
+		})
+	}
+	return nil
+}
+
+func (s *MemoryStore) RestoreDoublesGame(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.doublesGames {
+		if g := &s.doublesGames[i]; g.ID == id && g.DeletedAt != nil {
+			g.DeletedAt = nil
+			g.DeleteReason = ""
+			return nil
+		}
+	}
+	return exceptions.ErrGameNotFound
+}
+
+func (s *MemoryStore) RestoreGame(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.games {
+		if g := &s.games[i]; g.ID == id && g.DeletedAt != nil {
+			g.DeletedAt = nil
+			g.DeleteReason = ""
+			return nil
+		}
+	}
+	return exceptions.ErrGameNotFound
+}
+
+func (s *MemoryStore) UpdateDoublesEloRatings(players models.EloRatings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, eloRating := range players {
+		if p, ok := s.players[id]; ok {
+			p.DoublesEloRating = eloRating
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateEloRatings(players models.EloRatings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, eloRating := range players {
+		p, ok := s.players[id]
+		if !ok {
+			continue
+		}
+		// updated_at is refreshed on every row update, as MySQL's ON UPDATE CURRENT_TIMESTAMP does
+		if p.EloRating != eloRating {
+			p.UpdatedAt = now
+		}
+		p.EloRating = eloRating
+		p.HighestElo = max(p.HighestElo, eloRating)
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateGameResult(g models.BaseGame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, winnerExists := s.players[g.WinnerID]
+	_, loserExists := s.players[g.LoserID]
+	if !winnerExists || !loserExists {
+		return fmt.Errorf("error updating game: unknown player ID")
+	}
+
+	for i := range s.games {
+		if row := &s.games[i]; row.ID == g.ID && row.DeletedAt == nil {
+			row.WinnerID = g.WinnerID
+			row.LoserID = g.LoserID
+			row.WinnerScore = g.WinnerScore
+			row.LoserScore = g.LoserScore
+			row.CreatedAt = g.CreatedAt.UTC()
+			if len(g.Sets) > 0 {
+				row.Sets = slices.Clone(g.Sets)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateDoublesGameStatus(id int, status models.GameStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.doublesGames {
+		if s.doublesGames[i].ID == id && s.doublesGames[i].DeletedAt == nil {
+			s.doublesGames[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateGameStatus(id int, status models.GameStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.games {
+		if s.games[i].ID == id && s.games[i].DeletedAt == nil {
+			s.games[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateGlickoRatings(players models.GlickoRatings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, r := range players {
+		if p, ok := s.players[id]; ok {
+			p.Glicko = utils.NewGlickoRating(r.Rating, r.Deviation, r.Volatility)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateHighestEloRatings(players models.EloRatings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, highestElo := range players {
+		if p, ok := s.players[id]; ok {
+			p.HighestElo = highestElo
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdatePlayerUpdatedAt(m map[int]time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, updatedAt := range m {
+		if p, ok := s.players[id]; ok {
+			p.UpdatedAt = updatedAt
+		}
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------------------- initialiser
+
+func CreateMemoryStore() *MemoryStore {
+	tz, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		panic(fmt.Sprintf("error fetching timezone: %v", err))
+	}
+
+	return &MemoryStore{
+		TZ:           tz,
+		players:      make(map[int]*memoryPlayer),
+		achievements: slices.Clone(utils.ACHIEVEMENTS),
+	}
+}
@@ -0,0 +1,1114 @@
+package stores
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/events"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/utils"
+)
+
+// -------------------------------------------------------------------------------- Test Helpers
+
+// a helper function that creates a pointer to an interger
+func intPointer(n int) *int {
+	return &n
+}
+
+// createPlayers inserts a player for each name and returns their IDs in order.
+func createPlayers(t *testing.T, s models.Store, names ...string) []int {
+	t.Helper()
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		id, err := s.InsertPlayer(name, 1000)
+		if err != nil {
+			t.Fatalf("unexpected error inserting player: %v", err)
+		}
+		ids = append(ids, int(id))
+	}
+	return ids
+}
+
+// achievements in the built-in catalogue
+const (
+	warmingUp       models.AchievementID = 1
+	chocolate       models.AchievementID = 7
+	onTheScoreboard models.AchievementID = 33
+	oneCommaClub    models.AchievementID = 41
+)
+
+// earnedAt lists achievements as awarded to a player at the given time.
+func earnedAt(playerID int, at time.Time, ids ...models.AchievementID) []models.PlayerAchievement {
+	awards := make([]models.PlayerAchievement, 0, len(ids))
+	for _, id := range ids {
+		awards = append(awards, models.PlayerAchievement{PlayerID: playerID, AchievementID: int(id), CreatedAt: at})
+	}
+	return awards
+}
+
+// -------------------------------------------------------------------------------- Tests
+
+func TestMemoryStoreInsertPlayerRejectsDuplicateName(t *testing.T) {
+	s := CreateMemoryStore()
+	createPlayers(t, s, "Alice")
+
+	if _, err := s.InsertPlayer("alice", 1000); err == nil {
+		t.Errorf("expected an error inserting a duplicate player name, got nil")
+	}
+}
+
+func TestMemoryStoreInsertGameRejectsUnknownPlayer(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice")
+
+	_, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: 99})
+	if err == nil {
+		t.Errorf("expected an error inserting a game with an unknown player, got nil")
+	}
+}
+
+func TestMemoryStoreGetGamesPaginates(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	for range 51 {
+		if _, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	first, err := s.GetGames(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first) != 50 {
+		t.Errorf("expected 50 games on the first page, got %d", len(first))
+	}
+	if first[0].ID != 51 {
+		t.Errorf("expected the newest game first, got game %d", first[0].ID)
+	}
+
+	second, err := s.GetGames(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(second) != 1 || second[0].ID != 1 {
+		t.Errorf("expected only the oldest game on the second page, got %v", second)
+	}
+}
+
+func TestMemoryStoreIndexPageExcludesInactivePlayers(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	err := s.UpdatePlayerUpdatedAt(map[int]time.Time{ids[1]: time.Now().AddDate(0, -3, 0)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	active, err := s.GetIndexPageData(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active.Leaderboard) != 1 || active.Leaderboard[0].ID != ids[0] {
+		t.Errorf("expected only the active player on the leaderboard, got %v", active.Leaderboard)
+	}
+
+	all, err := s.GetIndexPageData(true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all.Leaderboard) != 2 {
+		t.Errorf("expected both players on the full leaderboard, got %v", all.Leaderboard)
+	}
+}
+
+func TestMemoryStoreHeadToHeadWithoutGames(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	_, err := s.GetHeadToHead(ids[0], ids[1])
+	if !errors.Is(err, exceptions.ErrNoGamesPlayed) {
+		t.Errorf("expected ErrNoGamesPlayed, got %v", err)
+	}
+}
+
+func TestMemoryStoreHeadToHeadStats(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	results := []models.GameResult{
+		{WinnerID: ids[0], LoserID: ids[1], WinnerScore: intPointer(11), LoserScore: intPointer(3)},
+		{WinnerID: ids[1], LoserID: ids[0], WinnerScore: intPointer(13), LoserScore: intPointer(11)},
+		{WinnerID: ids[0], LoserID: ids[1]},
+	}
+	for _, r := range results {
+		if _, err := s.InsertGameResult(r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	h, err := s.GetHeadToHead(ids[0], ids[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.TotalGameCount != 3 {
+		t.Errorf("expected 3 games, got %d", h.TotalGameCount)
+	}
+	if h.Player1.GamesWon != 2 || h.Player2.GamesWon != 1 {
+		t.Errorf("expected a 2-1 record, got %d-%d", h.Player1.GamesWon, h.Player2.GamesWon)
+	}
+	if *h.ScoreStats.BiggestBlowout.WinnerScore != 11 || *h.ScoreStats.BiggestBlowout.LoserScore != 3 {
+		t.Errorf("expected the 11-3 game to be the biggest blowout")
+	}
+	if h.Player1.WinProbability != 0.5 {
+		t.Errorf("expected an even win probability, got %v", h.Player1.WinProbability)
+	}
+}
+
+func TestMemoryStoreInsertPlayerAchievementsIgnoresDuplicates(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice")
+
+	earned := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 2 {
+		err := s.InsertPlayerAchievements(earnedAt(ids[0], earned.AddDate(0, 0, i), warmingUp, onTheScoreboard))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	profile, err := s.GetPlayerProfile(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(profile.Achievements) != 2 {
+		t.Errorf("expected 2 achievements, got %d", len(profile.Achievements))
+	}
+	for _, a := range profile.Achievements {
+		if !a.EarnedAt.Equal(earned) {
+			t.Errorf("expected achievement %d to keep the date it was first earned, got %v", a.ID, a.EarnedAt)
+		}
+	}
+}
+
+func TestMemoryStoreRecalculateEloRatings(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	for range 2 {
+		if _, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := utils.RecalculateEloRatings(s, config.Default()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ratings, err := s.GetPlayerEloRatings([2]int{ids[0], ids[1]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Round(ratings[ids[0]]) != 1038 || math.Round(ratings[ids[1]]) != 962 {
+		t.Errorf("expected ratings of 1038 and 962, got %v", ratings)
+	}
+}
+
+func TestMemoryStoreDoublesAffectSinglesWhenConfigured(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol", "Dave")
+
+	r := models.DoublesGameResult{WinnerIDs: []int{ids[0], ids[1]}, LoserIDs: []int{ids[2], ids[3]}}
+	if _, err := s.InsertDoublesGameResult(r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.Doubles.AffectsSingles = true
+	if err := utils.RecalculateEloRatings(s, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := utils.RecalculateDoublesEloRatings(s, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	profile, err := s.GetPlayerProfile(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Round(profile.EloRating) != 1020 || math.Round(profile.DoublesEloRating) != 1020 {
+		t.Errorf("expected singles and doubles ratings of 1020, got %v and %v", profile.EloRating, profile.DoublesEloRating)
+	}
+	if profile.GamesPlayed != 0 {
+		t.Errorf("expected doubles games not to count as singles games played, got %d", profile.GamesPlayed)
+	}
+}
+
+func TestMemoryStoreRecalculatesMatchesPerSet(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	r := models.MatchResult{
+		WinnerID: ids[0],
+		LoserID:  ids[1],
+		BestOf:   3,
+		Sets:     []models.SetScore{{WinnerScore: 11, LoserScore: 7}, {WinnerScore: 11, LoserScore: 9}},
+	}
+	if _, err := s.InsertMatchResult(r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.Matches.RatingMode = config.RATE_PER_SET
+	if err := utils.RecalculateEloRatings(s, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a 2-0 match rated per set is worth the same as two single games
+	ratings, err := s.GetPlayerEloRatings([2]int{ids[0], ids[1]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Round(ratings[ids[0]]) != 1038 || math.Round(ratings[ids[1]]) != 962 {
+		t.Errorf("expected ratings of 1038 and 962, got %v", ratings)
+	}
+}
+
+func TestMemoryStoreRecalculateGlickoRatings(t *testing.T) {
+	s := CreateMemoryStore()
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol")
+
+	for range 3 {
+		if _, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := utils.RecalculateGlickoRatings(s, config.Default()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := s.GetIndexPageData(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	glicko := make(map[int]models.GlickoRating)
+	for _, row := range data.Leaderboard {
+		glicko[row.ID] = *row.Glicko
+	}
+
+	if glicko[ids[0]].Rating <= 1000 || glicko[ids[1]].Rating >= 1000 {
+		t.Errorf("expected the winner to gain and the loser to lose, got %v and %v", glicko[ids[0]], glicko[ids[1]])
+	}
+	if glicko[ids[0]].Deviation >= glicko[ids[2]].Deviation {
+		t.Errorf("expected a player with games to be rated more confidently than one without")
+	}
+}
+
+func TestMemoryStoreLiveEloUpdatesMatchRecalculation(t *testing.T) {
+	s := CreateMemoryStore()
+	cfg := config.Default()
+	cfg.Ratings.Elo.StartRating = 1200
+	cfg.Ratings.Elo.ProvisionalGames = 2
+	cfg.Ratings.Elo.MarginOfVictory = true
+
+	ids := make([]int, 0, 2)
+	for _, name := range []string{"Alice", "Bob"} {
+		id, err := s.InsertPlayer(name, cfg.Ratings.Elo.StartRating)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, int(id))
+	}
+
+	results := []models.GameResult{
+		{WinnerID: ids[0], LoserID: ids[1], WinnerScore: intPointer(11), LoserScore: intPointer(2)},
+		{WinnerID: ids[1], LoserID: ids[0], WinnerScore: intPointer(12), LoserScore: intPointer(10)},
+		{WinnerID: ids[0], LoserID: ids[1]},
+	}
+	for _, r := range results {
+		id, err := s.InsertGameResult(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := utils.UpdatePlayersEloRating(s, cfg, int(id), r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	live, err := s.GetPlayerEloRatings([2]int{ids[0], ids[1]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	liveHistory, err := s.GetRatingHistory(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := utils.RecalculateEloRatings(s, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replayed, err := s.GetPlayerEloRatings([2]int{ids[0], ids[1]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, id := range ids {
+		if math.Abs(live[id]-replayed[id]) > 0.00001 {
+			t.Errorf("expected live and replayed ratings to agree, got %v and %v", live[id], replayed[id])
+		}
+	}
+
+	replayedHistory, err := s.GetRatingHistory(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(liveHistory) != 3 || len(replayedHistory) != 3 {
+		t.Fatalf("expected 3 rating changes, got %d live and %d replayed", len(liveHistory), len(replayedHistory))
+	}
+	for i := range liveHistory {
+		if liveHistory[i].GameID != replayedHistory[i].GameID ||
+			math.Abs(liveHistory[i].RatingAfter-replayedHistory[i].RatingAfter) > 0.00001 {
+			t.Errorf("expected live and replayed history to agree, got %+v and %+v", liveHistory[i], replayedHistory[i])
+		}
+	}
+	if math.Abs(replayedHistory[2].RatingAfter-live[ids[0]]) > 0.00001 {
+		t.Errorf("expected the last change to end at the current rating %v, got %v", live[ids[0]], replayedHistory[2].RatingAfter)
+	}
+}
+
+func TestMemoryStoreGameRatingChangesFollowReplays(t *testing.T) {
+	s := CreateMemoryStore()
+	cfg := config.Default()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	gameIDs := make([]int, 0, 2)
+	for range 2 {
+		r := models.GameResult{WinnerID: ids[0], LoserID: ids[1]}
+		id, err := s.InsertGameResult(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := utils.UpdatePlayersEloRating(s, cfg, int(id), r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		gameIDs = append(gameIDs, int(id))
+	}
+
+	games, err := s.GetPlayerGames(ids[0], 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 1 || games[0].WinnerRatingChange == nil || games[0].WinProbability == nil {
+		t.Fatalf("expected the latest game to include its rating changes, got %+v", games)
+	}
+	if *games[0].WinnerRatingChange >= 20 || *games[0].WinProbability <= 0.5 {
+		t.Errorf("expected the favourite to gain less than 20 points, got %v at %v", *games[0].WinnerRatingChange, *games[0].WinProbability)
+	}
+
+	// once the first game is gone, the second was played between equals
+	if err := s.DeleteGame(gameIDs[0], "recorded twice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := utils.RecalculateEloRatings(s, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	games, err = s.GetPlayerGames(ids[0], 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Round(*games[0].WinnerRatingChange) != 20 || *games[0].WinProbability != 0.5 {
+		t.Errorf("expected the replayed game to be worth 20 points at even odds, got %v at %v", *games[0].WinnerRatingChange, *games[0].WinProbability)
+	}
+}
+
+// checkConfirmationWorkflow records a pending game and checks that it stays out of the
+// games, profiles and stats until it is confirmed.
+func checkConfirmationWorkflow(t *testing.T, s models.Store) {
+	t.Helper()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	id, err := s.InsertPendingGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1], WinnerScore: intPointer(11), LoserScore: intPointer(7)}, ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	countGames := func() (int, int, int) {
+		t.Helper()
+		results, err := s.GetGameResults()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		profile, err := s.GetPlayerProfile(ids[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		index, err := s.GetIndexPageData(true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return len(results), profile.GamesPlayed, index.GlobalStats.TotalGames
+	}
+
+	if results, played, total := countGames(); results != 0 || played != 0 || total != 0 {
+		t.Errorf("expected a pending game to be left out, got %d results, %d played and %d in total", results, played, total)
+	}
+
+	pending, err := s.GetSubmittedGames(models.PENDING)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != int(id) || pending[0].SubmittedBy == nil || *pending[0].SubmittedBy != ids[0] {
+		t.Fatalf("expected the game pending on Alice's submission, got %+v", pending)
+	}
+
+	if err := s.UpdateGameStatus(int(id), models.CONFIRMED); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results, played, total := countGames(); results != 1 || played != 1 || total != 1 {
+		t.Errorf("expected the confirmed game to count, got %d results, %d played and %d in total", results, played, total)
+	}
+
+	game, err := s.GetSubmittedGame(int(id))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if game.Status != models.CONFIRMED {
+		t.Errorf("expected the game to be confirmed, got %v", game.Status)
+	}
+	if _, err := s.GetSubmittedGame(int(id) + 1); !errors.Is(err, exceptions.ErrGameNotFound) {
+		t.Errorf("expected ErrGameNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStoreConfirmationWorkflow(t *testing.T) {
+	checkConfirmationWorkflow(t, CreateMemoryStore())
+}
+
+// checkDoublesConfirmationWorkflow submits a pending doubles game, checking that it is left
+// out of the results until it is confirmed.
+func checkDoublesConfirmationWorkflow(t *testing.T, s models.Store) {
+	t.Helper()
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol", "Dave")
+
+	id, err := s.InsertPendingDoublesGameResult(models.DoublesGameResult{WinnerIDs: []int{ids[0], ids[1]}, LoserIDs: []int{ids[2], ids[3]}}, ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, err := s.GetDoublesGameResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected a pending doubles game to be left out, got %+v", results)
+	}
+
+	pending, err := s.GetSubmittedGames(models.PENDING)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != int(id) || pending[0].Type != models.DOUBLES || pending[0].SubmittedBy == nil || *pending[0].SubmittedBy != ids[0] {
+		t.Fatalf("expected the doubles game pending on Alice's submission, got %+v", pending)
+	}
+
+	if err := s.UpdateDoublesGameStatus(int(id), models.CONFIRMED); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results, err = s.GetDoublesGameResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected the confirmed doubles game to count, got %+v", results)
+	}
+
+	game, err := s.GetSubmittedDoublesGame(int(id))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if game.Status != models.CONFIRMED {
+		t.Errorf("expected the doubles game to be confirmed, got %v", game.Status)
+	}
+	if _, err := s.GetSubmittedDoublesGame(int(id) + 1); !errors.Is(err, exceptions.ErrGameNotFound) {
+		t.Errorf("expected ErrGameNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStoreDoublesConfirmationWorkflow(t *testing.T) {
+	checkDoublesConfirmationWorkflow(t, CreateMemoryStore())
+}
+
+// checkSubmittedAt records a backdated pending game, checking that it keeps both when it
+// was played and when it was recorded.
+func checkSubmittedAt(t *testing.T, s models.Store) {
+	t.Helper()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	playedAt := time.Now().Add(-60 * time.Hour).Truncate(time.Second)
+	id, err := s.InsertPendingGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1], PlayedAt: &playedAt}, ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	game, err := s.GetSubmittedGame(int(id))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !game.CreatedAt.Equal(playedAt) {
+		t.Errorf("expected the game to be played at %v, got %v", playedAt, game.CreatedAt)
+	}
+	if since := time.Since(game.SubmittedAt); since < -time.Minute || since > time.Minute {
+		t.Errorf("expected the game to be submitted just now, got %v", game.SubmittedAt)
+	}
+}
+
+func TestMemoryStoreSubmittedAt(t *testing.T) {
+	checkSubmittedAt(t, CreateMemoryStore())
+}
+
+// checkSoftDelete deletes a singles and a doubles game, then restores the singles game,
+// checking that deleted games are only listed as deleted.
+func checkSoftDelete(t *testing.T, s models.Store) {
+	t.Helper()
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol", "Dave")
+
+	gameID, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doublesID, err := s.InsertDoublesGameResult(models.DoublesGameResult{WinnerIDs: []int{ids[0], ids[1]}, LoserIDs: []int{ids[2], ids[3]}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := s.DeleteGame(int(gameID), "recorded twice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.DeleteDoublesGame(int(doublesID), "wrong teams"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.DeleteGame(int(gameID), "recorded twice"); !errors.Is(err, exceptions.ErrGameNotFound) {
+		t.Errorf("expected ErrGameNotFound when deleting a deleted game, got %v", err)
+	}
+
+	games, err := s.GetGames(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 0 {
+		t.Errorf("expected deleted games to be left out, got %+v", games)
+	}
+	doubles, err := s.GetDoublesGameResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(doubles) != 0 {
+		t.Errorf("expected deleted doubles games to be left out of the replay, got %+v", doubles)
+	}
+
+	deleted, err := s.GetDeletedGames()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 2 {
+		t.Fatalf("expected 2 deleted games, got %+v", deleted)
+	}
+	reasons := map[models.GameType]string{deleted[0].Type: deleted[0].DeleteReason, deleted[1].Type: deleted[1].DeleteReason}
+	if reasons[models.SINGLES] != "recorded twice" || reasons[models.DOUBLES] != "wrong teams" {
+		t.Errorf("expected the deleted games with their reasons, got %+v", deleted)
+	}
+
+	if err := s.RestoreGame(int(gameID)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.RestoreGame(int(gameID)); !errors.Is(err, exceptions.ErrGameNotFound) {
+		t.Errorf("expected ErrGameNotFound when restoring a game that is not deleted, got %v", err)
+	}
+	results, err := s.GetGameResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != int(gameID) {
+		t.Errorf("expected the restored game to count again, got %+v", results)
+	}
+}
+
+// checkAuditLog records entries and reads them back newest first, filtered by game.
+func checkAuditLog(t *testing.T, s models.Store) {
+	t.Helper()
+	singles, doubles, id := models.SINGLES, models.DOUBLES, 1
+	entries := []models.AuditLogEntry{
+		{Action: models.AUDIT_CREATE, Actor: "Alice's phone", TokenID: intPointer(2), GameType: &singles, GameID: &id},
+		{Action: models.AUDIT_CREATE, Actor: "Alice's phone", TokenID: intPointer(2), GameType: &doubles, GameID: &id},
+		{Action: models.AUDIT_DELETE, Actor: "ADMIN_TOKEN", GameType: &singles, GameID: &id, Reason: "recorded twice"},
+		{Action: models.AUDIT_UPDATE, Actor: "ADMIN_TOKEN", GameType: &doubles, GameID: &id, Changes: map[string]models.FieldChange{"loserScore": {From: 9, To: 8}}},
+		{Action: models.AUDIT_RECALCULATE, Actor: "ADMIN_TOKEN"},
+	}
+	for _, e := range entries {
+		if err := s.InsertAuditLogEntry(e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	all, err := s.GetAuditLog(models.AuditLogFilter{}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 5 || all[0].Action != models.AUDIT_RECALCULATE || all[0].GameID != nil {
+		t.Fatalf("expected every entry, newest first, got %+v", all)
+	}
+	if change := all[1].Changes["loserScore"]; change.From != 9.0 || change.To != 8.0 {
+		t.Errorf("expected the update's old and new loser score, got %+v", all[1].Changes)
+	}
+
+	game, err := s.GetAuditLog(models.AuditLogFilter{GameType: &singles, GameID: &id}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(game) != 2 || game[0].Reason != "recorded twice" || game[1].TokenID == nil || *game[1].TokenID != 2 {
+		t.Errorf("expected the singles game's delete and create, got %+v", game)
+	}
+
+	action := models.AUDIT_CREATE
+	created, err := s.GetAuditLog(models.AuditLogFilter{Action: &action}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 0 {
+		t.Errorf("expected an empty second page, got %+v", created)
+	}
+}
+
+func TestMemoryStoreSoftDelete(t *testing.T) {
+	checkSoftDelete(t, CreateMemoryStore())
+}
+
+func TestMemoryStoreAuditLog(t *testing.T) {
+	checkAuditLog(t, CreateMemoryStore())
+}
+
+// checkUpdateGameResult moves a game after a later one and swaps its winner, checking
+// that the replay order follows the corrected time.
+func checkUpdateGameResult(t *testing.T, s models.Store) {
+	t.Helper()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	first, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1], WinnerScore: intPointer(11), LoserScore: intPointer(7)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	corrected := models.BaseGame{
+		ID:          int(first),
+		WinnerID:    ids[1],
+		LoserID:     ids[0],
+		WinnerScore: intPointer(11),
+		LoserScore:  intPointer(9),
+		CreatedAt:   time.Now().Add(time.Hour),
+	}
+	if err := s.UpdateGameResult(corrected); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, err := s.GetGameResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != int(second) || results[1].ID != int(first) {
+		t.Fatalf("expected the corrected game to be replayed last, got %+v", results)
+	}
+	if results[1].WinnerID != ids[1] || *results[1].LoserScore != 9 {
+		t.Errorf("expected Bob's 11-9 win, got %+v", results[1])
+	}
+}
+
+func TestMemoryStoreUpdateGameResult(t *testing.T) {
+	checkUpdateGameResult(t, CreateMemoryStore())
+}
+
+func checkBackdatedGame(t *testing.T, s models.Store) {
+	t.Helper()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	latest, err := s.GetLatestGameTime(models.SINGLES)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !latest.IsZero() {
+		t.Errorf("expected no latest game, got %v", latest)
+	}
+
+	recent, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	playedAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	backdated, err := s.InsertGameResult(models.GameResult{WinnerID: ids[1], LoserID: ids[0], PlayedAt: &playedAt})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a pending game does not count towards ratings until it is confirmed
+	if _, err := s.InsertPendingGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]}, ids[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, err := s.GetGameResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != int(backdated) || results[1].ID != int(recent) {
+		t.Fatalf("expected the backdated game to be replayed first, got %+v", results)
+	}
+	if !results[0].CreatedAt.Equal(playedAt) {
+		t.Errorf("expected the backdated game to be played at %v, got %v", playedAt, results[0].CreatedAt)
+	}
+
+	latest, err = s.GetLatestGameTime(models.SINGLES)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !latest.Equal(results[1].CreatedAt) {
+		t.Errorf("expected the latest game to be played at %v, got %v", results[1].CreatedAt, latest)
+	}
+
+	latest, err = s.GetLatestGameTime(models.DOUBLES)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !latest.IsZero() {
+		t.Errorf("expected no latest doubles game, got %v", latest)
+	}
+}
+
+func TestMemoryStoreBackdatedGame(t *testing.T) {
+	checkBackdatedGame(t, CreateMemoryStore())
+}
+
+func checkImportGames(t *testing.T, s models.Store) {
+	t.Helper()
+	createPlayers(t, s, "Alice")
+
+	playedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	games := []models.ImportGame{
+		{Line: 2, Winner: "alice", Loser: "Bob", WinnerScore: intPointer(11), LoserScore: intPointer(4), PlayedAt: playedAt},
+		{Line: 3, Winner: "BOB", Loser: "Carol", PlayedAt: playedAt.Add(time.Hour)},
+	}
+	summary, err := s.ImportGames(games, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Games != 2 || !slices.Equal(summary.Players, []string{"Bob", "Carol"}) {
+		t.Errorf("expected 2 games and new players Bob and Carol, got %+v", summary)
+	}
+
+	players, err := s.GetPlayerBasicInfo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(players) != 3 {
+		t.Fatalf("expected existing players to be matched by name, got %+v", players)
+	}
+
+	results, err := s.GetGameResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(results))
+	}
+	if results[0].WinnerID != players[0].ID || results[0].LoserID != players[1].ID || !results[0].CreatedAt.Equal(playedAt) {
+		t.Errorf("expected Alice's win over Bob at %v, got %+v", playedAt, results[0])
+	}
+	if results[1].WinnerID != players[1].ID || results[1].LoserID != players[2].ID {
+		t.Errorf("expected Bob's win over Carol, got %+v", results[1])
+	}
+}
+
+func TestMemoryStoreImportGames(t *testing.T) {
+	checkImportGames(t, CreateMemoryStore())
+}
+
+// checkArchiveRoundTrip exports a record with every kind of game from one store, restores
+// it into another and checks that the second store exports the same record.
+func checkArchiveRoundTrip(t *testing.T, from models.Store, to models.Store) {
+	t.Helper()
+	ids := createPlayers(t, from, "Alice", "Bob", "Carol", "Dave")
+
+	if _, err := from.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1], WinnerScore: intPointer(11), LoserScore: intPointer(0)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sets := []models.SetScore{{WinnerScore: 11, LoserScore: 5}, {WinnerScore: 11, LoserScore: 9}}
+	if _, err := from.InsertMatchResult(models.MatchResult{WinnerID: ids[1], LoserID: ids[2], BestOf: 3, Sets: sets}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := from.InsertPendingGameResult(models.GameResult{WinnerID: ids[2], LoserID: ids[3]}, ids[2]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deleted, err := from.InsertGameResult(models.GameResult{WinnerID: ids[3], LoserID: ids[0]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := from.DeleteGame(int(deleted), "recorded twice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := from.InsertDoublesGameResult(models.DoublesGameResult{WinnerIDs: []int{ids[0], ids[1]}, LoserIDs: []int{ids[2], ids[3]}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := from.InsertPlayerAchievements(earnedAt(ids[0], time.Now(), warmingUp, chocolate)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archive, err := from.ExportArchive()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(archive.Players) != 4 || len(archive.Games) != 4 || len(archive.DoublesGames) != 1 || len(archive.PlayerAchievements) != 2 {
+		t.Fatalf("expected every player, game and achievement to be exported, got %+v", archive)
+	}
+
+	if err := to.RestoreArchive(archive, 1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored, err := to.ExportArchive()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(restored.Players, archive.Players) {
+		t.Errorf("expected players %+v, got %+v", archive.Players, restored.Players)
+	}
+	if !reflect.DeepEqual(restored.Games, archive.Games) {
+		t.Errorf("expected games %+v, got %+v", archive.Games, restored.Games)
+	}
+	if !reflect.DeepEqual(restored.DoublesGames, archive.DoublesGames) {
+		t.Errorf("expected doubles games %+v, got %+v", archive.DoublesGames, restored.DoublesGames)
+	}
+	if !reflect.DeepEqual(restored.PlayerAchievements, archive.PlayerAchievements) {
+		t.Errorf("expected player achievements %+v, got %+v", archive.PlayerAchievements, restored.PlayerAchievements)
+	}
+
+	// new rows carry on from the restored IDs
+	id, err := to.InsertPlayer("Erin", 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 5 {
+		t.Errorf("expected the next player to have ID 5, got %d", id)
+	}
+
+	if err := to.RestoreArchive(archive, 1000); !errors.Is(err, exceptions.ErrStoreNotEmpty) {
+		t.Errorf("expected ErrStoreNotEmpty when restoring over existing players, got %v", err)
+	}
+}
+
+func TestMemoryStoreArchiveRoundTrip(t *testing.T) {
+	checkArchiveRoundTrip(t, CreateMemoryStore(), CreateMemoryStore())
+}
+
+// checkRebuildAchievements deletes a game and checks that a rebuild takes away what it
+// earned, gives out what was missing and dates each achievement by its game.
+func checkRebuildAchievements(t *testing.T, s models.Store) {
+	t.Helper()
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	playedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	whitewash, err := s.InsertGameResult(models.GameResult{
+		WinnerID: ids[0], LoserID: ids[1], WinnerScore: intPointer(11), LoserScore: intPointer(0), PlayedAt: &playedAt,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rematch := playedAt.Add(time.Hour)
+	if _, err := s.InsertGameResult(models.GameResult{WinnerID: ids[1], LoserID: ids[0], PlayedAt: &rematch}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = s.InsertPlayerAchievements(earnedAt(ids[0], time.Now(), warmingUp, chocolate, oneCommaClub))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := s.DeleteGame(int(whitewash), "never happened"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := utils.RecalculateEloRatings(s, config.Default()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := utils.RebuildAchievements(s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	awards, err := s.GetPlayerAchievements()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	earned := make(map[[2]int]time.Time)
+	for _, pa := range awards {
+		earned[[2]int{pa.PlayerID, pa.AchievementID}] = pa.CreatedAt
+	}
+
+	expected := map[[2]int]time.Time{
+		{ids[0], int(warmingUp)}:       rematch,
+		{ids[1], int(warmingUp)}:       rematch,
+		{ids[1], int(onTheScoreboard)}: rematch,
+	}
+	for key, at := range expected {
+		if !earned[key].Equal(at) {
+			t.Errorf("expected player %d to have earned achievement %d at %v, got %v", key[0], key[1], at, earned[key])
+		}
+	}
+	if _, ok := earned[[2]int{ids[0], int(chocolate)}]; ok {
+		t.Error("expected Chocolate to be taken away with the deleted game")
+	}
+	if _, ok := earned[[2]int{ids[0], int(oneCommaClub)}]; ok {
+		t.Error("expected an achievement without any games behind it to be taken away")
+	}
+	if len(awards) != len(expected) {
+		t.Errorf("expected %d achievements, got %+v", len(expected), awards)
+	}
+}
+
+func TestMemoryStoreRebuildAchievements(t *testing.T) {
+	checkRebuildAchievements(t, CreateMemoryStore())
+}
+
+// checkAchievementStats awards an achievement to two of three active players and checks
+// its holders and rarity.
+func checkAchievementStats(t *testing.T, s models.Store) {
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol")
+
+	first := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	awards := append(earnedAt(ids[1], first.Add(time.Hour), warmingUp), earnedAt(ids[0], first, warmingUp)...)
+	if err := s.InsertPlayerAchievements(awards); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats, err := utils.GetAchievementStats(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, a := range stats {
+		switch models.AchievementID(a.ID) {
+		case warmingUp:
+			if a.HolderCount != 2 || a.ActivePercentage != 66.7 || a.Rarity != models.COMMON {
+				t.Errorf("expected Warming Up to be a common achievement held by 2 players, got %+v", a)
+			}
+			if a.FirstUnlock == nil || a.FirstUnlock.Name != "Alice" || !a.FirstUnlock.EarnedAt.Equal(first) {
+				t.Errorf("expected Alice to have unlocked Warming Up first, got %+v", a.FirstUnlock)
+			}
+			if a.LatestUnlock == nil || a.LatestUnlock.Name != "Bob" {
+				t.Errorf("expected Bob to have unlocked Warming Up most recently, got %+v", a.LatestUnlock)
+			}
+		case chocolate:
+			if a.HolderCount != 0 || a.Rarity != models.LEGENDARY || a.FirstUnlock != nil {
+				t.Errorf("expected Chocolate to be a legendary achievement held by nobody, got %+v", a)
+			}
+		}
+	}
+
+	detail, err := utils.GetAchievementDetail(s, int(warmingUp))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(detail.Holders) != 2 || detail.Holders[0].ID != ids[0] || detail.Holders[1].ID != ids[1] {
+		t.Errorf("expected Alice then Bob to hold Warming Up, got %+v", detail.Holders)
+	}
+
+	if _, err := utils.GetAchievementDetail(s, 9999); !errors.Is(err, exceptions.ErrAchievementNotFound) {
+		t.Errorf("expected ErrAchievementNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStoreAchievementStats(t *testing.T) {
+	checkAchievementStats(t, CreateMemoryStore())
+}
+
+func checkAchievementScores(t *testing.T, s models.Store) {
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol")
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	awards := append(earnedAt(ids[1], at, warmingUp), earnedAt(ids[2], at, warmingUp, chocolate)...)
+	if err := s.InsertPlayerAchievements(awards); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	profile, err := s.GetPlayerProfile(ids[2])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.AchievementScore != 55 {
+		t.Errorf("expected Carol to have 55 achievement points, got %d", profile.AchievementScore)
+	}
+
+	data, err := utils.GetIndexPageData(s, config.Default(), false, utils.SORT_BY_ACHIEVEMENTS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var order []int
+	var scores []int
+	for _, row := range data.Leaderboard {
+		order = append(order, row.ID)
+		scores = append(scores, row.AchievementScore)
+	}
+	if !slices.Equal(order, []int{ids[2], ids[1], ids[0]}) || !slices.Equal(scores, []int{55, 5, 0}) {
+		t.Errorf("expected Carol, Bob then Alice with 55, 5 and 0 points, got %v with %v", order, scores)
+	}
+}
+
+func TestMemoryStoreAchievementScores(t *testing.T) {
+	checkAchievementScores(t, CreateMemoryStore())
+}
+
+func checkAchievementUnlockEvents(t *testing.T, s models.Store) {
+	ids := createPlayers(t, s, "Alice", "Bob")
+	broker := events.CreateBroker()
+	subscription, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+
+	result := models.GameResult{WinnerID: ids[0], LoserID: ids[1], WinnerScore: intPointer(11), LoserScore: intPointer(5)}
+	unlocks := func() []events.AchievementUnlock {
+		if _, err := s.InsertGameResult(result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := utils.UpdatePlayerAchievements(s, result, broker); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		unlocks := make([]events.AchievementUnlock, 0)
+		for len(subscription) > 0 {
+			unlocks = append(unlocks, (<-subscription).Data.(events.AchievementUnlock))
+		}
+		return unlocks
+	}
+
+	first := unlocks()
+	if len(first) != 3 {
+		t.Fatalf("expected Warming Up for both players and On The Scoreboard for Alice, got %+v", first)
+	}
+	for _, u := range first {
+		if u.Player.Name == "Bob" && models.AchievementID(u.Achievement.ID) != warmingUp {
+			t.Errorf("expected Bob to unlock only Warming Up, got %+v", u)
+		}
+	}
+
+	if second := unlocks(); len(second) != 0 {
+		t.Errorf("expected achievements already held not to be published again, got %+v", second)
+	}
+}
+
+func TestMemoryStoreAchievementUnlockEvents(t *testing.T) {
+	checkAchievementUnlockEvents(t, CreateMemoryStore())
+}
@@ -2,45 +2,232 @@ package stores
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 	_ "time/tzdata"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/migrations"
 	"github.com/jda5/luinc-pong/src/internal/models"
 	"github.com/jda5/luinc-pong/src/internal/utils"
 )
 
 // -------------------------------------------------------------------------------- queries
 
-const DELETE_GAME_QUERY string = `
-DELETE FROM games
+const DELETE_API_TOKEN_QUERY string = `
+DELETE FROM api_tokens
 WHERE
 	id = ?;
 `
 
+// games are only marked as deleted, so that they can be restored
+const DELETE_DOUBLES_GAME_QUERY string = `
+UPDATE doubles_games
+SET
+	deleted_at = CURRENT_TIMESTAMP,
+	delete_reason = ?
+WHERE
+	id = ?
+		AND deleted_at IS NULL;
+`
+
+const DELETE_GAME_QUERY string = `
+UPDATE games
+SET
+	deleted_at = CURRENT_TIMESTAMP,
+	delete_reason = ?
+WHERE
+	id = ?
+		AND deleted_at IS NULL;
+`
+
+const DELETE_GAME_SETS_QUERY string = `
+DELETE FROM game_sets
+WHERE
+	game_id = ?;
+`
+
+const INSERT_API_TOKEN_QUERY string = `
+INSERT INTO api_tokens (token_hash, name, role, player_id)
+VALUES (?, ?, ?, ?);
+`
+
+const INSERT_AUDIT_LOG_ENTRY_QUERY string = `
+INSERT INTO audit_log (action, actor, token_id, game_type, game_id, reason, changes)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+`
+
+const INSERT_ARCHIVE_DOUBLES_GAME_QUERY string = `
+INSERT INTO doubles_games (
+	id, winner1_id, winner2_id, loser1_id, loser2_id, winner_score, loser_score, status, submitted_by, submitted_at,
+	created_at, deleted_at, delete_reason
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+
+const INSERT_ARCHIVE_GAME_QUERY string = `
+INSERT INTO games (
+	id, winner_id, loser_id, winner_score, loser_score, best_of, status, submitted_by, submitted_at, created_at,
+	deleted_at, delete_reason
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+
+const INSERT_PLAYER_ACHIEVEMENT_QUERY string = `
+INSERT INTO player_achievement (player_id, achievement_id, created_at)
+VALUES (?, ?, ?);
+`
+
+const DELETE_PLAYER_ACHIEVEMENTS_QUERY string = `
+DELETE FROM player_achievement;
+`
+
+const INSERT_ARCHIVE_PLAYER_QUERY string = `
+INSERT INTO players (id, name, elo_rating, highest_elo, doubles_elo_rating, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+`
+
 const INSERT_GAME_QUERY string = `
-INSERT INTO games (winner_id, loser_id, winner_score, loser_score)
+INSERT INTO games (winner_id, loser_id, winner_score, loser_score, status, submitted_by, submitted_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, COALESCE(?, CURRENT_TIMESTAMP));
+`
+
+const INSERT_GAME_SET_QUERY string = `
+INSERT INTO game_sets (game_id, set_number, winner_score, loser_score)
 VALUES (?, ?, ?, ?);
 `
 
+const INSERT_DOUBLES_GAME_QUERY string = `
+INSERT INTO doubles_games (
+	winner1_id, winner2_id, loser1_id, loser2_id, winner_score, loser_score, status, submitted_by, submitted_at,
+	created_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, COALESCE(?, CURRENT_TIMESTAMP));
+`
+
+const INSERT_MATCH_QUERY string = `
+INSERT INTO games (winner_id, loser_id, best_of, status, submitted_by, submitted_at, created_at)
+VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, COALESCE(?, CURRENT_TIMESTAMP));
+`
+
+const INSERT_RATING_HISTORY_QUERY string = `
+INSERT INTO rating_history (player_id, game_id, doubles_game_id, rating_before, rating_after)
+VALUES (?, ?, ?, ?, ?);
+`
+
+const DELETE_RATING_HISTORY_QUERY string = `
+DELETE FROM rating_history;
+`
+
+const INSERT_DOUBLES_RATING_HISTORY_QUERY string = `
+INSERT INTO doubles_rating_history (player_id, doubles_game_id, rating_before, rating_after)
+VALUES (?, ?, ?, ?);
+`
+
+const DELETE_DOUBLES_RATING_HISTORY_QUERY string = `
+DELETE FROM doubles_rating_history;
+`
+
 const INSERT_PLAYER_QUERY string = `
-INSERT INTO players (name)
-VALUES (?);
+INSERT INTO players (name, elo_rating, highest_elo, doubles_elo_rating)
+VALUES (?, ?, ?, ?);
+`
+
+const SELECT_API_TOKEN_QUERY string = `
+SELECT
+	id,
+	name,
+	role,
+	player_id,
+	created_at
+FROM api_tokens
+WHERE
+	token_hash = ?;
+`
+
+const SELECT_API_TOKENS_QUERY string = `
+SELECT
+	id,
+	name,
+	role,
+	player_id,
+	created_at
+FROM api_tokens
+ORDER BY id ASC;
+`
+
+const SELECT_ARCHIVE_DOUBLES_GAMES_QUERY string = `
+SELECT
+	id, winner1_id, winner2_id, loser1_id, loser2_id, winner_score, loser_score, status, submitted_by, submitted_at,
+	created_at, deleted_at, delete_reason
+FROM
+	doubles_games
+ORDER BY id ASC;
+`
+
+const SELECT_ARCHIVE_GAMES_QUERY string = `
+SELECT
+	id, winner_id, loser_id, winner_score, loser_score, best_of, status, submitted_by, submitted_at, created_at,
+	deleted_at, delete_reason
+FROM
+	games
+ORDER BY id ASC;
+`
+
+const SELECT_ALL_PLAYER_ACHIEVEMENTS_QUERY string = `
+SELECT
+	player_id, achievement_id, created_at
+FROM
+	player_achievement
+ORDER BY player_id ASC, achievement_id ASC;
+`
+
+const SELECT_ARCHIVE_PLAYERS_QUERY string = `
+SELECT
+	id, name, created_at
+FROM
+	players
+ORDER BY id ASC;
+`
+
+// a nil filter parameter matches every entry
+const SELECT_AUDIT_LOG_QUERY string = `
+SELECT
+	id, action, actor, token_id, game_type, game_id, reason, changes, created_at
+FROM
+	audit_log
+WHERE
+	(? IS NULL OR action = ?)
+		AND (? IS NULL OR game_type = ?)
+		AND (? IS NULL OR game_id = ?)
+ORDER BY id DESC
+LIMIT ? OFFSET ?;
 `
 
 const SELECT_ACHIEVEMENTS_QUERY string = `
 SELECT
-	id, title, description
+	id, title, description, family, tier, points
 FROM
 	achievement
 `
 
+const SELECT_PLAYER_ID_BY_NAME_QUERY string = `
+SELECT id FROM players WHERE name = ?;
+`
+
+const SELECT_PLAYER_COUNT_QUERY string = `
+SELECT COUNT(*) FROM players;
+`
+
 const SELECT_PLAYER_ACHIEVEMENTS_QUERY string = `
 SELECT 
-   a.id, a.title, a.description
+   a.id, a.title, a.description, a.family, a.tier, a.points, pa.created_at
 FROM
     achievement a
 		JOIN
@@ -57,16 +244,20 @@ SELECT
         FROM
             games
         WHERE
-            winner_id = players.id) AS total_wins,
+            winner_id = players.id AND status = 'confirmed' AND deleted_at IS NULL) AS total_wins,
     (SELECT 
             COUNT(*)
         FROM
             games
         WHERE
-            loser_id = players.id) AS total_lost,
+            loser_id = players.id AND status = 'confirmed' AND deleted_at IS NULL) AS total_lost,
     name,
     elo_rating,
 	highest_elo,
+	doubles_elo_rating,
+	glicko_rating,
+	glicko_deviation,
+	glicko_volatility,
     created_at
 FROM
     players
@@ -74,6 +265,29 @@ WHERE
     id = ?;
 `
 
+// the time of a change is the time of its game, which is in one of the two game tables.
+// Changes from deleted games are left out until the next replay removes them.
+const SELECT_RATING_HISTORY_QUERY string = `
+SELECT
+	h.game_id,
+	h.doubles_game_id,
+	h.rating_before,
+	h.rating_after,
+	g.created_at,
+	d.created_at
+FROM
+	rating_history h
+		LEFT JOIN
+	games g ON h.game_id = g.id
+		LEFT JOIN
+	doubles_games d ON h.doubles_game_id = d.id
+WHERE
+	h.player_id = ?
+		AND g.deleted_at IS NULL
+		AND d.deleted_at IS NULL
+ORDER BY h.id ASC;
+`
+
 const SELECT_PLAYER_GAMES string = `
 SELECT
 	g.id AS game_id,
@@ -83,6 +297,7 @@ SELECT
     l.name AS loser_name,
     g.winner_score,
     g.loser_score,
+    g.best_of,
     g.created_at
 FROM
     games g
@@ -91,16 +306,40 @@ FROM
         LEFT JOIN
     players l ON g.loser_id = l.id
 WHERE
-    g.winner_id = ? OR g.loser_id = ?
+    (g.winner_id = ? OR g.loser_id = ?)
+		AND g.status = 'confirmed'
+		AND g.deleted_at IS NULL
 ORDER BY g.created_at DESC
 LIMIT ?;
 `
 
 const SELECT_GAME_RESULTS string = `
 SELECT 
-    winner_id, loser_id, created_at
+    id, winner_id, loser_id, winner_score, loser_score, created_at
 FROM
     games
+WHERE
+	status = 'confirmed'
+		AND deleted_at IS NULL
+ORDER BY created_at ASC;
+`
+
+const SELECT_GAME_SETS string = `
+SELECT
+	game_id, winner_score, loser_score
+FROM
+	game_sets
+ORDER BY game_id ASC, set_number ASC;
+`
+
+const SELECT_DOUBLES_GAME_RESULTS string = `
+SELECT
+	id, winner1_id, winner2_id, loser1_id, loser2_id, created_at
+FROM
+	doubles_games
+WHERE
+	status = 'confirmed'
+		AND deleted_at IS NULL
 ORDER BY created_at ASC;
 `
 
@@ -113,6 +352,7 @@ SELECT
     l.name AS loser_name,
     g.winner_score,
     g.loser_score,
+    g.best_of,
     g.created_at
 FROM
     games g
@@ -121,42 +361,299 @@ FROM
         LEFT JOIN
     players l ON g.loser_id = l.id
 WHERE
-    (g.winner_id = ? AND g.loser_id = ?)
-		OR (g.winner_id = ? AND g.loser_id = ?)
+    ((g.winner_id = ? AND g.loser_id = ?)
+		OR (g.winner_id = ? AND g.loser_id = ?))
+		AND g.status = 'confirmed'
+		AND g.deleted_at IS NULL
 ORDER BY g.created_at DESC;
 `
 
-const SELECT_GAMES_PAGINATED_QUERY string = `
+const SELECT_SUBMITTED_DOUBLES_GAME_QUERY string = `
 SELECT
-	g.id AS game_id,
-	w.id AS winner_id,
-	w.name AS winner_name,
-	l.id AS loser_id,
-	l.name AS loser_name,
-	g.winner_score,
-	g.loser_score,
-	g.created_at
+	d.id AS game_id,
+	w1.id AS winner_id,
+	w1.name AS winner_name,
+	w2.id AS winner_partner_id,
+	w2.name AS winner_partner_name,
+	l1.id AS loser_id,
+	l1.name AS loser_name,
+	l2.id AS loser_partner_id,
+	l2.name AS loser_partner_name,
+	d.winner_score,
+	d.loser_score,
+	d.status,
+	d.submitted_by,
+	d.submitted_at,
+	d.created_at
 FROM
-	games g
+	doubles_games d
 		LEFT JOIN
-	players w ON g.winner_id = w.id
+	players w1 ON d.winner1_id = w1.id
 		LEFT JOIN
-	players l ON g.loser_id = l.id
-ORDER BY g.created_at DESC
+	players w2 ON d.winner2_id = w2.id
+		LEFT JOIN
+	players l1 ON d.loser1_id = l1.id
+		LEFT JOIN
+	players l2 ON d.loser2_id = l2.id
+WHERE
+	d.id = ?
+		AND d.deleted_at IS NULL;
+`
+
+const SELECT_SUBMITTED_DOUBLES_GAMES_QUERY string = `
+SELECT
+	d.id AS game_id,
+	w1.id AS winner_id,
+	w1.name AS winner_name,
+	w2.id AS winner_partner_id,
+	w2.name AS winner_partner_name,
+	l1.id AS loser_id,
+	l1.name AS loser_name,
+	l2.id AS loser_partner_id,
+	l2.name AS loser_partner_name,
+	d.winner_score,
+	d.loser_score,
+	d.status,
+	d.submitted_by,
+	d.submitted_at,
+	d.created_at
+FROM
+	doubles_games d
+		LEFT JOIN
+	players w1 ON d.winner1_id = w1.id
+		LEFT JOIN
+	players w2 ON d.winner2_id = w2.id
+		LEFT JOIN
+	players l1 ON d.loser1_id = l1.id
+		LEFT JOIN
+	players l2 ON d.loser2_id = l2.id
+WHERE
+	d.status = ?
+		AND d.deleted_at IS NULL
+ORDER BY d.created_at ASC;
+`
+
+const SELECT_SUBMITTED_GAME_QUERY string = `
+SELECT
+	g.id AS game_id,
+    w.id AS winner_id,
+    w.name AS winner_name,
+    l.id AS loser_id,
+    l.name AS loser_name,
+    g.winner_score,
+    g.loser_score,
+    g.best_of,
+    g.status,
+    g.submitted_by,
+    g.submitted_at,
+    g.created_at
+FROM
+    games g
+        LEFT JOIN
+    players w ON g.winner_id = w.id
+        LEFT JOIN
+    players l ON g.loser_id = l.id
+WHERE
+    g.id = ?
+		AND g.deleted_at IS NULL;
+`
+
+const SELECT_SUBMITTED_GAMES_QUERY string = `
+SELECT
+	g.id AS game_id,
+    w.id AS winner_id,
+    w.name AS winner_name,
+    l.id AS loser_id,
+    l.name AS loser_name,
+    g.winner_score,
+    g.loser_score,
+    g.best_of,
+    g.status,
+    g.submitted_by,
+    g.submitted_at,
+    g.created_at
+FROM
+    games g
+        LEFT JOIN
+    players w ON g.winner_id = w.id
+        LEFT JOIN
+    players l ON g.loser_id = l.id
+WHERE
+    g.status = ?
+		AND g.deleted_at IS NULL
+ORDER BY g.created_at ASC;
+`
+
+const SELECT_GAMES_PAGINATED_QUERY string = `
+SELECT
+	*
+FROM
+	(SELECT
+		'singles' AS game_type,
+		g.id AS game_id,
+		w.id AS winner_id,
+		w.name AS winner_name,
+		NULL AS winner_partner_id,
+		NULL AS winner_partner_name,
+		l.id AS loser_id,
+		l.name AS loser_name,
+		NULL AS loser_partner_id,
+		NULL AS loser_partner_name,
+		g.winner_score,
+		g.loser_score,
+		g.best_of,
+		g.created_at
+	FROM
+		games g
+			LEFT JOIN
+		players w ON g.winner_id = w.id
+			LEFT JOIN
+		players l ON g.loser_id = l.id
+	WHERE
+		g.status = 'confirmed'
+			AND g.deleted_at IS NULL
+	UNION ALL
+	SELECT
+		'doubles' AS game_type,
+		d.id AS game_id,
+		w1.id AS winner_id,
+		w1.name AS winner_name,
+		w2.id AS winner_partner_id,
+		w2.name AS winner_partner_name,
+		l1.id AS loser_id,
+		l1.name AS loser_name,
+		l2.id AS loser_partner_id,
+		l2.name AS loser_partner_name,
+		d.winner_score,
+		d.loser_score,
+		NULL AS best_of,
+		d.created_at
+	FROM
+		doubles_games d
+			LEFT JOIN
+		players w1 ON d.winner1_id = w1.id
+			LEFT JOIN
+		players w2 ON d.winner2_id = w2.id
+			LEFT JOIN
+		players l1 ON d.loser1_id = l1.id
+			LEFT JOIN
+		players l2 ON d.loser2_id = l2.id
+	WHERE
+		d.status = 'confirmed'
+			AND d.deleted_at IS NULL) AS all_games
+ORDER BY created_at DESC
 LIMIT ? OFFSET ?;
 `
 
+const SELECT_DELETED_GAMES_QUERY string = `
+SELECT
+	*
+FROM
+	(SELECT
+		'singles' AS game_type,
+		g.id AS game_id,
+		w.id AS winner_id,
+		w.name AS winner_name,
+		NULL AS winner_partner_id,
+		NULL AS winner_partner_name,
+		l.id AS loser_id,
+		l.name AS loser_name,
+		NULL AS loser_partner_id,
+		NULL AS loser_partner_name,
+		g.winner_score,
+		g.loser_score,
+		g.best_of,
+		g.created_at,
+		g.deleted_at,
+		g.delete_reason
+	FROM
+		games g
+			LEFT JOIN
+		players w ON g.winner_id = w.id
+			LEFT JOIN
+		players l ON g.loser_id = l.id
+	WHERE
+		g.deleted_at IS NOT NULL
+	UNION ALL
+	SELECT
+		'doubles' AS game_type,
+		d.id AS game_id,
+		w1.id AS winner_id,
+		w1.name AS winner_name,
+		w2.id AS winner_partner_id,
+		w2.name AS winner_partner_name,
+		l1.id AS loser_id,
+		l1.name AS loser_name,
+		l2.id AS loser_partner_id,
+		l2.name AS loser_partner_name,
+		d.winner_score,
+		d.loser_score,
+		NULL AS best_of,
+		d.created_at,
+		d.deleted_at,
+		d.delete_reason
+	FROM
+		doubles_games d
+			LEFT JOIN
+		players w1 ON d.winner1_id = w1.id
+			LEFT JOIN
+		players w2 ON d.winner2_id = w2.id
+			LEFT JOIN
+		players l1 ON d.loser1_id = l1.id
+			LEFT JOIN
+		players l2 ON d.loser2_id = l2.id
+	WHERE
+		d.deleted_at IS NOT NULL) AS deleted_games
+ORDER BY deleted_at DESC;
+`
+
 const SELECT_TOTAL_GAMES_STATS string = `
 SELECT 
     COUNT(*) AS total_game_count,
-    COALESCE(SUM(winner_score) + SUM(loser_score), 0) AS total_point_sum
+    COALESCE(SUM(winner_score) + SUM(loser_score), 0)
+		+ (SELECT
+			COALESCE(SUM(s.winner_score + s.loser_score), 0)
+		FROM
+			game_sets s
+				JOIN
+			games g ON s.game_id = g.id
+		WHERE
+			g.status = 'confirmed'
+				AND g.deleted_at IS NULL) AS total_point_sum
 FROM
-    games;
+    games
+WHERE
+	status = 'confirmed'
+		AND deleted_at IS NULL;
+`
+
+const SELECT_LATEST_DOUBLES_GAME_TIME_QUERY string = `
+SELECT
+	created_at
+FROM
+	doubles_games
+WHERE
+	status = 'confirmed'
+		AND deleted_at IS NULL
+ORDER BY created_at DESC
+LIMIT 1;
+`
+
+const SELECT_LATEST_GAME_TIME_QUERY string = `
+SELECT
+	created_at
+FROM
+	games
+WHERE
+	status = 'confirmed'
+		AND deleted_at IS NULL
+ORDER BY created_at DESC
+LIMIT 1;
 `
 
 const SELECT_LEADERBOARD_QUERY string = `
 SELECT 
-    id, name, elo_rating
+    id, name, elo_rating, glicko_rating, glicko_deviation, glicko_volatility
 FROM
     players
 WHERE
@@ -164,6 +661,37 @@ WHERE
 ORDER BY elo_rating DESC;
 `
 
+const SELECT_DOUBLES_LEADERBOARD_QUERY string = `
+SELECT
+    p.id, p.name, p.doubles_elo_rating
+FROM
+    players p
+		JOIN
+	(SELECT
+		player_id, MAX(created_at) AS last_played
+	FROM
+		(SELECT winner1_id AS player_id, created_at FROM doubles_games WHERE status = 'confirmed' AND deleted_at IS NULL
+		UNION ALL
+		SELECT winner2_id, created_at FROM doubles_games WHERE status = 'confirmed' AND deleted_at IS NULL
+		UNION ALL
+		SELECT loser1_id, created_at FROM doubles_games WHERE status = 'confirmed' AND deleted_at IS NULL
+		UNION ALL
+		SELECT loser2_id, created_at FROM doubles_games WHERE status = 'confirmed' AND deleted_at IS NULL) AS appearances
+	GROUP BY player_id) AS d ON d.player_id = p.id
+WHERE
+	d.last_played >= ?
+ORDER BY p.doubles_elo_rating DESC;
+`
+
+const SELECT_PLAYER_DOUBLES_ELO_RATINGS string = `
+SELECT
+	id, doubles_elo_rating
+FROM
+	players
+WHERE
+	id IN (?, ?, ?, ?);
+`
+
 const SELECT_PLAYER_ELO_RATINGS string = `
 SELECT
 	id, elo_rating
@@ -173,6 +701,37 @@ WHERE
 	id IN (?, ?);
 `
 
+const RESTORE_DOUBLES_GAME_QUERY string = `
+UPDATE doubles_games
+SET
+	deleted_at = NULL,
+	delete_reason = NULL
+WHERE
+	id = ?
+		AND deleted_at IS NOT NULL;
+`
+
+const RESTORE_GAME_QUERY string = `
+UPDATE games
+SET
+	deleted_at = NULL,
+	delete_reason = NULL
+WHERE
+	id = ?
+		AND deleted_at IS NOT NULL;
+`
+
+// updated_at is assigned to itself so doubles games do not mark a player as active on
+// the singles leaderboard.
+const UPDATE_DOUBLES_ELO_RATING_QUERY string = `
+UPDATE players
+SET
+    doubles_elo_rating = ?,
+	updated_at = updated_at
+WHERE
+    id = ?;
+`
+
 const UPDATE_ELO_RATING_QUERY string = `
 UPDATE players 
 SET 
@@ -182,6 +741,49 @@ WHERE
     id = ?;
 `
 
+const UPDATE_GAME_RESULT_QUERY string = `
+UPDATE games
+SET
+	winner_id = ?,
+	loser_id = ?,
+	winner_score = ?,
+	loser_score = ?,
+	created_at = ?
+WHERE
+	id = ?
+		AND deleted_at IS NULL;
+`
+
+const UPDATE_DOUBLES_GAME_STATUS_QUERY string = `
+UPDATE doubles_games
+SET
+	status = ?
+WHERE
+	id = ?
+		AND deleted_at IS NULL;
+`
+
+const UPDATE_GAME_STATUS_QUERY string = `
+UPDATE games
+SET
+	status = ?
+WHERE
+	id = ?
+		AND deleted_at IS NULL;
+`
+
+// updated_at is assigned to itself because a replay of the ratings is not activity.
+const UPDATE_GLICKO_RATING_QUERY string = `
+UPDATE players
+SET
+    glicko_rating = ?,
+	glicko_deviation = ?,
+	glicko_volatility = ?,
+	updated_at = updated_at
+WHERE
+    id = ?;
+`
+
 const UPDATE_HIGHEST_ELO_RATING_QUERY string = `
 UPDATE players
 SET
@@ -216,12 +818,31 @@ type MySQLStore struct {
 
 // -------------------------------------------------------------------------------- interface implementation
 
-func (s *MySQLStore) DeleteGame(id int) error {
+func (s *MySQLStore) DeleteAPIToken(id int) error {
+	_, err := s.DB.Exec(DELETE_API_TOKEN_QUERY, id)
+	if err != nil {
+		return fmt.Errorf("error deleting API token: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) DeleteDoublesGame(id int, reason string) error {
+	result, err := s.DB.Exec(DELETE_DOUBLES_GAME_QUERY, reason, id)
+	if err != nil {
+		return fmt.Errorf("error deleting doubles game: %v", err)
+	}
+	return requireRowsAffected(result, "error deleting doubles game")
+}
+
+func (s *MySQLStore) DeleteGame(id int, reason string) error {
 
-	_, err := s.DB.Exec(DELETE_GAME_QUERY, id)
+	result, err := s.DB.Exec(DELETE_GAME_QUERY, reason, id)
 	if err != nil {
 		return fmt.Errorf("error deleting game: %v", err)
 	}
+	if err = requireRowsAffected(result, "error deleting game"); err != nil {
+		return err
+	}
 
 	err = SetGameStatistics(s)
 	if err != nil {
@@ -231,56 +852,343 @@ func (s *MySQLStore) DeleteGame(id int) error {
 	return nil
 }
 
-func (s *MySQLStore) GetAchievements() ([]models.Achievement, error) {
-	achievements := make([]models.Achievement, 0)
+// ExportArchive reads every player, game and earned achievement in one transaction, so
+// that the archive is a consistent snapshot. The caller fills in the archive's header.
+func (s *MySQLStore) ExportArchive() (models.Archive, error) {
+	a := models.Archive{
+		Players:            make([]models.ArchivePlayer, 0),
+		Games:              make([]models.ArchiveGame, 0),
+		DoublesGames:       make([]models.ArchiveDoublesGame, 0),
+		PlayerAchievements: make([]models.PlayerAchievement, 0),
+	}
 
-	rows, err := s.DB.Query(SELECT_ACHIEVEMENTS_QUERY)
+	achievements, err := s.GetAchievements()
 	if err != nil {
-		return nil, fmt.Errorf("error fetching achievements: %v", err)
+		return a, err
 	}
-	defer rows.Close()
+	a.Achievements = achievements
 
-	for rows.Next() {
-		var a models.Achievement
-		if err := rows.Scan(&a.ID, &a.Title, &a.Description); err != nil {
-			return nil, fmt.Errorf("error fetching achievements: %v", err)
-		}
-		achievements = append(achievements, a)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error fetching achievements: %v", err)
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return a, fmt.Errorf("error exporting archive: %v", err)
 	}
 
-	return achievements, nil
-}
-
-func (s *MySQLStore) GetGames(page int) ([]models.Game, error) {
-	games := make([]models.Game, 0)
-	offset := (page - 1) * 50
-
-	rows, err := s.DB.Query(SELECT_GAMES_PAGINATED_QUERY, 50, offset)
+	defer tx.Rollback()
 
+	err = queryRows(tx, SELECT_ARCHIVE_PLAYERS_QUERY, func(rows *sql.Rows) error {
+		var p models.ArchivePlayer
+		if err := rows.Scan(&p.ID, &p.Name, &p.CreatedAt); err != nil {
+			return err
+		}
+		p.CreatedAt = p.CreatedAt.UTC()
+		a.Players = append(a.Players, p)
+		return nil
+	})
 	if err != nil {
-		return games, fmt.Errorf("error fetching games: %v", err)
+		return a, fmt.Errorf("error exporting players: %v", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var g models.Game
-		var winner models.Player
-		var loser models.Player
-		if err := rows.Scan(&g.ID, &winner.ID, &winner.Name, &loser.ID, &loser.Name, &g.WinnerScore, &g.LoserScore, &g.CreatedAt); err != nil {
+	games := make(map[int]int)
+	err = queryRows(tx, SELECT_ARCHIVE_GAMES_QUERY, func(rows *sql.Rows) error {
+		var g models.ArchiveGame
+		var submittedBy sql.NullInt64
+		var deletedAt sql.NullTime
+		var deleteReason sql.NullString
+		err := rows.Scan(
+			&g.ID, &g.WinnerID, &g.LoserID, &g.WinnerScore, &g.LoserScore, &g.BestOf, &g.Status, &submittedBy,
+			&g.SubmittedAt, &g.CreatedAt, &deletedAt, &deleteReason,
+		)
+		if err != nil {
+			return err
+		}
+		if submittedBy.Valid {
+			id := int(submittedBy.Int64)
+			g.SubmittedBy = &id
+		}
+		g.SubmittedAt = g.SubmittedAt.UTC()
+		g.CreatedAt = g.CreatedAt.UTC()
+		g.DeletedAt = utcOrNil(nullTimePointer(deletedAt))
+		g.DeleteReason = deleteReason.String
+		games[g.ID] = len(a.Games)
+		a.Games = append(a.Games, g)
+		return nil
+	})
+	if err != nil {
+		return a, fmt.Errorf("error exporting games: %v", err)
+	}
+
+	err = queryRows(tx, SELECT_GAME_SETS, func(rows *sql.Rows) error {
+		var gameID int
+		var set models.SetScore
+		if err := rows.Scan(&gameID, &set.WinnerScore, &set.LoserScore); err != nil {
+			return err
+		}
+		if i, ok := games[gameID]; ok {
+			a.Games[i].Sets = append(a.Games[i].Sets, set)
+		}
+		return nil
+	})
+	if err != nil {
+		return a, fmt.Errorf("error exporting game sets: %v", err)
+	}
+
+	err = queryRows(tx, SELECT_ARCHIVE_DOUBLES_GAMES_QUERY, func(rows *sql.Rows) error {
+		var g models.ArchiveDoublesGame
+		var submittedBy sql.NullInt64
+		var deletedAt sql.NullTime
+		var deleteReason sql.NullString
+		err := rows.Scan(
+			&g.ID, &g.WinnerIDs[0], &g.WinnerIDs[1], &g.LoserIDs[0], &g.LoserIDs[1], &g.WinnerScore, &g.LoserScore,
+			&g.Status, &submittedBy, &g.SubmittedAt, &g.CreatedAt, &deletedAt, &deleteReason,
+		)
+		if err != nil {
+			return err
+		}
+		if submittedBy.Valid {
+			id := int(submittedBy.Int64)
+			g.SubmittedBy = &id
+		}
+		g.SubmittedAt = g.SubmittedAt.UTC()
+		g.CreatedAt = g.CreatedAt.UTC()
+		g.DeletedAt = utcOrNil(nullTimePointer(deletedAt))
+		g.DeleteReason = deleteReason.String
+		a.DoublesGames = append(a.DoublesGames, g)
+		return nil
+	})
+	if err != nil {
+		return a, fmt.Errorf("error exporting doubles games: %v", err)
+	}
+
+	err = queryRows(tx, SELECT_ALL_PLAYER_ACHIEVEMENTS_QUERY, func(rows *sql.Rows) error {
+		var pa models.PlayerAchievement
+		if err := rows.Scan(&pa.PlayerID, &pa.AchievementID, &pa.CreatedAt); err != nil {
+			return err
+		}
+		pa.CreatedAt = pa.CreatedAt.UTC()
+		a.PlayerAchievements = append(a.PlayerAchievements, pa)
+		return nil
+	})
+	if err != nil {
+		return a, fmt.Errorf("error exporting player achievements: %v", err)
+	}
+
+	return a, nil
+}
+
+func (s *MySQLStore) GetAchievements() ([]models.Achievement, error) {
+	achievements := make([]models.Achievement, 0)
+
+	rows, err := s.DB.Query(SELECT_ACHIEVEMENTS_QUERY)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching achievements: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Achievement
+		if err := rows.Scan(&a.ID, &a.Title, &a.Description, &a.Family, &a.Tier, &a.Points); err != nil {
+			return nil, fmt.Errorf("error fetching achievements: %v", err)
+		}
+		achievements = append(achievements, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching achievements: %v", err)
+	}
+
+	return achievements, nil
+}
+
+func (s *MySQLStore) GetAPIToken(hash string) (models.APIToken, error) {
+	var t models.APIToken
+	var playerID sql.NullInt64
+
+	err := s.DB.QueryRow(SELECT_API_TOKEN_QUERY, hash).Scan(&t.ID, &t.Name, &t.Role, &playerID, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, exceptions.ErrTokenNotFound
+	}
+	if err != nil {
+		return t, fmt.Errorf("error fetching API token: %v", err)
+	}
+
+	if playerID.Valid {
+		id := int(playerID.Int64)
+		t.PlayerID = &id
+	}
+	t.CreatedAt = t.CreatedAt.In(s.TZ)
+	return t, nil
+}
+
+func (s *MySQLStore) GetAPITokens() ([]models.APIToken, error) {
+	tokens := make([]models.APIToken, 0)
+
+	rows, err := s.DB.Query(SELECT_API_TOKENS_QUERY)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching API tokens: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.APIToken
+		var playerID sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.Name, &t.Role, &playerID, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error fetching API tokens: %v", err)
+		}
+		if playerID.Valid {
+			id := int(playerID.Int64)
+			t.PlayerID = &id
+		}
+		t.CreatedAt = t.CreatedAt.In(s.TZ)
+		tokens = append(tokens, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching API tokens: %v", err)
+	}
+	return tokens, nil
+}
+
+func (s *MySQLStore) GetAuditLog(filter models.AuditLogFilter, page int) ([]models.AuditLogEntry, error) {
+	entries := make([]models.AuditLogEntry, 0)
+	offset := (page - 1) * 50
+
+	rows, err := s.DB.Query(
+		SELECT_AUDIT_LOG_QUERY,
+		filter.Action, filter.Action,
+		filter.GameType, filter.GameType,
+		filter.GameID, filter.GameID,
+		50, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching audit log: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.AuditLogEntry
+		var tokenID, gameID sql.NullInt64
+		var gameType, reason, changes sql.NullString
+		if err := rows.Scan(&e.ID, &e.Action, &e.Actor, &tokenID, &gameType, &gameID, &reason, &changes, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error fetching audit log: %v", err)
+		}
+		if changes.Valid {
+			if err := json.Unmarshal([]byte(changes.String), &e.Changes); err != nil {
+				return nil, fmt.Errorf("error reading changes of audit log entry %d: %v", e.ID, err)
+			}
+		}
+		if tokenID.Valid {
+			id := int(tokenID.Int64)
+			e.TokenID = &id
+		}
+		if gameType.Valid {
+			t := models.GameType(gameType.String)
+			e.GameType = &t
+		}
+		if gameID.Valid {
+			id := int(gameID.Int64)
+			e.GameID = &id
+		}
+		e.Reason = reason.String
+		e.CreatedAt = e.CreatedAt.In(s.TZ)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching audit log: %v", err)
+	}
+	return entries, nil
+}
+
+func (s *MySQLStore) GetDeletedGames() ([]models.DeletedGame, error) {
+	deleted := make([]models.DeletedGame, 0)
+
+	rows, err := s.DB.Query(SELECT_DELETED_GAMES_QUERY)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching deleted games: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.DeletedGame
+		var err error
+		d.Game, err = s.scanGame(rows, &d.DeletedAt, &d.DeleteReason)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching deleted games: %v", err)
+		}
+		d.DeletedAt = d.DeletedAt.In(s.TZ)
+		deleted = append(deleted, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching deleted games: %v", err)
+	}
+
+	games := make([]models.Game, 0, len(deleted))
+	for _, d := range deleted {
+		games = append(games, d.Game)
+	}
+	if err := s.attachSets(games); err != nil {
+		return nil, err
+	}
+	for i := range deleted {
+		deleted[i].Game = games[i]
+	}
+	return deleted, nil
+}
+
+func (s *MySQLStore) GetDoublesGameResults() ([]models.BaseDoublesGame, error) {
+	rows, err := s.DB.Query(SELECT_DOUBLES_GAME_RESULTS)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching doubles game results: %v", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.BaseDoublesGame, 0)
+
+	for rows.Next() {
+		var g models.BaseDoublesGame
+		err := rows.Scan(
+			&g.ID,
+			&g.WinnerIDs[0],
+			&g.WinnerIDs[1],
+			&g.LoserIDs[0],
+			&g.LoserIDs[1],
+			&g.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning doubles game: %v", err)
+		}
+		results = append(results, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating doubles games: %v", err)
+	}
+
+	return results, nil
+}
+
+func (s *MySQLStore) GetGames(page int) ([]models.Game, error) {
+	games := make([]models.Game, 0)
+	offset := (page - 1) * 50
+
+	rows, err := s.DB.Query(SELECT_GAMES_PAGINATED_QUERY, 50, offset)
+
+	if err != nil {
+		return games, fmt.Errorf("error fetching games: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		g, err := s.scanGame(rows)
+		if err != nil {
 			return games, fmt.Errorf("error fetching games: %v", err)
 		}
-		g.Winner = winner
-		g.Loser = loser
-		g.CreatedAt = g.CreatedAt.In(s.TZ)
 		games = append(games, g)
 	}
 	if err := rows.Err(); err != nil {
 		return games, fmt.Errorf("error fetching games: %v", err)
 	}
-	return games, nil
+	if err := s.attachSets(games); err != nil {
+		return games, err
+	}
+	return games, s.attachRatingChanges(games)
 }
 
 func (s *MySQLStore) GetGameResults() ([]models.BaseGame, error) {
@@ -296,8 +1204,11 @@ func (s *MySQLStore) GetGameResults() ([]models.BaseGame, error) {
 	for rows.Next() {
 		var g models.BaseGame
 		err := rows.Scan(
+			&g.ID,
 			&g.WinnerID,
 			&g.LoserID,
+			&g.WinnerScore,
+			&g.LoserScore,
 			&g.CreatedAt,
 		)
 		if err != nil {
@@ -311,6 +1222,30 @@ func (s *MySQLStore) GetGameResults() ([]models.BaseGame, error) {
 		return nil, fmt.Errorf("error iterating games: %v", err)
 	}
 
+	// attach the sets of best-of-N matches
+	setRows, err := s.DB.Query(SELECT_GAME_SETS)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching game sets: %v", err)
+	}
+	defer setRows.Close()
+
+	sets := make(map[int][]models.SetScore)
+	for setRows.Next() {
+		var gameID int
+		var set models.SetScore
+		if err := setRows.Scan(&gameID, &set.WinnerScore, &set.LoserScore); err != nil {
+			return nil, fmt.Errorf("error scanning game set: %v", err)
+		}
+		sets[gameID] = append(sets[gameID], set)
+	}
+	if err := setRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating game sets: %v", err)
+	}
+
+	for i := range results {
+		results[i].Sets = sets[results[i].ID]
+	}
+
 	return results, nil
 
 }
@@ -324,15 +1259,7 @@ func (s *MySQLStore) GetHeadToHead(p1 int, p2 int) (models.HeadToHead, error) {
 	}
 	defer rows.Close()
 
-	// Initialize tracking variables
-	var (
-		p1Streak      int
-		p2Streak      int
-		recordedCount int
-		gameIndex     int
-		scores        = models.ScoreStats{}
-	)
-
+	games := make([]models.Game, 0)
 	for rows.Next() {
 		var game models.Game
 		var winner models.Player
@@ -346,143 +1273,36 @@ func (s *MySQLStore) GetHeadToHead(p1 int, p2 int) (models.HeadToHead, error) {
 			&loser.Name,
 			&game.WinnerScore,
 			&game.LoserScore,
+			&game.BestOf,
 			&game.CreatedAt,
 		)
 		if err != nil {
 			return h, fmt.Errorf("error scanning game: %v", err)
 		}
 
+		game.Type = models.SINGLES
 		game.Winner = winner
 		game.Loser = loser
 		game.CreatedAt = game.CreatedAt.In(s.TZ)
-
-		// Initialize players on first game
-		if gameIndex == 0 {
-			if p1 == winner.ID {
-				h.Player1.ID = winner.ID
-				h.Player1.Name = winner.Name
-				h.Player2.ID = loser.ID
-				h.Player2.Name = loser.Name
-			} else {
-				h.Player1.ID = loser.ID
-				h.Player1.Name = loser.Name
-				h.Player2.ID = winner.ID
-				h.Player2.Name = winner.Name
-			}
-			h.FirstPlayedAt = game.CreatedAt
-		}
-
-		// Track last 30 games
-		if gameIndex < 30 {
-			h.RecentGames = append(h.RecentGames, game)
-		}
-
-		// Update player statistics based on who won
-		if winner.ID == h.Player1.ID {
-			// Player 1 won
-			h.Player1.GamesWon++
-
-			if game.WinnerScore != nil {
-				h.Player1.TotalPoints += *game.WinnerScore
-			}
-			if game.LoserScore != nil {
-				h.Player2.TotalPoints += *game.LoserScore
-			}
-
-			p1Streak++
-			h.Player1.LongestWinStreak = max(h.Player1.LongestWinStreak, p1Streak)
-			p2Streak = 0
-		} else {
-			// Player 2 won
-			h.Player2.GamesWon++
-
-			if game.WinnerScore != nil {
-				h.Player2.TotalPoints += *game.WinnerScore
-			}
-			if game.LoserScore != nil {
-				h.Player1.TotalPoints += *game.LoserScore
-			}
-
-			p2Streak++
-			h.Player2.LongestWinStreak = max(h.Player2.LongestWinStreak, p2Streak)
-			p1Streak = 0
-		}
-
-		// Update score statistics for games with recorded scores
-		if game.WinnerScore != nil && game.LoserScore != nil {
-			recordedCount++
-
-			winnerScore := *game.WinnerScore
-			loserScore := *game.LoserScore
-			scoreDiff := winnerScore - loserScore
-
-			// Track average score differential
-			scores.AvgScoreDifferential += float64(scoreDiff)
-
-			// Track biggest blowout (largest score difference)
-			if scores.BiggestBlowout.WinnerScore == nil {
-				scores.BiggestBlowout = models.GameResult{
-					WinnerID:    game.Winner.ID,
-					LoserID:     game.Loser.ID,
-					WinnerScore: game.WinnerScore,
-					LoserScore:  game.LoserScore,
-				}
-			} else {
-				existingDiff := *scores.BiggestBlowout.WinnerScore - *scores.BiggestBlowout.LoserScore
-				if scoreDiff > existingDiff {
-					scores.BiggestBlowout = models.GameResult{
-						WinnerID:    game.Winner.ID,
-						LoserID:     game.Loser.ID,
-						WinnerScore: game.WinnerScore,
-						LoserScore:  game.LoserScore,
-					}
-				}
-			}
-
-			// Track most competitive game (highest total points)
-			totalPoints := winnerScore + loserScore
-			if scores.MostCompetitive.WinnerScore == nil {
-				scores.MostCompetitive = models.GameResult{
-					WinnerID:    game.Winner.ID,
-					LoserID:     game.Loser.ID,
-					WinnerScore: game.WinnerScore,
-					LoserScore:  game.LoserScore,
-				}
-			} else {
-				existingTotal := *scores.MostCompetitive.WinnerScore + *scores.MostCompetitive.LoserScore
-				if totalPoints > existingTotal {
-					scores.MostCompetitive = models.GameResult{
-						WinnerID:    game.Winner.ID,
-						LoserID:     game.Loser.ID,
-						WinnerScore: game.WinnerScore,
-						LoserScore:  game.LoserScore,
-					}
-				}
-			}
-		}
-
-		gameIndex++
+		games = append(games, game)
 	}
 
 	// Check for errors during iteration
 	if err := rows.Err(); err != nil {
 		return h, fmt.Errorf("error iterating games: %v", err)
 	}
+	if err := s.attachSets(games); err != nil {
+		return h, err
+	}
+	if err := s.attachRatingChanges(games); err != nil {
+		return h, err
+	}
 
+	h = summariseHeadToHead(p1, games)
 	if len(h.RecentGames) == 0 {
 		return h, exceptions.ErrNoGamesPlayed
 	}
 
-	// Calculate averages if we have recorded games
-	if recordedCount > 0 {
-		count := float64(recordedCount)
-		scores.AvgScoreDifferential = scores.AvgScoreDifferential / count
-		h.Player1.AvgPointsPerGame = float64(h.Player1.TotalPoints) / count
-		h.Player2.AvgPointsPerGame = float64(h.Player2.TotalPoints) / count
-	}
-	h.ScoreStats = scores
-	h.TotalGameCount = gameIndex
-
 	// Calculate win probabilities
 	h.Player1.WinProbability, h.Player2.WinProbability, err = utils.GetWinProbabilities(s, h.Player1.ID, h.Player2.ID)
 	if err != nil {
@@ -510,23 +1330,65 @@ func (s *MySQLStore) GetIndexPageData(includeInactive bool) (models.IndexPageDat
 
 	for rows.Next() {
 		var row models.LeaderboardRow
-		if err := rows.Scan(&row.ID, &row.Name, &row.EloRating); err != nil {
+		var glicko models.GlickoRating
+		if err := rows.Scan(&row.ID, &row.Name, &row.EloRating, &glicko.Rating, &glicko.Deviation, &glicko.Volatility); err != nil {
 			return models.IndexPageData{}, fmt.Errorf("error fetching leaderboard: %v", err)
 		}
+		glicko = utils.NewGlickoRating(glicko.Rating, glicko.Deviation, glicko.Volatility)
+		row.Glicko = &glicko
 		leaderboard = append(leaderboard, row)
 	}
 	if err := rows.Err(); err != nil {
 		return models.IndexPageData{}, fmt.Errorf("error fetching leaderboard: %v", err)
 	}
 
+	doublesLeaderboard := make([]models.LeaderboardRow, 0)
+
+	doublesRows, err := s.DB.Query(SELECT_DOUBLES_LEADERBOARD_QUERY, cutoff)
+	if err != nil {
+		return models.IndexPageData{}, fmt.Errorf("error fetching doubles leaderboard: %v", err)
+	}
+	defer doublesRows.Close()
+
+	for doublesRows.Next() {
+		var row models.LeaderboardRow
+		if err := doublesRows.Scan(&row.ID, &row.Name, &row.EloRating); err != nil {
+			return models.IndexPageData{}, fmt.Errorf("error fetching doubles leaderboard: %v", err)
+		}
+		doublesLeaderboard = append(doublesLeaderboard, row)
+	}
+	if err := doublesRows.Err(); err != nil {
+		return models.IndexPageData{}, fmt.Errorf("error fetching doubles leaderboard: %v", err)
+	}
+
 	return models.IndexPageData{
-		Leaderboard: leaderboard,
+		Leaderboard:        leaderboard,
+		DoublesLeaderboard: doublesLeaderboard,
 		GlobalStats: models.GlobalStats{
 			TotalGames: s.TotalGameCount, TotalPoints: s.TotalPointSum,
 		},
 	}, nil
 }
 
+// GetLatestGameTime returns when the most recent game of a type that counts towards
+// ratings was played, or the zero time if there is none.
+func (s *MySQLStore) GetLatestGameTime(gameType models.GameType) (time.Time, error) {
+	query := SELECT_LATEST_GAME_TIME_QUERY
+	if gameType == models.DOUBLES {
+		query = SELECT_LATEST_DOUBLES_GAME_TIME_QUERY
+	}
+
+	var latest time.Time
+	err := s.DB.QueryRow(query).Scan(&latest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error fetching latest %v game: %v", gameType, err)
+	}
+	return latest.In(s.TZ), nil
+}
+
 func (s *MySQLStore) GetPlayerBasicInfo() ([]models.PlayerBasicInfo, error) {
 	players := make([]models.PlayerBasicInfo, 0)
 
@@ -550,6 +1412,58 @@ func (s *MySQLStore) GetPlayerBasicInfo() ([]models.PlayerBasicInfo, error) {
 	return players, nil
 }
 
+// GetPlayerAchievements returns every achievement awarded to every player.
+func (s *MySQLStore) GetPlayerAchievements() ([]models.PlayerAchievement, error) {
+	awards := make([]models.PlayerAchievement, 0)
+	rows, err := s.DB.Query(SELECT_ALL_PLAYER_ACHIEVEMENTS_QUERY)
+	if err != nil {
+		return awards, fmt.Errorf("error fetching player achievements: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pa models.PlayerAchievement
+		if err := rows.Scan(&pa.PlayerID, &pa.AchievementID, &pa.CreatedAt); err != nil {
+			return awards, fmt.Errorf("error fetching player achievements: %v", err)
+		}
+		pa.CreatedAt = pa.CreatedAt.UTC()
+		awards = append(awards, pa)
+	}
+	if err := rows.Err(); err != nil {
+		return awards, fmt.Errorf("error fetching player achievements: %v", err)
+	}
+	return awards, nil
+}
+
+func (s *MySQLStore) GetPlayerDoublesEloRatings(ids [4]int) (models.EloRatings, error) {
+	ratings := make(models.EloRatings)
+
+	rows, err := s.DB.Query(SELECT_PLAYER_DOUBLES_ELO_RATINGS, ids[0], ids[1], ids[2], ids[3])
+	if err != nil {
+		return nil, fmt.Errorf("error getting player doubles elo ratings: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var eloRating float64
+		if err := rows.Scan(&id, &eloRating); err != nil {
+			return nil, fmt.Errorf("error getting player doubles elo ratings: %v", err)
+		}
+		ratings[id] = eloRating
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting player doubles elo ratings: %v", err)
+	}
+
+	// check if we found the right number of rows
+	if len(ratings) != 4 {
+		return nil, fmt.Errorf("expected 4 players, but query found %d", len(ratings))
+	}
+
+	return ratings, nil
+}
+
 func (s *MySQLStore) GetPlayerEloRatings(ids [2]int) (models.EloRatings, error) {
 
 	// need to use the make() function when creating a map
@@ -594,9 +1508,10 @@ func (s *MySQLStore) GetPlayerGames(id int, limit int) ([]models.Game, error) {
 		var g models.Game
 		var winner models.Player
 		var loser models.Player
-		if err := rows.Scan(&g.ID, &winner.ID, &winner.Name, &loser.ID, &loser.Name, &g.WinnerScore, &g.LoserScore, &g.CreatedAt); err != nil {
+		if err := rows.Scan(&g.ID, &winner.ID, &winner.Name, &loser.ID, &loser.Name, &g.WinnerScore, &g.LoserScore, &g.BestOf, &g.CreatedAt); err != nil {
 			return games, fmt.Errorf("error fetching games: %v", err)
 		}
+		g.Type = models.SINGLES
 		g.Winner = winner
 		g.Loser = loser
 		g.CreatedAt = g.CreatedAt.In(s.TZ)
@@ -605,7 +1520,10 @@ func (s *MySQLStore) GetPlayerGames(id int, limit int) ([]models.Game, error) {
 	if err := rows.Err(); err != nil {
 		return games, fmt.Errorf("error fetching games: %v", err)
 	}
-	return games, nil
+	if err := s.attachSets(games); err != nil {
+		return games, err
+	}
+	return games, s.attachRatingChanges(games)
 }
 
 func (s *MySQLStore) GetPlayerProfile(id int) (models.PlayerProfile, error) {
@@ -615,9 +1533,23 @@ func (s *MySQLStore) GetPlayerProfile(id int) (models.PlayerProfile, error) {
 
 	// ---------------------------------------- basic profile info
 	row := s.DB.QueryRow(SELECT_PLAYER_PROFILE_QUERY, id)
-	if err := row.Scan(&totalWins, &totalLost, &profile.Name, &profile.EloRating, &profile.HighestElo, &profile.CreatedAt); err != nil {
+	var glicko models.GlickoRating
+	err := row.Scan(
+		&totalWins,
+		&totalLost,
+		&profile.Name,
+		&profile.EloRating,
+		&profile.HighestElo,
+		&profile.DoublesEloRating,
+		&glicko.Rating,
+		&glicko.Deviation,
+		&glicko.Volatility,
+		&profile.CreatedAt,
+	)
+	if err != nil {
 		return profile, fmt.Errorf("error fetching profile: %v", err)
 	}
+	profile.Glicko = utils.NewGlickoRating(glicko.Rating, glicko.Deviation, glicko.Volatility)
 	profile.ID = id
 	profile.GamesWon = totalWins
 	profile.GamesPlayed = totalWins + totalLost
@@ -640,47 +1572,297 @@ func (s *MySQLStore) GetPlayerProfile(id int) (models.PlayerProfile, error) {
 	defer achievementRows.Close()
 
 	for achievementRows.Next() {
-		var achievement models.Achievement
-		if err := achievementRows.Scan(&achievement.ID, &achievement.Title, &achievement.Description); err != nil {
+		var achievement models.EarnedAchievement
+		err := achievementRows.Scan(
+			&achievement.ID, &achievement.Title, &achievement.Description,
+			&achievement.Family, &achievement.Tier, &achievement.Points, &achievement.EarnedAt,
+		)
+		if err != nil {
 			return profile, fmt.Errorf("error fetching profile (achievements): %v", err)
 		}
+		achievement.EarnedAt = achievement.EarnedAt.In(s.TZ)
 		profile.Achievements = append(profile.Achievements, achievement)
+		profile.AchievementScore += achievement.Points
 	}
 	if err := achievementRows.Err(); err != nil {
 		return profile, fmt.Errorf("error fetching profile (achievements): %v", err)
 	}
 
 	if len(profile.Achievements) == 0 {
-		profile.Achievements = make([]models.Achievement, 0)
+		profile.Achievements = make([]models.EarnedAchievement, 0)
 	}
 
 	return profile, nil
 }
 
-func (s *MySQLStore) InsertGameResult(r models.GameResult) (int64, error) {
-	result, err := s.DB.Exec(INSERT_GAME_QUERY, r.WinnerID, r.LoserID, r.WinnerScore, r.LoserScore)
-	if err != nil {
-		return 0, fmt.Errorf("error inserting game: %v", err)
-	}
-	id, err := result.LastInsertId()
+func (s *MySQLStore) GetRatingHistory(id int) ([]models.RatingChange, error) {
+	changes := make([]models.RatingChange, 0)
+
+	rows, err := s.DB.Query(SELECT_RATING_HISTORY_QUERY, id)
 	if err != nil {
-		return 0, fmt.Errorf("error inserting game: unknown player ID")
+		return nil, fmt.Errorf("error fetching rating history: %v", err)
 	}
+	defer rows.Close()
 
-	// increment the cached total games played and points
-	s.TotalGameCount++
-	if r.WinnerScore != nil {
-		s.TotalPointSum = s.TotalPointSum + *r.WinnerScore
+	for rows.Next() {
+		var gameID, doublesGameID sql.NullInt64
+		var gamePlayedAt, doublesGamePlayedAt sql.NullTime
+		c := models.RatingChange{PlayerID: id}
+		err := rows.Scan(&gameID, &doublesGameID, &c.RatingBefore, &c.RatingAfter, &gamePlayedAt, &doublesGamePlayedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching rating history: %v", err)
+		}
+		if gameID.Valid {
+			c.GameType, c.GameID, c.PlayedAt = models.SINGLES, int(gameID.Int64), gamePlayedAt.Time
+		} else {
+			c.GameType, c.GameID, c.PlayedAt = models.DOUBLES, int(doublesGameID.Int64), doublesGamePlayedAt.Time
+		}
+		c.PlayedAt = c.PlayedAt.In(s.TZ)
+		changes = append(changes, c)
 	}
-	if r.LoserScore != nil {
-		s.TotalPointSum = s.TotalPointSum + *r.LoserScore
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching rating history: %v", err)
 	}
 
-	return id, nil
+	// rows are stored in the order the games were rated, which is not always the order
+	// they were played in
+	slices.SortStableFunc(changes, func(a, b models.RatingChange) int {
+		return a.PlayedAt.Compare(b.PlayedAt)
+	})
+	return changes, nil
 }
 
-func (s *MySQLStore) InsertPlayer(name string) (int64, error) {
-	result, err := s.DB.Exec(INSERT_PLAYER_QUERY, name)
+func (s *MySQLStore) GetSubmittedDoublesGame(id int) (models.SubmittedGame, error) {
+	g, err := s.scanSubmittedDoublesGame(s.DB.QueryRow(SELECT_SUBMITTED_DOUBLES_GAME_QUERY, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return g, exceptions.ErrGameNotFound
+	}
+	if err != nil {
+		return g, fmt.Errorf("error fetching doubles game: %v", err)
+	}
+	return g, nil
+}
+
+func (s *MySQLStore) GetSubmittedGame(id int) (models.SubmittedGame, error) {
+	g, err := s.scanSubmittedGame(s.DB.QueryRow(SELECT_SUBMITTED_GAME_QUERY, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return g, exceptions.ErrGameNotFound
+	}
+	if err != nil {
+		return g, fmt.Errorf("error fetching game: %v", err)
+	}
+
+	games := []models.Game{g.Game}
+	if err := s.attachSets(games); err != nil {
+		return g, err
+	}
+	g.Game = games[0]
+	return g, nil
+}
+
+func (s *MySQLStore) GetSubmittedGames(status models.GameStatus) ([]models.SubmittedGame, error) {
+	submitted := make([]models.SubmittedGame, 0)
+
+	rows, err := s.DB.Query(SELECT_SUBMITTED_GAMES_QUERY, status)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %v games: %v", status, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		g, err := s.scanSubmittedGame(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %v games: %v", status, err)
+		}
+		submitted = append(submitted, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching %v games: %v", status, err)
+	}
+
+	games := make([]models.Game, 0, len(submitted))
+	for _, g := range submitted {
+		games = append(games, g.Game)
+	}
+	if err := s.attachSets(games); err != nil {
+		return nil, err
+	}
+	for i := range submitted {
+		submitted[i].Game = games[i]
+	}
+
+	rows, err = s.DB.Query(SELECT_SUBMITTED_DOUBLES_GAMES_QUERY, status)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %v doubles games: %v", status, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		g, err := s.scanSubmittedDoublesGame(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %v doubles games: %v", status, err)
+		}
+		submitted = append(submitted, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching %v doubles games: %v", status, err)
+	}
+
+	slices.SortStableFunc(submitted, func(a, b models.SubmittedGame) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return submitted, nil
+}
+
+// ImportGames records historical games in a single transaction, creating any player
+// that does not exist yet, so either the whole import is saved or none of it is.
+func (s *MySQLStore) ImportGames(games []models.ImportGame, rating float64) (models.ImportSummary, error) {
+	summary := models.ImportSummary{Players: make([]string, 0)}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return summary, fmt.Errorf("error importing games: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	// names are matched case-insensitively, like the unique index on players
+	ids := make(map[string]int)
+	playerID := func(name string) (int, error) {
+		if id, ok := ids[strings.ToLower(name)]; ok {
+			return id, nil
+		}
+
+		var id int
+		err := tx.QueryRow(SELECT_PLAYER_ID_BY_NAME_QUERY, name).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			result, err := tx.Exec(INSERT_PLAYER_QUERY, name, rating, rating, rating)
+			if err != nil {
+				return 0, fmt.Errorf("error importing player '%v': %v", name, err)
+			}
+			newID, err := result.LastInsertId()
+			if err != nil {
+				return 0, fmt.Errorf("error importing player '%v': %v", name, err)
+			}
+			id = int(newID)
+			summary.Players = append(summary.Players, name)
+		} else if err != nil {
+			return 0, fmt.Errorf("error importing player '%v': %v", name, err)
+		}
+
+		ids[strings.ToLower(name)] = id
+		return id, nil
+	}
+
+	for _, g := range games {
+		winnerID, err := playerID(g.Winner)
+		if err != nil {
+			return summary, err
+		}
+		loserID, err := playerID(g.Loser)
+		if err != nil {
+			return summary, err
+		}
+
+		_, err = tx.Exec(
+			INSERT_GAME_QUERY, winnerID, loserID, g.WinnerScore, g.LoserScore, models.CONFIRMED, nil, g.PlayedAt.UTC(),
+		)
+		if err != nil {
+			return summary, fmt.Errorf("error importing game on line %d: %v", g.Line, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return summary, fmt.Errorf("error importing games: %v", err)
+	}
+	summary.Games = len(games)
+
+	// increment the cached total games played and points
+	s.TotalGameCount += len(games)
+	for _, g := range games {
+		if g.WinnerScore != nil && g.LoserScore != nil {
+			s.TotalPointSum = s.TotalPointSum + *g.WinnerScore + *g.LoserScore
+		}
+	}
+	return summary, nil
+}
+
+func (s *MySQLStore) InsertAPIToken(hash string, t models.NewAPIToken) (int64, error) {
+	result, err := s.DB.Exec(INSERT_API_TOKEN_QUERY, hash, t.Name, t.Role, t.PlayerID)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting API token: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error inserting API token: %v", err)
+	}
+	return id, nil
+}
+
+func (s *MySQLStore) InsertAuditLogEntry(e models.AuditLogEntry) error {
+	reason := sql.NullString{String: e.Reason, Valid: e.Reason != ""}
+
+	var changes sql.NullString
+	if len(e.Changes) > 0 {
+		b, err := json.Marshal(e.Changes)
+		if err != nil {
+			return fmt.Errorf("error inserting audit log entry: %v", err)
+		}
+		changes = sql.NullString{String: string(b), Valid: true}
+	}
+
+	_, err := s.DB.Exec(INSERT_AUDIT_LOG_ENTRY_QUERY, e.Action, e.Actor, e.TokenID, e.GameType, e.GameID, reason, changes)
+	if err != nil {
+		return fmt.Errorf("error inserting audit log entry: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) InsertDoublesGameResult(r models.DoublesGameResult) (int64, error) {
+	return s.insertDoublesGameResult(r, models.CONFIRMED, nil)
+}
+
+func (s *MySQLStore) InsertDoublesRatingHistory(changes []models.RatingChange) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error inserting doubles rating history: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	if err = insertDoublesRatingHistory(tx, changes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error inserting doubles rating history: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) InsertGameResult(r models.GameResult) (int64, error) {
+	return s.insertGameResult(r, models.CONFIRMED, nil)
+}
+
+func (s *MySQLStore) InsertMatchResult(r models.MatchResult) (int64, error) {
+	return s.insertMatchResult(r, models.CONFIRMED, nil)
+}
+
+func (s *MySQLStore) InsertPendingDoublesGameResult(r models.DoublesGameResult, submittedBy int) (int64, error) {
+	return s.insertDoublesGameResult(r, models.PENDING, &submittedBy)
+}
+
+func (s *MySQLStore) InsertPendingGameResult(r models.GameResult, submittedBy int) (int64, error) {
+	return s.insertGameResult(r, models.PENDING, &submittedBy)
+}
+
+func (s *MySQLStore) InsertPendingMatchResult(r models.MatchResult, submittedBy int) (int64, error) {
+	return s.insertMatchResult(r, models.PENDING, &submittedBy)
+}
+
+func (s *MySQLStore) InsertPlayer(name string, rating float64) (int64, error) {
+	result, err := s.DB.Exec(INSERT_PLAYER_QUERY, name, rating, rating, rating)
 	if err != nil {
 		return 0, fmt.Errorf("error inserting player: %v", err)
 	}
@@ -691,17 +1873,19 @@ func (s *MySQLStore) InsertPlayer(name string) (int64, error) {
 	return id, nil
 }
 
-func (s *MySQLStore) InsertPlayerAchievements(id int, achievementIDs []models.AchievementID) error {
-	if len(achievementIDs) == 0 {
+// InsertPlayerAchievements awards achievements, dated by when they were earned. Those a
+// player already has keep their date.
+func (s *MySQLStore) InsertPlayerAchievements(awards []models.PlayerAchievement) error {
+	if len(awards) == 0 {
 		return nil // No achievements to insert; avoid invalid query
 	}
 
-	insertQuery := "INSERT IGNORE INTO player_achievement (player_id, achievement_id) VALUES "
+	insertQuery := "INSERT IGNORE INTO player_achievement (player_id, achievement_id, created_at) VALUES "
 	vals := []any{}
 
-	for _, achievementID := range achievementIDs {
-		insertQuery += "(?, ?),"
-		vals = append(vals, id, int(achievementID))
+	for _, pa := range awards {
+		insertQuery += "(?, ?, ?),"
+		vals = append(vals, pa.PlayerID, pa.AchievementID, pa.CreatedAt.UTC())
 	}
 	// trim the last ,
 	insertQuery = insertQuery[0 : len(insertQuery)-1]
@@ -721,6 +1905,211 @@ func (s *MySQLStore) InsertPlayerAchievements(id int, achievementIDs []models.Ac
 	return nil
 }
 
+func (s *MySQLStore) InsertRatingHistory(changes []models.RatingChange) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error inserting rating history: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	if err = insertRatingHistory(tx, changes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error inserting rating history: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) ReplaceDoublesRatingHistory(changes []models.RatingChange) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error replacing doubles rating history: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(DELETE_DOUBLES_RATING_HISTORY_QUERY); err != nil {
+		return fmt.Errorf("error replacing doubles rating history: %v", err)
+	}
+	if err = insertDoublesRatingHistory(tx, changes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error replacing doubles rating history: %v", err)
+	}
+	return nil
+}
+
+// ReplacePlayerAchievements swaps every awarded achievement for the given ones in one
+// transaction.
+func (s *MySQLStore) ReplacePlayerAchievements(awards []models.PlayerAchievement) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error replacing player achievements: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(DELETE_PLAYER_ACHIEVEMENTS_QUERY); err != nil {
+		return fmt.Errorf("error replacing player achievements: %v", err)
+	}
+	for _, pa := range awards {
+		_, err = tx.Exec(INSERT_PLAYER_ACHIEVEMENT_QUERY, pa.PlayerID, pa.AchievementID, pa.CreatedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("error replacing player achievements: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error replacing player achievements: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) ReplaceRatingHistory(changes []models.RatingChange) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error replacing rating history: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(DELETE_RATING_HISTORY_QUERY); err != nil {
+		return fmt.Errorf("error replacing rating history: %v", err)
+	}
+	if err = insertRatingHistory(tx, changes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error replacing rating history: %v", err)
+	}
+	return nil
+}
+
+// RestoreArchive loads an archive into an empty database in one transaction, keeping
+// every ID. Each player starts on the given rating, ready for the games to be replayed.
+func (s *MySQLStore) RestoreArchive(a models.Archive, rating float64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error restoring archive: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	var players int
+	if err := tx.QueryRow(SELECT_PLAYER_COUNT_QUERY).Scan(&players); err != nil {
+		return fmt.Errorf("error restoring archive: %v", err)
+	}
+	if players > 0 {
+		return exceptions.ErrStoreNotEmpty
+	}
+
+	for _, p := range a.Players {
+		_, err := tx.Exec(INSERT_ARCHIVE_PLAYER_QUERY, p.ID, p.Name, rating, rating, rating, p.CreatedAt.UTC(), p.CreatedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("error restoring Player %v: %v", p.ID, err)
+		}
+	}
+
+	for _, g := range a.Games {
+		_, err := tx.Exec(
+			INSERT_ARCHIVE_GAME_QUERY,
+			g.ID, g.WinnerID, g.LoserID, g.WinnerScore, g.LoserScore, g.BestOf, g.Status, g.SubmittedBy,
+			g.SubmittedAt.UTC(), g.CreatedAt.UTC(), utcOrNil(g.DeletedAt), sql.NullString{String: g.DeleteReason, Valid: g.DeletedAt != nil},
+		)
+		if err != nil {
+			return fmt.Errorf("error restoring Game %v: %v", g.ID, err)
+		}
+		for i, set := range g.Sets {
+			if _, err := tx.Exec(INSERT_GAME_SET_QUERY, g.ID, i+1, set.WinnerScore, set.LoserScore); err != nil {
+				return fmt.Errorf("error restoring set %d of Game %v: %v", i+1, g.ID, err)
+			}
+		}
+	}
+
+	for _, g := range a.DoublesGames {
+		_, err := tx.Exec(
+			INSERT_ARCHIVE_DOUBLES_GAME_QUERY,
+			g.ID, g.WinnerIDs[0], g.WinnerIDs[1], g.LoserIDs[0], g.LoserIDs[1], g.WinnerScore, g.LoserScore,
+			g.Status, g.SubmittedBy, g.SubmittedAt.UTC(), g.CreatedAt.UTC(), utcOrNil(g.DeletedAt),
+			sql.NullString{String: g.DeleteReason, Valid: g.DeletedAt != nil},
+		)
+		if err != nil {
+			return fmt.Errorf("error restoring Doubles Game %v: %v", g.ID, err)
+		}
+	}
+
+	for _, pa := range a.PlayerAchievements {
+		_, err := tx.Exec(INSERT_PLAYER_ACHIEVEMENT_QUERY, pa.PlayerID, pa.AchievementID, pa.CreatedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("error restoring achievement %v of Player %v: %v", pa.AchievementID, pa.PlayerID, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error restoring archive: %v", err)
+	}
+
+	// the cached totals are counted from the restored games
+	return SetGameStatistics(s)
+}
+
+func (s *MySQLStore) RestoreDoublesGame(id int) error {
+	result, err := s.DB.Exec(RESTORE_DOUBLES_GAME_QUERY, id)
+	if err != nil {
+		return fmt.Errorf("error restoring doubles game: %v", err)
+	}
+	return requireRowsAffected(result, "error restoring doubles game")
+}
+
+func (s *MySQLStore) RestoreGame(id int) error {
+	result, err := s.DB.Exec(RESTORE_GAME_QUERY, id)
+	if err != nil {
+		return fmt.Errorf("error restoring game: %v", err)
+	}
+	if err = requireRowsAffected(result, "error restoring game"); err != nil {
+		return err
+	}
+
+	err = SetGameStatistics(s)
+	if err != nil {
+		return fmt.Errorf("error setting game statistics: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) UpdateDoublesEloRatings(players models.EloRatings) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error updating Players doubles Elo rating: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(UPDATE_DOUBLES_ELO_RATING_QUERY)
+	if err != nil {
+		return fmt.Errorf("error updating Players doubles Elo rating: %v", err)
+	}
+
+	for id, eloRating := range players {
+		_, err := stmt.Exec(eloRating, id)
+		if err != nil {
+			return fmt.Errorf("error updating Player %v doubles Elo rating: %v", id, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error updating Players doubles Elo rating: %v", err)
+	}
+
+	return nil
+}
+
 func (s *MySQLStore) UpdateEloRatings(players models.EloRatings) error {
 	tx, err := s.DB.Begin()
 	if err != nil {
@@ -751,6 +2140,93 @@ func (s *MySQLStore) UpdateEloRatings(players models.EloRatings) error {
 	return nil
 }
 
+// UpdateGameResult corrects a game's players, scores and time. A match's sets are
+// replaced with g.Sets.
+func (s *MySQLStore) UpdateGameResult(g models.BaseGame) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error updating game: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(UPDATE_GAME_RESULT_QUERY, g.WinnerID, g.LoserID, g.WinnerScore, g.LoserScore, g.CreatedAt.UTC(), g.ID)
+	if err != nil {
+		return fmt.Errorf("error updating game: %v", err)
+	}
+
+	if len(g.Sets) > 0 {
+		if _, err = tx.Exec(DELETE_GAME_SETS_QUERY, g.ID); err != nil {
+			return fmt.Errorf("error updating game sets: %v", err)
+		}
+		for i, set := range g.Sets {
+			if _, err = tx.Exec(INSERT_GAME_SET_QUERY, g.ID, i+1, set.WinnerScore, set.LoserScore); err != nil {
+				return fmt.Errorf("error updating game set %d: %v", i+1, err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error updating game: %v", err)
+	}
+
+	// the cached point total changes with the scores
+	err = SetGameStatistics(s)
+	if err != nil {
+		return fmt.Errorf("error setting game statistics: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) UpdateDoublesGameStatus(id int, status models.GameStatus) error {
+	_, err := s.DB.Exec(UPDATE_DOUBLES_GAME_STATUS_QUERY, status, id)
+	if err != nil {
+		return fmt.Errorf("error updating doubles game status: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) UpdateGameStatus(id int, status models.GameStatus) error {
+	_, err := s.DB.Exec(UPDATE_GAME_STATUS_QUERY, status, id)
+	if err != nil {
+		return fmt.Errorf("error updating game status: %v", err)
+	}
+
+	// the cached totals only count confirmed games
+	err = SetGameStatistics(s)
+	if err != nil {
+		return fmt.Errorf("error setting game statistics: %v", err)
+	}
+	return nil
+}
+
+func (s *MySQLStore) UpdateGlickoRatings(players models.GlickoRatings) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error updating Players Glicko-2 rating: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(UPDATE_GLICKO_RATING_QUERY)
+	if err != nil {
+		return fmt.Errorf("error updating Players Glicko-2 rating: %v", err)
+	}
+
+	for id, r := range players {
+		_, err := stmt.Exec(r.Rating, r.Deviation, r.Volatility, id)
+		if err != nil {
+			return fmt.Errorf("error updating Player %v Glicko-2 rating: %v", id, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error updating Players Glicko-2 rating: %v", err)
+	}
+
+	return nil
+}
+
 func (s *MySQLStore) UpdateHighestEloRatings(players models.EloRatings) error {
 	tx, err := s.DB.Begin()
 	if err != nil {
@@ -808,6 +2284,392 @@ func (s *MySQLStore) UpdatePlayerUpdatedAt(m map[int]time.Time) error {
 	return nil
 }
 
+// -------------------------------------------------------------------------------- helpers
+
+// attachSets loads the set scores of any best-of-N matches among the games.
+func (s *MySQLStore) attachSets(games []models.Game) error {
+	ids := make([]any, 0)
+	index := make(map[int]int)
+	for i, g := range games {
+		if g.BestOf != nil {
+			ids = append(ids, g.ID)
+			index[g.ID] = i
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := "SELECT game_id, winner_score, loser_score FROM game_sets WHERE game_id IN (?"
+	query += strings.Repeat(", ?", len(ids)-1)
+	query += ") ORDER BY game_id ASC, set_number ASC;"
+
+	rows, err := s.DB.Query(query, ids...)
+	if err != nil {
+		return fmt.Errorf("error fetching game sets: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var gameID int
+		var set models.SetScore
+		if err := rows.Scan(&gameID, &set.WinnerScore, &set.LoserScore); err != nil {
+			return fmt.Errorf("error fetching game sets: %v", err)
+		}
+		g := &games[index[gameID]]
+		g.Sets = append(g.Sets, set)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error fetching game sets: %v", err)
+	}
+	return nil
+}
+
+// attachRatingChanges fills in how each game moved its players' ratings, from the rating
+// history. Singles games use the singles history and doubles games the doubles history.
+func (s *MySQLStore) attachRatingChanges(games []models.Game) error {
+	ids := map[models.GameType][]any{}
+	for _, g := range games {
+		ids[g.Type] = append(ids[g.Type], g.ID)
+	}
+
+	sources := []struct {
+		gameType models.GameType
+		table    string
+		column   string
+	}{
+		{models.SINGLES, "rating_history", "game_id"},
+		{models.DOUBLES, "doubles_rating_history", "doubles_game_id"},
+	}
+
+	changes := make(map[gameKey]map[int]models.RatingChange)
+	for _, source := range sources {
+		gameType := source.gameType
+		if len(ids[gameType]) == 0 {
+			continue
+		}
+
+		query := fmt.Sprintf(
+			"SELECT %s, player_id, rating_before, rating_after FROM %s WHERE %s IN (?",
+			source.column, source.table, source.column,
+		)
+		query += strings.Repeat(", ?", len(ids[gameType])-1)
+		query += ");"
+
+		rows, err := s.DB.Query(query, ids[gameType]...)
+		if err != nil {
+			return fmt.Errorf("error fetching rating changes: %v", err)
+		}
+
+		for rows.Next() {
+			c := models.RatingChange{GameType: gameType}
+			if err := rows.Scan(&c.GameID, &c.PlayerID, &c.RatingBefore, &c.RatingAfter); err != nil {
+				rows.Close()
+				return fmt.Errorf("error fetching rating changes: %v", err)
+			}
+			key := gameKey{gameType, c.GameID}
+			if changes[key] == nil {
+				changes[key] = make(map[int]models.RatingChange)
+			}
+			changes[key][c.PlayerID] = c
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("error fetching rating changes: %v", err)
+		}
+	}
+
+	for i := range games {
+		setRatingChanges(&games[i], changes[gameKey{games[i].Type, games[i].ID}])
+	}
+	return nil
+}
+
+// insertGameResult records a singles game. Only confirmed games count towards the cached
+// totals.
+func (s *MySQLStore) insertDoublesGameResult(r models.DoublesGameResult, status models.GameStatus, submittedBy *int) (int64, error) {
+	result, err := s.DB.Exec(
+		INSERT_DOUBLES_GAME_QUERY,
+		r.WinnerIDs[0], r.WinnerIDs[1], r.LoserIDs[0], r.LoserIDs[1], r.WinnerScore, r.LoserScore, status, submittedBy,
+		utcOrNil(r.PlayedAt),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting doubles game: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error inserting doubles game: unknown player ID")
+	}
+	return id, nil
+}
+
+func (s *MySQLStore) insertGameResult(r models.GameResult, status models.GameStatus, submittedBy *int) (int64, error) {
+	result, err := s.DB.Exec(INSERT_GAME_QUERY, r.WinnerID, r.LoserID, r.WinnerScore, r.LoserScore, status, submittedBy, utcOrNil(r.PlayedAt))
+	if err != nil {
+		return 0, fmt.Errorf("error inserting game: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error inserting game: unknown player ID")
+	}
+	if status != models.CONFIRMED {
+		return id, nil
+	}
+
+	// increment the cached total games played and points
+	s.TotalGameCount++
+	if r.WinnerScore != nil {
+		s.TotalPointSum = s.TotalPointSum + *r.WinnerScore
+	}
+	if r.LoserScore != nil {
+		s.TotalPointSum = s.TotalPointSum + *r.LoserScore
+	}
+
+	return id, nil
+}
+
+// insertMatchResult records a best-of-N match and its sets. Only confirmed matches count
+// towards the cached totals.
+func (s *MySQLStore) insertMatchResult(r models.MatchResult, status models.GameStatus, submittedBy *int) (int64, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error inserting match: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec(INSERT_MATCH_QUERY, r.WinnerID, r.LoserID, r.BestOf, status, submittedBy, utcOrNil(r.PlayedAt))
+	if err != nil {
+		return 0, fmt.Errorf("error inserting match: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error inserting match: unknown player ID")
+	}
+
+	stmt, err := tx.Prepare(INSERT_GAME_SET_QUERY)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting match sets: %v", err)
+	}
+
+	points := 0
+	for i, set := range r.Sets {
+		_, err := stmt.Exec(id, i+1, set.WinnerScore, set.LoserScore)
+		if err != nil {
+			return 0, fmt.Errorf("error inserting match set %d: %v", i+1, err)
+		}
+		points = points + set.WinnerScore + set.LoserScore
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error inserting match: %v", err)
+	}
+	if status != models.CONFIRMED {
+		return id, nil
+	}
+
+	// increment the cached total games played and points
+	s.TotalGameCount++
+	s.TotalPointSum = s.TotalPointSum + points
+
+	return id, nil
+}
+
+// scanGame reads a row of SELECT_GAMES_PAGINATED_QUERY, or of SELECT_DELETED_GAMES_QUERY
+// with its extra columns read into extra.
+func (s *MySQLStore) scanGame(row interface{ Scan(...any) error }, extra ...any) (models.Game, error) {
+	var g models.Game
+	var winnerPartnerID, loserPartnerID sql.NullInt64
+	var winnerPartnerName, loserPartnerName sql.NullString
+	dest := []any{
+		&g.Type,
+		&g.ID,
+		&g.Winner.ID,
+		&g.Winner.Name,
+		&winnerPartnerID,
+		&winnerPartnerName,
+		&g.Loser.ID,
+		&g.Loser.Name,
+		&loserPartnerID,
+		&loserPartnerName,
+		&g.WinnerScore,
+		&g.LoserScore,
+		&g.BestOf,
+		&g.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return g, err
+	}
+
+	if winnerPartnerID.Valid {
+		g.WinnerPartner = &models.Player{ID: int(winnerPartnerID.Int64), Name: winnerPartnerName.String}
+	}
+	if loserPartnerID.Valid {
+		g.LoserPartner = &models.Player{ID: int(loserPartnerID.Int64), Name: loserPartnerName.String}
+	}
+	g.CreatedAt = g.CreatedAt.In(s.TZ)
+	return g, nil
+}
+
+// scanSubmittedDoublesGame reads a row of SELECT_SUBMITTED_DOUBLES_GAME_QUERY or
+// SELECT_SUBMITTED_DOUBLES_GAMES_QUERY.
+func (s *MySQLStore) scanSubmittedDoublesGame(row interface{ Scan(...any) error }) (models.SubmittedGame, error) {
+	var g models.SubmittedGame
+	var winnerPartner, loserPartner models.Player
+	var submittedBy sql.NullInt64
+	err := row.Scan(
+		&g.ID,
+		&g.Winner.ID,
+		&g.Winner.Name,
+		&winnerPartner.ID,
+		&winnerPartner.Name,
+		&g.Loser.ID,
+		&g.Loser.Name,
+		&loserPartner.ID,
+		&loserPartner.Name,
+		&g.WinnerScore,
+		&g.LoserScore,
+		&g.Status,
+		&submittedBy,
+		&g.SubmittedAt,
+		&g.CreatedAt,
+	)
+	if err != nil {
+		return g, err
+	}
+
+	g.Type = models.DOUBLES
+	g.WinnerPartner = &winnerPartner
+	g.LoserPartner = &loserPartner
+	if submittedBy.Valid {
+		id := int(submittedBy.Int64)
+		g.SubmittedBy = &id
+	}
+	g.SubmittedAt = g.SubmittedAt.In(s.TZ)
+	g.CreatedAt = g.CreatedAt.In(s.TZ)
+	return g, nil
+}
+
+// scanSubmittedGame reads a row of SELECT_SUBMITTED_GAME_QUERY or SELECT_SUBMITTED_GAMES_QUERY.
+func (s *MySQLStore) scanSubmittedGame(row interface{ Scan(...any) error }) (models.SubmittedGame, error) {
+	var g models.SubmittedGame
+	var submittedBy sql.NullInt64
+	err := row.Scan(
+		&g.ID,
+		&g.Winner.ID,
+		&g.Winner.Name,
+		&g.Loser.ID,
+		&g.Loser.Name,
+		&g.WinnerScore,
+		&g.LoserScore,
+		&g.BestOf,
+		&g.Status,
+		&submittedBy,
+		&g.SubmittedAt,
+		&g.CreatedAt,
+	)
+	if err != nil {
+		return g, err
+	}
+
+	g.Type = models.SINGLES
+	if submittedBy.Valid {
+		id := int(submittedBy.Int64)
+		g.SubmittedBy = &id
+	}
+	g.SubmittedAt = g.SubmittedAt.In(s.TZ)
+	g.CreatedAt = g.CreatedAt.In(s.TZ)
+	return g, nil
+}
+
+// requireRowsAffected reports ErrGameNotFound when an update to a game matched no row:
+// the game does not exist, or is not in the state the update expects.
+func requireRowsAffected(result sql.Result, message string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%v: %v", message, err)
+	}
+	if n == 0 {
+		return exceptions.ErrGameNotFound
+	}
+	return nil
+}
+
+// queryRows runs a query within a transaction and passes each row to scan.
+func queryRows(tx *sql.Tx, query string, scan func(rows *sql.Rows) error) error {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// nullTimePointer returns a nullable time as a pointer that is nil for NULL.
+func nullTimePointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// utcOrNil returns a time in UTC, or nil so that the database records the current time.
+func utcOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// insertDoublesRatingHistory writes doubles rating changes within a transaction.
+func insertDoublesRatingHistory(tx *sql.Tx, changes []models.RatingChange) error {
+	stmt, err := tx.Prepare(INSERT_DOUBLES_RATING_HISTORY_QUERY)
+	if err != nil {
+		return fmt.Errorf("error inserting doubles rating history: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range changes {
+		_, err := stmt.Exec(c.PlayerID, c.GameID, c.RatingBefore, c.RatingAfter)
+		if err != nil {
+			return fmt.Errorf("error inserting doubles rating history for Player %v: %v", c.PlayerID, err)
+		}
+	}
+	return nil
+}
+
+// insertRatingHistory writes rating changes within a transaction.
+func insertRatingHistory(tx *sql.Tx, changes []models.RatingChange) error {
+	stmt, err := tx.Prepare(INSERT_RATING_HISTORY_QUERY)
+	if err != nil {
+		return fmt.Errorf("error inserting rating history: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range changes {
+		var gameID, doublesGameID sql.NullInt64
+		if c.GameType == models.DOUBLES {
+			doublesGameID = sql.NullInt64{Int64: int64(c.GameID), Valid: true}
+		} else {
+			gameID = sql.NullInt64{Int64: int64(c.GameID), Valid: true}
+		}
+
+		_, err := stmt.Exec(c.PlayerID, gameID, doublesGameID, c.RatingBefore, c.RatingAfter)
+		if err != nil {
+			return fmt.Errorf("error inserting rating history for Player %v: %v", c.PlayerID, err)
+		}
+	}
+	return nil
+}
+
 // -------------------------------------------------------------------------------- initialiser
 
 func SetGameStatistics(s *MySQLStore) error {
@@ -845,6 +2707,27 @@ func CreateMySQLDAO() *MySQLStore {
 		panic(fmt.Sprintf("ping failed to mysq dsn '%v': %v", cfg.FormatDSN(), err))
 	}
 
+	// migration scripts contain several statements each. Only the migrations get a
+	// connection that allows that, so that a query built from user input cannot.
+	migrationCfg := cfg.Clone()
+	migrationCfg.MultiStatements = true
+
+	migrationDB, err := sql.Open("mysql", migrationCfg.FormatDSN())
+	if err != nil {
+		panic(fmt.Sprintf("unable to connect to mysq dsn '%v': %v", migrationCfg.FormatDSN(), err))
+	}
+	defer migrationDB.Close()
+
+	m := migrations.Migrator{
+		DB:           migrationDB,
+		Dialect:      migrations.MYSQL,
+		Achievements: utils.ACHIEVEMENTS,
+		DryRun:       os.Getenv("MIGRATIONS_DRY_RUN") == "true",
+	}
+	if err = m.Up(); err != nil {
+		panic(fmt.Sprintf("error migrating mysql database: %v", err))
+	}
+
 	tz, err := time.LoadLocation("Europe/London")
 	if err != nil {
 		panic(fmt.Sprintf("error fetching timezone: %v", err))
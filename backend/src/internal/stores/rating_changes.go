@@ -0,0 +1,55 @@
+package stores
+
+import (
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/utils"
+)
+
+// gameKey identifies a game, as singles and doubles games are numbered separately.
+type gameKey struct {
+	Type models.GameType
+	ID   int
+}
+
+// setRatingChanges fills in how a game moved each of its players' ratings, and how likely
+// the winners were to win beforehand, from the game's rating changes keyed by player.
+// A game with no recorded change for one of its players is left without any.
+func setRatingChanges(g *models.Game, changes map[int]models.RatingChange) {
+	winners := []int{g.Winner.ID}
+	losers := []int{g.Loser.ID}
+	if g.WinnerPartner != nil && g.LoserPartner != nil {
+		winners = append(winners, g.WinnerPartner.ID)
+		losers = append(losers, g.LoserPartner.ID)
+	}
+
+	// a team's strength is the mean of its players' ratings before the game
+	var winnerRating, loserRating float64
+	for _, id := range winners {
+		c, ok := changes[id]
+		if !ok {
+			return
+		}
+		winnerRating += c.RatingBefore / float64(len(winners))
+	}
+	for _, id := range losers {
+		c, ok := changes[id]
+		if !ok {
+			return
+		}
+		loserRating += c.RatingBefore / float64(len(losers))
+	}
+
+	delta := func(id int) *float64 {
+		d := changes[id].RatingAfter - changes[id].RatingBefore
+		return &d
+	}
+	g.WinnerRatingChange = delta(g.Winner.ID)
+	g.LoserRatingChange = delta(g.Loser.ID)
+	if g.WinnerPartner != nil && g.LoserPartner != nil {
+		g.WinnerPartnerRatingChange = delta(g.WinnerPartner.ID)
+		g.LoserPartnerRatingChange = delta(g.LoserPartner.ID)
+	}
+
+	probability := utils.CalculateExpectedScore(winnerRating, loserRating)
+	g.WinProbability = &probability
+}
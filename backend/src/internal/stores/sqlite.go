@@ -0,0 +1,139 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/migrations"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/utils"
+	_ "modernc.org/sqlite"
+)
+
+// -------------------------------------------------------------------------------- queries
+
+const UPDATE_ELO_RATING_SQLITE_QUERY string = `
+UPDATE players
+SET
+    elo_rating = ?,
+	highest_elo = MAX(highest_elo, ?),
+	updated_at = CASE WHEN elo_rating <> ? THEN CURRENT_TIMESTAMP ELSE updated_at END
+WHERE
+    id = ?;
+`
+
+// -------------------------------------------------------------------------------- store implementation
+
+// SQLiteStore keeps the leaderboard in a single file on disk. SQLite understands
+// nearly all of the MySQL queries, so the MySQLStore implementation is reused and
+// only the statements using MySQL-specific syntax are overridden below.
+type SQLiteStore struct {
+	MySQLStore
+}
+
+// -------------------------------------------------------------------------------- interface implementation
+
+func (s *SQLiteStore) InsertPlayerAchievements(awards []models.PlayerAchievement) error {
+	if len(awards) == 0 {
+		return nil // No achievements to insert; avoid invalid query
+	}
+
+	insertQuery := "INSERT OR IGNORE INTO player_achievement (player_id, achievement_id, created_at) VALUES "
+	vals := []any{}
+
+	for _, pa := range awards {
+		insertQuery += "(?, ?, ?),"
+		vals = append(vals, pa.PlayerID, pa.AchievementID, pa.CreatedAt.UTC())
+	}
+	// trim the last ,
+	insertQuery = insertQuery[0 : len(insertQuery)-1]
+
+	_, err := s.DB.Exec(insertQuery, vals...)
+	if err != nil {
+		return fmt.Errorf("error inserting player achievements: %v", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) UpdateEloRatings(players models.EloRatings) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error updating Players Elo rating: %v", err)
+	}
+
+	// Defer a rollback in case anything fails.
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(UPDATE_ELO_RATING_SQLITE_QUERY)
+	if err != nil {
+		return fmt.Errorf("error updating Players Elo rating: %v", err)
+	}
+
+	for id, eloRating := range players {
+		_, err := stmt.Exec(eloRating, eloRating, eloRating, id)
+		if err != nil {
+			return fmt.Errorf("error updating Player %v Elo rating: %v", id, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error updating Players Elo rating: %v", err)
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------------------- initialiser
+
+func CreateSQLiteDAO() *SQLiteStore {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "table_tennis.db"
+	}
+
+	// Times are written in a format SQLite's date functions understand, foreign keys
+	// are enforced as they are in MySQL, and writers wait on a locked database
+	// rather than failing immediately.
+	params := url.Values{}
+	params.Add("_time_format", "sqlite")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	dsn := fmt.Sprintf("file:%s?%s", path, params.Encode())
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		panic(fmt.Sprintf("unable to open sqlite database '%v': %v", path, err))
+	}
+
+	// SQLite only allows a single writer; serialising access through one connection
+	// avoids SQLITE_BUSY errors from the background achievement updates.
+	db.SetMaxOpenConns(1)
+
+	m := migrations.Migrator{
+		DB:           db,
+		Dialect:      migrations.SQLITE,
+		Achievements: utils.ACHIEVEMENTS,
+		DryRun:       os.Getenv("MIGRATIONS_DRY_RUN") == "true",
+	}
+	if err = m.Up(); err != nil {
+		panic(fmt.Sprintf("error migrating sqlite database '%v': %v", path, err))
+	}
+
+	tz, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		panic(fmt.Sprintf("error fetching timezone: %v", err))
+	}
+
+	s := &SQLiteStore{MySQLStore{DB: db, TZ: tz, TotalGameCount: 0, TotalPointSum: 0}}
+
+	err = SetGameStatistics(&s.MySQLStore)
+	if err != nil {
+		panic(fmt.Sprintf("error setting initial game statistics: %v", err))
+	}
+
+	return s
+}
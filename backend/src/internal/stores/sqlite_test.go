@@ -0,0 +1,464 @@
+package stores
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/utils"
+)
+
+// -------------------------------------------------------------------------------- Test Helpers
+
+func createSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	s := CreateSQLiteDAO()
+	t.Cleanup(func() { s.DB.Close() })
+	return s
+}
+
+// -------------------------------------------------------------------------------- Tests
+
+func TestSQLiteStoreSeedsAchievements(t *testing.T) {
+	s := createSQLiteStore(t)
+
+	achievements, err := s.GetAchievements()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(achievements) != len(utils.ACHIEVEMENTS) {
+		t.Errorf("expected %d achievements, got %d", len(utils.ACHIEVEMENTS), len(achievements))
+	}
+}
+
+func TestSQLiteStoreInsertPlayerRejectsDuplicateName(t *testing.T) {
+	s := createSQLiteStore(t)
+	createPlayers(t, s, "Alice")
+
+	if _, err := s.InsertPlayer("ALICE", 1000); err == nil {
+		t.Errorf("expected an error inserting a duplicate player name, got nil")
+	}
+}
+
+func TestSQLiteStoreInsertGameRejectsUnknownPlayer(t *testing.T) {
+	s := createSQLiteStore(t)
+	ids := createPlayers(t, s, "Alice")
+
+	_, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: 99})
+	if err == nil {
+		t.Errorf("expected an error inserting a game with an unknown player, got nil")
+	}
+}
+
+func TestSQLiteStoreGamesAndProfile(t *testing.T) {
+	s := createSQLiteStore(t)
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	r := models.GameResult{WinnerID: ids[0], LoserID: ids[1], WinnerScore: intPointer(11), LoserScore: intPointer(7)}
+	if _, err := s.InsertGameResult(r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.InsertPlayerAchievements(earnedAt(ids[0], time.Now(), warmingUp, warmingUp)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	profile, err := s.GetPlayerProfile(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.GamesPlayed != 1 || profile.GamesWon != 1 {
+		t.Errorf("expected 1 game played and won, got %d and %d", profile.GamesPlayed, profile.GamesWon)
+	}
+	if len(profile.RecentGames) != 1 || *profile.RecentGames[0].WinnerScore != 11 {
+		t.Errorf("expected the 11-7 game in recent games, got %v", profile.RecentGames)
+	}
+	if len(profile.Achievements) != 1 {
+		t.Errorf("expected 1 achievement, got %d", len(profile.Achievements))
+	}
+
+	data, err := s.GetIndexPageData(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.GlobalStats.TotalGames != 1 || data.GlobalStats.TotalPoints != 18 {
+		t.Errorf("expected 1 game and 18 points, got %v", data.GlobalStats)
+	}
+}
+
+func TestSQLiteStoreRecalculateEloRatings(t *testing.T) {
+	s := createSQLiteStore(t)
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	for range 2 {
+		if _, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := utils.RecalculateEloRatings(s, config.Default()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := s.GetIndexPageData(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data.Leaderboard) != 2 || data.Leaderboard[0].ID != ids[0] {
+		t.Fatalf("expected the winner to top the leaderboard, got %v", data.Leaderboard)
+	}
+	if math.Round(data.Leaderboard[0].EloRating) != 1038 {
+		t.Errorf("expected a rating of 1038, got %v", data.Leaderboard[0].EloRating)
+	}
+}
+
+func TestSQLiteStoreDoublesGames(t *testing.T) {
+	s := createSQLiteStore(t)
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol", "Dave")
+
+	if _, err := s.InsertGameResult(models.GameResult{WinnerID: ids[0], LoserID: ids[1]}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := models.DoublesGameResult{WinnerIDs: []int{ids[0], ids[1]}, LoserIDs: []int{ids[2], ids[3]}}
+	doublesID, err := s.InsertDoublesGameResult(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := utils.UpdatePlayersDoublesEloRating(s, config.Default(), int(doublesID), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	games, err := s.GetGames(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(games))
+	}
+	// both games were recorded within the same second, so their order is not fixed
+	idx := slices.IndexFunc(games, func(g models.Game) bool { return g.Type == models.DOUBLES })
+	if idx == -1 {
+		t.Fatalf("expected a doubles game, got %+v", games)
+	}
+	doubles, singles := games[idx], games[1-idx]
+	if doubles.WinnerPartner == nil || doubles.LoserPartner == nil {
+		t.Fatalf("expected the doubles game to include all four players, got %+v", doubles)
+	}
+	if doubles.LoserPartner.Name != "Dave" {
+		t.Errorf("expected Dave as the losing partner, got %v", doubles.LoserPartner.Name)
+	}
+	if singles.Type != models.SINGLES || singles.WinnerPartner != nil {
+		t.Errorf("expected a singles game without partners, got %+v", singles)
+	}
+
+	data, err := s.GetIndexPageData(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data.DoublesLeaderboard) != 4 || math.Round(data.DoublesLeaderboard[0].EloRating) != 1020 {
+		t.Errorf("expected all four players on the doubles leaderboard, got %v", data.DoublesLeaderboard)
+	}
+
+	// singles ratings are untouched by default
+	ratings, err := s.GetPlayerEloRatings([2]int{ids[2], ids[3]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ratings[ids[2]] != 1000 || ratings[ids[3]] != 1000 {
+		t.Errorf("expected singles ratings to be unchanged, got %v", ratings)
+	}
+}
+
+func TestSQLiteStoreMatchSets(t *testing.T) {
+	s := createSQLiteStore(t)
+	ids := createPlayers(t, s, "Alice", "Bob")
+
+	r := models.MatchResult{
+		WinnerID: ids[0],
+		LoserID:  ids[1],
+		BestOf:   3,
+		Sets: []models.SetScore{
+			{WinnerScore: 11, LoserScore: 7},
+			{WinnerScore: 9, LoserScore: 11},
+			{WinnerScore: 12, LoserScore: 10},
+		},
+	}
+	if _, err := s.InsertMatchResult(r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	games, err := s.GetGames(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 1 || games[0].BestOf == nil || *games[0].BestOf != 3 {
+		t.Fatalf("expected a best of 3 match, got %+v", games)
+	}
+	if !slices.Equal(games[0].Sets, r.Sets) {
+		t.Errorf("expected the sets %v, got %v", r.Sets, games[0].Sets)
+	}
+
+	results, err := s.GetGameResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Sets) != 3 {
+		t.Errorf("expected the game results to include the sets, got %+v", results)
+	}
+
+	profile, err := s.GetPlayerProfile(ids[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(profile.RecentGames) != 1 || len(profile.RecentGames[0].Sets) != 3 {
+		t.Errorf("expected the match in recent games, got %+v", profile.RecentGames)
+	}
+
+	data, err := s.GetIndexPageData(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.GlobalStats.TotalGames != 1 || data.GlobalStats.TotalPoints != 60 {
+		t.Errorf("expected 1 game and 60 points, got %v", data.GlobalStats)
+	}
+
+	// the cached totals agree with the database
+	if err := SetGameStatistics(&s.MySQLStore); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.TotalPointSum != 60 {
+		t.Errorf("expected 60 points, got %d", s.TotalPointSum)
+	}
+}
+
+func TestSQLiteStoreGlickoRatings(t *testing.T) {
+	s := createSQLiteStore(t)
+	ids := createPlayers(t, s, "Alice")
+
+	err := s.UpdateGlickoRatings(models.GlickoRatings{ids[0]: {Rating: 1100, Deviation: 80, Volatility: 0.05}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	profile, err := s.GetPlayerProfile(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.Glicko.Rating != 1100 || profile.Glicko.Deviation != 80 {
+		t.Errorf("expected a rating of 1100 ± 80, got %+v", profile.Glicko)
+	}
+	if math.Round(profile.Glicko.Interval[1]) != 1257 {
+		t.Errorf("expected the interval to end at 1257, got %v", profile.Glicko.Interval)
+	}
+
+	data, err := s.GetIndexPageData(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data.Leaderboard) != 1 || data.Leaderboard[0].Glicko == nil || data.Leaderboard[0].Glicko.Rating != 1100 {
+		t.Errorf("expected the Glicko-2 rating on the leaderboard, got %+v", data.Leaderboard)
+	}
+}
+
+func TestSQLiteStoreRatingHistory(t *testing.T) {
+	s := createSQLiteStore(t)
+	cfg := config.Default()
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol", "Dave")
+
+	r := models.GameResult{WinnerID: ids[0], LoserID: ids[1]}
+	id, err := s.InsertGameResult(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := utils.UpdatePlayersEloRating(s, cfg, int(id), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.InsertDoublesGameResult(models.DoublesGameResult{
+		WinnerIDs: []int{ids[0], ids[2]}, LoserIDs: []int{ids[1], ids[3]},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	history, err := s.GetRatingHistory(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 1 || history[0].GameID != int(id) || history[0].GameType != models.SINGLES {
+		t.Fatalf("expected the singles game in the history, got %+v", history)
+	}
+	if history[0].RatingBefore != 1000 || math.Round(history[0].RatingAfter) != 1020 {
+		t.Errorf("expected a provisional change from 1000 to 1020, got %+v", history[0])
+	}
+	if history[0].PlayedAt.IsZero() {
+		t.Errorf("expected the change to take the time of its game")
+	}
+
+	// a replay with doubles counted rebuilds the history from every game
+	cfg.Doubles.AffectsSingles = true
+	if err := utils.RecalculateEloRatings(s, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	history, err = s.GetRatingHistory(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 rating changes after the replay, got %+v", history)
+	}
+	doubles := slices.IndexFunc(history, func(c models.RatingChange) bool { return c.GameType == models.DOUBLES })
+	if doubles == -1 {
+		t.Errorf("expected the doubles game in the history, got %+v", history)
+	}
+
+	if err := s.DeleteGame(int(id), "recorded twice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	history, err = s.GetRatingHistory(ids[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 1 || history[0].GameType != models.DOUBLES {
+		t.Errorf("expected deleting a game to remove its rating changes, got %+v", history)
+	}
+}
+
+func TestSQLiteStoreGamesIncludeRatingChanges(t *testing.T) {
+	s := createSQLiteStore(t)
+	cfg := config.Default()
+	ids := createPlayers(t, s, "Alice", "Bob", "Carol", "Dave")
+
+	r := models.GameResult{WinnerID: ids[0], LoserID: ids[1]}
+	id, err := s.InsertGameResult(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := utils.UpdatePlayersEloRating(s, cfg, int(id), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d := models.DoublesGameResult{WinnerIDs: []int{ids[0], ids[2]}, LoserIDs: []int{ids[1], ids[3]}}
+	doublesID, err := s.InsertDoublesGameResult(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := utils.UpdatePlayersDoublesEloRating(s, cfg, int(doublesID), d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	games, err := s.GetGames(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(games))
+	}
+	for _, g := range games {
+		if g.WinnerRatingChange == nil || g.LoserRatingChange == nil || g.WinProbability == nil {
+			t.Fatalf("expected the %v game to include its rating changes, got %+v", g.Type, g)
+		}
+		if math.Round(*g.WinnerRatingChange) != 20 || math.Round(*g.LoserRatingChange) != -20 {
+			t.Errorf("expected the %v game to be worth 20 points, got %v and %v", g.Type, *g.WinnerRatingChange, *g.LoserRatingChange)
+		}
+		if *g.WinProbability != 0.5 {
+			t.Errorf("expected an even %v game, got a win probability of %v", g.Type, *g.WinProbability)
+		}
+		if g.Type == models.DOUBLES && (g.WinnerPartnerRatingChange == nil || g.LoserPartnerRatingChange == nil) {
+			t.Errorf("expected the doubles game to include the partners' rating changes, got %+v", g)
+		}
+	}
+
+	// the recent games on a profile come from a separate query
+	profile, err := s.GetPlayerProfile(ids[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(profile.RecentGames) != 1 || profile.RecentGames[0].LoserRatingChange == nil {
+		t.Errorf("expected the profile's recent game to include its rating changes, got %+v", profile.RecentGames)
+	}
+}
+
+func TestSQLiteStoreAPITokens(t *testing.T) {
+	s := createSQLiteStore(t)
+	ids := createPlayers(t, s, "Alice")
+
+	id, err := s.InsertAPIToken("hash", models.NewAPIToken{Name: "Alice's phone", Role: models.ROLE_PLAYER, PlayerID: &ids[0]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.InsertAPIToken("other", models.NewAPIToken{Name: "ghost", Role: models.ROLE_PLAYER, PlayerID: intPointer(99)}); err == nil {
+		t.Errorf("expected an error for a token of an unknown player")
+	}
+
+	token, err := s.GetAPIToken("hash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token.ID != int(id) || token.Role != models.ROLE_PLAYER || token.PlayerID == nil || *token.PlayerID != ids[0] {
+		t.Errorf("expected Alice's player token, got %+v", token)
+	}
+
+	if err := s.DeleteAPIToken(int(id)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.GetAPIToken("hash"); !errors.Is(err, exceptions.ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound for a revoked token, got %v", err)
+	}
+}
+
+func TestSQLiteStoreConfirmationWorkflow(t *testing.T) {
+	checkConfirmationWorkflow(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreDoublesConfirmationWorkflow(t *testing.T) {
+	checkDoublesConfirmationWorkflow(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreSubmittedAt(t *testing.T) {
+	checkSubmittedAt(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreSoftDelete(t *testing.T) {
+	checkSoftDelete(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreAuditLog(t *testing.T) {
+	checkAuditLog(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreUpdateGameResult(t *testing.T) {
+	checkUpdateGameResult(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreBackdatedGame(t *testing.T) {
+	checkBackdatedGame(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreImportGames(t *testing.T) {
+	checkImportGames(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreArchiveRoundTrip(t *testing.T) {
+	checkArchiveRoundTrip(t, CreateMemoryStore(), createSQLiteStore(t))
+}
+
+func TestSQLiteStoreRebuildAchievements(t *testing.T) {
+	checkRebuildAchievements(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreAchievementStats(t *testing.T) {
+	checkAchievementStats(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreAchievementScores(t *testing.T) {
+	checkAchievementScores(t, createSQLiteStore(t))
+}
+
+func TestSQLiteStoreAchievementUnlockEvents(t *testing.T) {
+	checkAchievementUnlockEvents(t, createSQLiteStore(t))
+}
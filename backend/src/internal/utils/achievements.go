@@ -1,11 +1,19 @@
 package utils
 
 import (
+	"cmp"
+	_ "embed"
+	"encoding/json"
 	"fmt"
-	"maps"
+	"io"
+	"math"
+	"os"
 	"slices"
+	"strings"
 	"time"
 
+	"github.com/jda5/luinc-pong/src/internal/events"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
 	"github.com/jda5/luinc-pong/src/internal/models"
 )
 
@@ -13,59 +21,79 @@ import (
 
 const LIMIT int = 1_000_000
 
+// The built-in achievement catalogue, used unless ACHIEVEMENTS_FILE names another.
+//
+//go:embed achievements.json
+var builtInAchievements []byte
+
+// ACHIEVEMENT_DEFINITIONS is the achievement catalogue, with the rule that earns each one.
+var ACHIEVEMENT_DEFINITIONS = mustReadAchievements(builtInAchievements)
+
+// ACHIEVEMENTS is the catalogue as the stores serve it and as it is seeded into the
+// `achievement` table.
+var ACHIEVEMENTS = achievementCatalogue(ACHIEVEMENT_DEFINITIONS)
+
+type RuleType string
+
+const (
+	RULE_GAME_COUNT       RuleType = "gameCount"      // play Count games
+	RULE_WIN_COUNT        RuleType = "winCount"       // win Count games
+	RULE_WIN_STREAK       RuleType = "winStreak"      // win Count games in a row
+	RULE_LOSS_STREAK      RuleType = "lossStreak"     // lose Count games in a row
+	RULE_SCORE            RuleType = "score"          // play a game with the given score
+	RULE_OPPONENT_COUNT   RuleType = "opponentCount"  // play Count different opponents
+	RULE_HEAD_TO_HEAD     RuleType = "headToHead"     // play, win or lose Count games against the same opponent
+	RULE_DAILY_WIN_STREAK RuleType = "dailyWinStreak" // beat the same opponent Count times in a row in a single day
+	RULE_DAILY_GAMES      RuleType = "dailyGames"     // play Count games in a single day
+	RULE_DAY_STREAK       RuleType = "dayStreak"      // play on Count consecutive days
+	RULE_TIME_OF_DAY      RuleType = "timeOfDay"      // play a game between FromHour and ToHour
+	RULE_RATING           RuleType = "rating"         // reach an Elo rating of Rating
+	RULE_UPSET            RuleType = "upset"          // beat an opponent rated at least Margin higher
+)
+
+// the rules that count up to a target, rather than being met by a single game
+var countingRules = []RuleType{
+	RULE_GAME_COUNT, RULE_WIN_COUNT, RULE_WIN_STREAK, RULE_LOSS_STREAK, RULE_OPPONENT_COUNT,
+	RULE_HEAD_TO_HEAD, RULE_DAILY_WIN_STREAK, RULE_DAILY_GAMES, RULE_DAY_STREAK,
+}
+
+type RuleResult string
+
 const (
-	PLAY_1   models.AchievementID = iota + 1 // warming up
-	PLAY_10                                  // minimum viable pong
-	PLAY_50                                  // regular
-	PLAY_100                                 // centurion
-	PLAY_250                                 // legend
-	PLAY_500                                 // unicorn
-
-	WIN_11_0                     // chocolate
-	WIN_11_1                     // bottle job
-	WIN_12_10                    // clutch
-	WIN_WITH_MORE_THAN_14_POINTS // marathon madness
-	LOSE_12_10                   // heartbreaker
-
-	WIN_5_CONSECUTIVE  // streaky
-	WIN_10_CONSECUTIVE // unstoppable
-	WIN_15_CONSECUTIVE // immortal
-
-	LOSE_5_CONSECUTIVE                            // i get knocked down, but i get up again
-	DAILY_WIN_3_CONSECUTIVE_AGAINST_SAME_OPPONENT // hat trick
-	DAILY_WIN_5_CONSECUTIVE_AGAINST_SAME_OPPONENT // brutal
-
-	LOSE_OPPONENT_15 // nemesis
-	PLAY_OPPONENT_25 // rivalry
-	PLAY_5_OPPONENTS // social butterfly
-
-	PLAY_5_DAY  // daily standup
-	PLAY_10_DAY // do you even work here?
-
-	PLAY_OUTSIDE_WORK_HOURS // go home
-	PLAY_3_DAY_STREAK       // dedicated
-	PLAY_5_DAY_STREAK       // addicted
-
-	WIN_UPSET_100_ELO // hostile takeover
-	ELO_REACH_1100    // rising star
-	ELO_REACH_1200    // big shot
-	ELO_REACH_1300    // title charge
-	ELO_REACH_1400    // final boss
-	ELO_REACH_1500    // roll credits
-
-	PLAY_420 // enhance your calm
-
-	WIN_1     // on the scoreboard
-	WIN_10    // not a fluke
-	WIN_25    // victory lap
-	WIN_50    // certified menace
-	WIN_100   // fear me
-	WIN_200   // apex predator
-	WIN_400   // collecting souls
-	PLAY_750  // titan
-	PLAY_1000 // one comma club
+	RESULT_WIN  RuleResult = "win"
+	RESULT_LOSS RuleResult = "loss"
 )
 
+// AchievementRule says what earns an achievement. Each type of rule uses only the fields
+// named in its description.
+type AchievementRule struct {
+	Type  RuleType `json:"type"`
+	Count int      `json:"count,omitempty"`
+
+	// Limits score and headToHead rules to the games the player won or lost.
+	Result RuleResult `json:"result,omitempty"`
+
+	// A score rule is met by a game with exactly these scores, or in which the winner
+	// scored at least MinWinnerScore. Any score left out matches every game.
+	WinnerScore    *int `json:"winnerScore,omitempty"`
+	LoserScore     *int `json:"loserScore,omitempty"`
+	MinWinnerScore *int `json:"minWinnerScore,omitempty"`
+
+	// A timeOfDay rule is met by a game played from the start of FromHour up to the start
+	// of ToHour, in the local time of the office. The hours wrap past midnight.
+	FromHour int `json:"fromHour,omitempty"`
+	ToHour   int `json:"toHour,omitempty"`
+
+	Rating float64 `json:"rating,omitempty"`
+	Margin float64 `json:"margin,omitempty"`
+}
+
+// AchievementDefinition is an achievement in the catalogue with the rule that earns it.
+type AchievementDefinition struct {
+	models.Achievement
+	Rule AchievementRule `json:"rule"`
+}
+
 type DayCount struct {
 	date  time.Time
 	count int
@@ -73,329 +101,626 @@ type DayCount struct {
 
 type HeadToHead struct {
 	playCount    int
+	winCount     int
 	loseCount    int
 	dayWinStreak DayCount
 }
 
-type AchievementSet map[models.AchievementID]struct{}
+// playerRecord is what a player has done up to and including the game being replayed.
+type playerRecord struct {
+	gamesPlayed int
+	winCount    int
+	winStreak   int
+	loseStreak  int
+
+	// the number of games played on the day of the last game, and the number of
+	// consecutive days up to it that the player has played on
+	dayGames   int
+	playStreak int
+	lastPlayed time.Time
+
+	// a map from opponent ID to head-to-head stats
+	headToHead map[int]HeadToHead
+}
+
+// achievementValues maps each counted achievement to the best value a player has reached
+// towards it.
+type achievementValues map[models.AchievementID]float64
+
+func (v achievementValues) max(id models.AchievementID, value float64) {
+	if best, ok := v[id]; !ok || value > best {
+		v[id] = value
+	}
+}
+
+// AchievementSet maps each achievement a player has earned to when they first earned it.
+type AchievementSet map[models.AchievementID]time.Time
 
-func (a AchievementSet) InsertID(id models.AchievementID) {
-	a[id] = struct{}{}
+func (a AchievementSet) InsertID(id models.AchievementID, at time.Time) {
+	if earned, ok := a[id]; !ok || at.Before(earned) {
+		a[id] = at
+	}
 }
 
 // -------------------------------------------------------------------------------- public functions
 
-// Calculate and update the achievements for both players based on their game history and recent game result.
-func UpdatePlayerAchievements(
-	s models.Store,
-	lastGame models.GameResult,
-	oldRatings models.EloRatings,
-	newRatings models.EloRatings,
-) error {
+// UpdatePlayerAchievements awards both players of a recorded game the achievements they
+// have earned over their whole history, each dated by the game that earned it. Those the
+// players did not hold before are published to the broker as they are unlocked.
+func UpdatePlayerAchievements(s models.Store, lastGame models.GameResult, broker *events.Broker) error {
+	ids := []int{lastGame.WinnerID, lastGame.LoserID}
 
-	for _, id := range []int{lastGame.WinnerID, lastGame.LoserID} {
-		games, err := s.GetPlayerGames(id, LIMIT)
-		if err != nil {
-			return fmt.Errorf("error updating player achievements %v", err)
-		}
-		if len(games) == 0 {
+	games, history, ratings, err := loadAchievementHistory(s, ids)
+	if err != nil {
+		return fmt.Errorf("error updating player achievements %v", err)
+	}
+	held, err := s.GetPlayerAchievements()
+	if err != nil {
+		return fmt.Errorf("error updating player achievements %v", err)
+	}
+
+	unlocked := make([]models.PlayerAchievement, 0)
+	for _, id := range ids {
+		if len(games[id]) == 0 {
 			// nothing to update
 			continue
 		}
 
-		playerAchievements, err := calculatePlayersAchievements(
-			id, games, lastGame, oldRatings, newRatings,
-		)
+		earned, _ := earnPlayerAchievements(id, games[id], history[id], ratings)
+		awards := earned.awards(id)
+		err = s.InsertPlayerAchievements(awards)
 		if err != nil {
 			return fmt.Errorf("error updating player achievements %v", err)
 		}
-		err = s.InsertPlayerAchievements(id, playerAchievements)
-		if err != nil {
-			return fmt.Errorf("error updating player achievements %v", err)
+
+		for _, a := range awards {
+			if !slices.ContainsFunc(held, func(h models.PlayerAchievement) bool {
+				return h.PlayerID == a.PlayerID && h.AchievementID == a.AchievementID
+			}) {
+				unlocked = append(unlocked, a)
+			}
 		}
 	}
+
+	if broker != nil && len(unlocked) > 0 {
+		return publishAchievementUnlocks(s, broker, unlocked)
+	}
 	return nil
 }
 
-// -------------------------------------------------------------------------------- private functions
+// RebuildAchievements works out every player's achievements from their whole history
+// and replaces the awarded achievements with them in one go. Achievements earned by games
+// since deleted or corrected are taken away, achievements added to the catalogue since
+// are given out, and each is dated by the game that earned it.
+func RebuildAchievements(s models.Store) error {
+	players, err := s.GetPlayerBasicInfo()
+	if err != nil {
+		return fmt.Errorf("error rebuilding achievements: %v", err)
+	}
 
-// Calculate the achievements a player has earned based on their game history and recent game result.
-func calculatePlayersAchievements(
-	id int,
-	playerGames []models.Game,
-	lastGame models.GameResult,
-	oldRatings models.EloRatings,
-	newRating models.EloRatings,
-) ([]models.AchievementID, error) {
+	ids := make([]int, 0, len(players))
+	for _, p := range players {
+		ids = append(ids, p.ID)
+	}
+	history, ratings, err := loadRatingHistory(s, ids)
+	if err != nil {
+		return fmt.Errorf("error rebuilding achievements: %v", err)
+	}
 
-	a := make(AchievementSet)
-	gamesPlayed := len(playerGames)
+	awards := make([]models.PlayerAchievement, 0)
+	for _, id := range ids {
+		games, err := s.GetPlayerGames(id, LIMIT)
+		if err != nil {
+			return fmt.Errorf("error rebuilding achievements: %v", err)
+		}
+		earned, _ := earnPlayerAchievements(id, games, history[id], ratings)
+		awards = append(awards, earned.awards(id)...)
+	}
+
+	return s.ReplacePlayerAchievements(awards)
+}
 
-	// ---------------------------------------- game count milestones
-	if gamesPlayed >= 1 {
-		a.InsertID(PLAY_1)
+// GetAchievementProgress works out how far a player has got towards every achievement in
+// the catalogue, from the same replay of their history that awards them.
+func GetAchievementProgress(s models.Store, id int, startRating float64) ([]models.AchievementProgress, error) {
+	games, history, ratings, err := loadAchievementHistory(s, []int{id})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching achievement progress: %v", err)
 	}
-	if gamesPlayed >= 10 {
-		a.InsertID(PLAY_10)
+
+	earned, values := earnPlayerAchievements(id, games[id], history[id], ratings)
+	return achievementProgress(earned, values, startRating), nil
+}
+
+// GroupAchievementFamilies sums up a player's progress through each achievement family, in
+// the order the families first appear in the catalogue.
+func GroupAchievementFamilies(progress []models.AchievementProgress) []models.AchievementFamily {
+	families := make([]models.AchievementFamily, 0)
+	tiers := make(map[string][]models.AchievementProgress)
+	for _, p := range progress {
+		if p.Family == "" {
+			continue
+		}
+		if _, ok := tiers[p.Family]; !ok {
+			families = append(families, models.AchievementFamily{Family: p.Family})
+		}
+		tiers[p.Family] = append(tiers[p.Family], p)
 	}
-	if gamesPlayed >= 50 {
-		a.InsertID(PLAY_50)
+
+	for i, f := range families {
+		familyTiers := slices.SortedFunc(slices.Values(tiers[f.Family]), func(a, b models.AchievementProgress) int {
+			return cmp.Compare(a.Tier, b.Tier)
+		})
+		f.Tiers = len(familyTiers)
+		for _, p := range familyTiers {
+			if !p.Unlocked {
+				f.Next = &p
+				break
+			}
+			f.Tier, f.Highest = p.Tier, &p
+		}
+		families[i] = f
 	}
-	if gamesPlayed >= 100 {
-		a.InsertID(PLAY_100)
+	return families
+}
+
+// ReadAchievements decodes an achievement catalogue: a JSON array of achievements, each
+// with the rule that earns it. Every definition is checked, so that all the problems
+// with a file can be fixed at once.
+func ReadAchievements(r io.Reader) ([]AchievementDefinition, error) {
+	var definitions []AchievementDefinition
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&definitions); err != nil {
+		return nil, fmt.Errorf("error reading achievements: %v", err)
 	}
-	if gamesPlayed >= 250 {
-		a.InsertID(PLAY_250)
+	if err := validateAchievements(definitions); err != nil {
+		return nil, err
 	}
-	if gamesPlayed >= 420 {
-		a.InsertID(PLAY_420)
+	return definitions, nil
+}
+
+// LoadAchievements replaces the built-in catalogue with the one in a file. It must be
+// called before a store is opened, as the stores seed the catalogue when they start.
+func LoadAchievements(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening achievements file: %v", err)
 	}
-	if gamesPlayed >= 500 {
-		a.InsertID(PLAY_500)
+	defer f.Close()
+
+	definitions, err := ReadAchievements(f)
+	if err != nil {
+		return err
 	}
-	if gamesPlayed >= 750 {
-		a.InsertID(PLAY_750)
+	ACHIEVEMENT_DEFINITIONS = definitions
+	ACHIEVEMENTS = achievementCatalogue(definitions)
+	return nil
+}
+
+// -------------------------------------------------------------------------------- private functions
+
+// mustReadAchievements reads the built-in catalogue. It is part of the build, so a problem
+// with it is a bug.
+func mustReadAchievements(data []byte) []AchievementDefinition {
+	definitions, err := ReadAchievements(strings.NewReader(string(data)))
+	if err != nil {
+		panic(fmt.Sprintf("error reading the built-in achievements: %v", err))
 	}
-	if gamesPlayed >= 1000 {
-		a.InsertID(PLAY_1000)
+	return definitions
+}
+
+// achievementCatalogue lists the achievements of a catalogue without their rules.
+func achievementCatalogue(definitions []AchievementDefinition) []models.Achievement {
+	achievements := make([]models.Achievement, 0, len(definitions))
+	for _, d := range definitions {
+		achievements = append(achievements, d.Achievement)
 	}
+	return achievements
+}
 
-	// ---------------------------------------- time-based achievements
+// validateAchievements checks that every achievement has a unique id, a title, a place in
+// its family and a rule with the fields its type needs. The lengths are those of the
+// `achievement` table's columns.
+func validateAchievements(definitions []AchievementDefinition) error {
+	errs := &exceptions.ValidationError{}
+	ids := make(map[int]bool)
+	tiers := make(map[string]map[int]bool)
+	for i, d := range definitions {
+		field := fmt.Sprintf("achievements[%d]", i)
+		if d.ID < 1 || ids[d.ID] {
+			errs.Add(field+".id", fmt.Sprintf("%v is not a positive, unique id", d.ID))
+		}
+		ids[d.ID] = true
+		if strings.TrimSpace(d.Title) == "" || len(d.Title) > 63 {
+			errs.Add(field+".title", "must be from 1 to 63 characters long")
+		}
+		if len(d.Description) > 255 {
+			errs.Add(field+".description", "cannot be longer than 255 characters")
+		}
 
-	// A counter for consecutive days played
-	playStreak := 0
+		switch {
+		case len(d.Family) > 63:
+			errs.Add(field+".family", "cannot be longer than 63 characters")
+		case d.Family == "" && d.Tier != 0:
+			errs.Add(field+".tier", "only achievements in a family have a tier")
+		case d.Family != "" && (d.Tier < 1 || tiers[d.Family][d.Tier]):
+			errs.Add(field+".tier", fmt.Sprintf("%v is not a positive tier unique to the '%v' family", d.Tier, d.Family))
+		}
+		if tiers[d.Family] == nil {
+			tiers[d.Family] = make(map[int]bool)
+		}
+		tiers[d.Family][d.Tier] = true
 
-	// A counter for consecutive wins
-	winStreak := 0
+		if d.Points < 0 {
+			errs.Add(field+".points", "cannot be negative")
+		}
+		d.Rule.validate(errs, field+".rule")
+	}
+	return errs.ErrOrNil()
+}
 
-	// A counter for consecutive losses
-	loseStreak := 0
+// validate adds any problems with a rule to errs.
+func (r AchievementRule) validate(errs *exceptions.ValidationError, field string) {
+	if slices.Contains(countingRules, r.Type) && r.Count < 1 {
+		errs.Add(field+".count", "must be at least 1")
+	}
+	if r.Result != "" && r.Result != RESULT_WIN && r.Result != RESULT_LOSS {
+		errs.Add(field+".result", fmt.Sprintf("unknown result '%v': expected win or loss", r.Result))
+	}
 
-	// The last time the player played a game. Initialized to a date far in the past
-	lastPlayed := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	switch r.Type {
+	case RULE_SCORE:
+		if r.WinnerScore == nil && r.LoserScore == nil && r.MinWinnerScore == nil {
+			errs.Add(field, "a score rule needs a winnerScore, loserScore or minWinnerScore")
+		}
+	case RULE_TIME_OF_DAY:
+		if r.FromHour < 0 || r.FromHour > 23 || r.ToHour < 0 || r.ToHour > 23 || r.FromHour == r.ToHour {
+			errs.Add(field, "fromHour and toHour must be different hours from 0 to 23")
+		}
+	case RULE_RATING:
+		if r.Rating <= 0 {
+			errs.Add(field+".rating", "must be greater than 0")
+		}
+	case RULE_UPSET:
+		if r.Margin <= 0 {
+			errs.Add(field+".margin", "must be greater than 0")
+		}
+	default:
+		if !slices.Contains(countingRules, r.Type) {
+			errs.Add(field+".type", fmt.Sprintf("unknown rule type '%v'", r.Type))
+		}
+	}
+}
 
-	var opponentID int
-	var wonGame bool
+// ratingKey identifies the change to one player's singles rating made by one game.
+type ratingKey struct {
+	gameType models.GameType
+	gameID   int
+	playerID int
+}
 
-	// A counter for number of games played in a single day
-	dayStreak := 0
+// loadAchievementHistory fetches the games of each player, and the rating history of them
+// and everyone they have played, as upsets are judged on the opponents' ratings too.
+func loadAchievementHistory(s models.Store, ids []int) (
+	map[int][]models.Game,
+	map[int][]models.RatingChange,
+	map[ratingKey]models.RatingChange,
+	error,
+) {
+	games := make(map[int][]models.Game)
+	historyIDs := slices.Clone(ids)
+	for _, id := range ids {
+		playerGames, err := s.GetPlayerGames(id, LIMIT)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		games[id] = playerGames
+		for _, g := range playerGames {
+			historyIDs = append(historyIDs, g.Winner.ID, g.Loser.ID)
+		}
+	}
 
-	// A counter for the total number of games won
-	winCount := 0
+	slices.Sort(historyIDs)
+	history, ratings, err := loadRatingHistory(s, slices.Compact(historyIDs))
+	return games, history, ratings, err
+}
 
-	// A map from opponent ID to head-to-head stats
-	headToHeadMap := make(map[int]HeadToHead)
+// loadRatingHistory fetches the singles rating history of each player, both as a list
+// per player and indexed by game.
+func loadRatingHistory(s models.Store, ids []int) (map[int][]models.RatingChange, map[ratingKey]models.RatingChange, error) {
+	history := make(map[int][]models.RatingChange)
+	all := make([]models.RatingChange, 0)
+	for _, id := range ids {
+		changes, err := s.GetRatingHistory(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		history[id] = changes
+		all = append(all, changes...)
+	}
+	return history, indexRatingChanges(all), nil
+}
 
-	for _, game := range playerGames {
+// indexRatingChanges indexes rating changes by game and player.
+func indexRatingChanges(changes []models.RatingChange) map[ratingKey]models.RatingChange {
+	ratings := make(map[ratingKey]models.RatingChange, len(changes))
+	for _, c := range changes {
+		ratings[ratingKey{c.GameType, c.GameID, c.PlayerID}] = c
+	}
+	return ratings
+}
 
-		// ---------------------------------------- play streaks
-		if datesEqual(game.CreatedAt, lastPlayed) {
-			// same day as previous game
-			dayStreak++
-			switch dayStreak {
-			case 5:
-				a.InsertID(PLAY_5_DAY)
-			case 10:
-				a.InsertID(PLAY_10_DAY)
-			}
-		} else {
-			// new day
-
-			// day streak is reset
-			dayStreak = 1
-
-			if datesEqual(game.CreatedAt.AddDate(0, 0, -1), lastPlayed) {
-				playStreak++
-				switch playStreak {
-				case 3:
-					a.InsertID(PLAY_3_DAY_STREAK)
-				case 5:
-					a.InsertID(PLAY_5_DAY_STREAK)
-				}
-			} else {
-				playStreak = 1
-			}
+// publishAchievementUnlocks tells subscribers about newly awarded achievements, oldest first.
+func publishAchievementUnlocks(s models.Store, broker *events.Broker, unlocked []models.PlayerAchievement) error {
+	players, err := s.GetPlayerBasicInfo()
+	if err != nil {
+		return fmt.Errorf("error publishing achievements %v", err)
+	}
+	names := make(map[int]string, len(players))
+	for _, p := range players {
+		names[p.ID] = p.Name
+	}
 
+	slices.SortFunc(unlocked, func(a, b models.PlayerAchievement) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	for _, a := range unlocked {
+		i := slices.IndexFunc(ACHIEVEMENTS, func(achievement models.Achievement) bool { return achievement.ID == a.AchievementID })
+		if i == -1 {
+			continue
 		}
-		lastPlayed = game.CreatedAt
-
-		wonGame = game.Winner.ID == id
-		if wonGame {
-			opponentID = game.Loser.ID
-
-			// ---------------------------------------- score-based
-			if notNilPointer(game.WinnerScore) {
-				if *game.WinnerScore == 11 {
-					switch *game.LoserScore {
-					case 0:
-						a.InsertID(WIN_11_0)
-					case 1:
-						a.InsertID(WIN_11_1)
-					}
-				} else if *game.WinnerScore == 12 && *game.LoserScore == 10 {
-					a.InsertID(WIN_12_10)
-				} else if *game.WinnerScore >= 15 {
-					a.InsertID(WIN_WITH_MORE_THAN_14_POINTS)
-				}
-			}
+		broker.Publish(events.ACHIEVEMENT_UNLOCKED, events.AchievementUnlock{
+			Player:      models.Player{ID: a.PlayerID, Name: names[a.PlayerID]},
+			Achievement: ACHIEVEMENTS[i],
+			EarnedAt:    a.CreatedAt.UTC(),
+		})
+	}
+	return nil
+}
 
-			// ---------------------------------------- winning streaks
-			loseStreak = 0
-			winStreak++
-			switch winStreak {
-			case 5:
-				a.InsertID(WIN_5_CONSECUTIVE)
-			case 10:
-				a.InsertID(WIN_10_CONSECUTIVE)
-			case 15:
-				a.InsertID(WIN_15_CONSECUTIVE)
-			}
+// awards lists the achievements in the set as awarded to a player.
+func (a AchievementSet) awards(id int) []models.PlayerAchievement {
+	awards := make([]models.PlayerAchievement, 0, len(a))
+	for achievementID, at := range a {
+		awards = append(awards, models.PlayerAchievement{PlayerID: id, AchievementID: int(achievementID), CreatedAt: at})
+	}
+	return awards
+}
 
-			// ---------------------------------------- winning
-			winCount++
-			if winCount >= 1 {
-				a.InsertID(WIN_1)
-			}
-			if winCount >= 10 {
-				a.InsertID(WIN_10)
-			}
-			if winCount >= 25 {
-				a.InsertID(WIN_25)
-			}
-			if winCount >= 50 {
-				a.InsertID(WIN_50)
-			}
-			if winCount >= 100 {
-				a.InsertID(WIN_100)
-			}
-			if winCount >= 200 {
-				a.InsertID(WIN_200)
-			}
-			if winCount >= 400 {
-				a.InsertID(WIN_400)
+// earnPlayerAchievements works out everything a player has earned from their games and
+// the rating history: their own changes for the rating milestones, and those of both
+// players of each game for the upsets. Each achievement is dated by the game that
+// earned it. The best value the player has reached towards each counted achievement is
+// returned with them.
+func earnPlayerAchievements(
+	id int,
+	playerGames []models.Game,
+	changes []models.RatingChange,
+	ratings map[ratingKey]models.RatingChange,
+) (AchievementSet, achievementValues) {
+	a, values := earnGameAchievements(id, playerGames)
+
+	for _, d := range ACHIEVEMENT_DEFINITIONS {
+		switch d.Rule.Type {
+		case RULE_RATING:
+			for _, c := range changes {
+				values.max(models.AchievementID(d.ID), c.RatingAfter)
+				if c.RatingAfter >= d.Rule.Rating {
+					a.InsertID(models.AchievementID(d.ID), c.PlayedAt)
+				}
 			}
 
-			// ---------------------------------------- losing achievements
-		} else {
-			opponentID = game.Winner.ID
-			winStreak = 0
-			loseStreak++
-			if loseStreak == 5 {
-				a.InsertID(LOSE_5_CONSECUTIVE)
-			}
-			if notNilPointer(game.WinnerScore) && notNilPointer(game.LoserScore) {
-				if *game.WinnerScore == 12 && *game.LoserScore == 10 {
-					a.InsertID(LOSE_12_10)
+		case RULE_UPSET:
+			for _, g := range playerGames {
+				if g.Winner.ID != id {
+					continue
+				}
+				winner, ok := ratings[ratingKey{g.Type, g.ID, id}]
+				if !ok {
+					continue
+				}
+				loser, ok := ratings[ratingKey{g.Type, g.ID, g.Loser.ID}]
+				if !ok {
+					continue
+				}
+				if loser.RatingBefore-winner.RatingBefore >= d.Rule.Margin {
+					a.InsertID(models.AchievementID(d.ID), g.CreatedAt)
 				}
 			}
 		}
+	}
+	return a, values
+}
 
-		// ---------------------------------------- head-to-head
-		h, ok := headToHeadMap[opponentID]
-		if !ok {
-			headToHeadMap[opponentID] = HeadToHead{
-				playCount: 1,
-				loseCount: boolToInt(!wonGame),
-				dayWinStreak: DayCount{
-					date:  game.CreatedAt,
-					count: boolToInt(game.Winner.ID == id),
-				},
-			}
-		} else {
-			h.playCount++
-			if h.playCount == 25 {
-				a.InsertID(PLAY_OPPONENT_25)
-			}
+// earnGameAchievements replays a player's games in the order they were played, noting the
+// game each achievement with a game rule was first earned in, and the best value reached
+// for each counting rule.
+func earnGameAchievements(id int, playerGames []models.Game) (AchievementSet, achievementValues) {
 
-			if wonGame {
-				if datesEqual(h.dayWinStreak.date, game.CreatedAt) {
-					h.dayWinStreak.count++
-				} else {
-					h.dayWinStreak.count = 1
-					h.dayWinStreak.date = game.CreatedAt
-				}
+	a := make(AchievementSet)
+	values := make(achievementValues)
 
-				switch h.dayWinStreak.count {
-				case 3:
-					a.InsertID(DAILY_WIN_3_CONSECUTIVE_AGAINST_SAME_OPPONENT)
-				case 5:
-					a.InsertID(DAILY_WIN_5_CONSECUTIVE_AGAINST_SAME_OPPONENT)
-				}
+	// games are fetched newest first
+	playerGames = slices.SortedStableFunc(slices.Values(playerGames), func(x, y models.Game) int {
+		return x.CreatedAt.Compare(y.CreatedAt)
+	})
 
-			} else {
-				h.dayWinStreak.count = 0
-				h.dayWinStreak.date = game.CreatedAt
-				h.loseCount++
-				if h.loseCount == 15 {
-					a.InsertID(LOSE_OPPONENT_15)
-				}
+	record := playerRecord{
+		// initialized to a date far in the past
+		lastPlayed: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		headToHead: make(map[int]HeadToHead),
+	}
+
+	for _, game := range playerGames {
+		record.add(id, game)
+		for _, d := range ACHIEVEMENT_DEFINITIONS {
+			if slices.Contains(countingRules, d.Rule.Type) {
+				values.max(models.AchievementID(d.ID), float64(d.Rule.count(&record, id, game)))
 			}
+			if d.Rule.earnedBy(&record, id, game) {
+				a.InsertID(models.AchievementID(d.ID), game.CreatedAt)
+			}
+		}
+	}
 
-			// h is a copy of the struct value in the map; modifying it does not update the stored value
-			headToHeadMap[opponentID] = h
+	return a, values
+}
+
+// add updates the record with the player's next game.
+func (r *playerRecord) add(id int, game models.Game) {
+	r.gamesPlayed++
+
+	// ---------------------------------------- play streaks
+	if datesEqual(game.CreatedAt, r.lastPlayed) {
+		// same day as previous game
+		r.dayGames++
+	} else {
+		// new day
+		r.dayGames = 1
+		if datesEqual(game.CreatedAt.AddDate(0, 0, -1), r.lastPlayed) {
+			r.playStreak++
+		} else {
+			r.playStreak = 1
 		}
+	}
+	r.lastPlayed = game.CreatedAt
+
+	// ---------------------------------------- winning & losing streaks
+	won := game.Winner.ID == id
+	opponentID := game.Winner.ID
+	if won {
+		opponentID = game.Loser.ID
+		r.winCount++
+		r.winStreak++
+		r.loseStreak = 0
+	} else {
+		r.winStreak = 0
+		r.loseStreak++
+	}
 
-		// ---------------------------------------- misc
-		playedAt := game.CreatedAt.Hour()
-		if playedAt < 9 || playedAt > 17 {
-			a.InsertID(PLAY_OUTSIDE_WORK_HOURS)
+	// ---------------------------------------- head-to-head
+	h := r.headToHead[opponentID]
+	h.playCount++
+	if won {
+		h.winCount++
+		if datesEqual(h.dayWinStreak.date, game.CreatedAt) {
+			h.dayWinStreak.count++
+		} else {
+			h.dayWinStreak.count = 1
+			h.dayWinStreak.date = game.CreatedAt
 		}
+	} else {
+		h.loseCount++
+		h.dayWinStreak.count = 0
+		h.dayWinStreak.date = game.CreatedAt
 	}
 
-	if len(headToHeadMap) >= 5 {
-		a.InsertID(PLAY_5_OPPONENTS)
+	// h is a copy of the struct value in the map; modifying it does not update the stored value
+	r.headToHead[opponentID] = h
+}
+
+// earnedBy reports whether a game rule is met by a player's game, given their record up
+// to and including it. Rating rules are never met by a game alone.
+func (r AchievementRule) earnedBy(record *playerRecord, id int, game models.Game) bool {
+	won := game.Winner.ID == id
+	if (r.Result == RESULT_WIN && !won) || (r.Result == RESULT_LOSS && won) {
+		return false
 	}
 
-	achievements := make([]models.AchievementID, 0)
-	if len(a) > 0 {
-		achievements = slices.Collect(maps.Keys(a))
+	if slices.Contains(countingRules, r.Type) {
+		return r.count(record, id, game) >= r.Count
 	}
-	err := addPlayerEloAchievement(&achievements, id, lastGame, oldRatings, newRating)
-	if err != nil {
-		return achievements, err
+
+	switch r.Type {
+	case RULE_SCORE:
+		return scoreMatches(r.WinnerScore, game.WinnerScore) &&
+			scoreMatches(r.LoserScore, game.LoserScore) &&
+			(r.MinWinnerScore == nil || (game.WinnerScore != nil && *game.WinnerScore >= *r.MinWinnerScore))
+	case RULE_TIME_OF_DAY:
+		hour := game.CreatedAt.Hour()
+		if r.FromHour < r.ToHour {
+			return hour >= r.FromHour && hour < r.ToHour
+		}
+		return hour >= r.FromHour || hour < r.ToHour
+	default:
+		return false
 	}
-	return achievements, nil
 }
 
-func addPlayerEloAchievement(
-	achievements *[]models.AchievementID,
-	id int,
-	lastGame models.GameResult,
-	oldRatings models.EloRatings,
-	newRating models.EloRatings,
-) error {
-	if id == lastGame.WinnerID {
-		opponentElo, ok := oldRatings[lastGame.LoserID]
-		if !ok {
-			return fmt.Errorf("old elo rating for opponent not found")
+// count measures a player's record, up to and including a game, for a counting rule.
+// Head-to-head rules count the games against that game's opponent.
+func (r AchievementRule) count(record *playerRecord, id int, game models.Game) int {
+	opponentID := game.Winner.ID
+	if game.Winner.ID == id {
+		opponentID = game.Loser.ID
+	}
+	h := record.headToHead[opponentID]
+
+	switch r.Type {
+	case RULE_GAME_COUNT:
+		return record.gamesPlayed
+	case RULE_WIN_COUNT:
+		return record.winCount
+	case RULE_WIN_STREAK:
+		return record.winStreak
+	case RULE_LOSS_STREAK:
+		return record.loseStreak
+	case RULE_OPPONENT_COUNT:
+		return len(record.headToHead)
+	case RULE_HEAD_TO_HEAD:
+		switch r.Result {
+		case RESULT_WIN:
+			return h.winCount
+		case RESULT_LOSS:
+			return h.loseCount
+		default:
+			return h.playCount
 		}
-		playerElo, ok := oldRatings[id]
-		if !ok {
-			return fmt.Errorf("old elo rating for player not found")
+	case RULE_DAILY_WIN_STREAK:
+		return h.dayWinStreak.count
+	case RULE_DAILY_GAMES:
+		return record.dayGames
+	case RULE_DAY_STREAK:
+		return record.playStreak
+	default:
+		return 0
+	}
+}
+
+// achievementProgress lists every achievement in the catalogue with whether the player has
+// unlocked it. Those counted up to also have the best value the player has reached, the
+// target and the percentage of the way there; for rating milestones, that is the way
+// from the starting rating.
+func achievementProgress(a AchievementSet, values achievementValues, startRating float64) []models.AchievementProgress {
+	progress := make([]models.AchievementProgress, 0, len(ACHIEVEMENT_DEFINITIONS))
+	for _, d := range ACHIEVEMENT_DEFINITIONS {
+		p := models.AchievementProgress{Achievement: d.Achievement}
+		if at, ok := a[models.AchievementID(d.ID)]; ok {
+			p.Unlocked = true
+			p.EarnedAt = &at
 		}
-		if opponentElo-playerElo >= 100 {
-			*achievements = append(*achievements, WIN_UPSET_100_ELO)
+
+		current, start, target := values[models.AchievementID(d.ID)], 0.0, float64(d.Rule.Count)
+		switch {
+		case d.Rule.Type == RULE_RATING:
+			current, start, target = max(current, startRating), startRating, d.Rule.Rating
+		case !slices.Contains(countingRules, d.Rule.Type):
+			progress = append(progress, p)
+			continue
 		}
-	}
 
-	newElo, ok := newRating[id]
-	if !ok {
-		return fmt.Errorf("error updating player achievements: old elo rating not found")
-	}
-	if newElo >= 1100 {
-		*achievements = append(*achievements, ELO_REACH_1100)
-	}
-	if newElo >= 1200 {
-		*achievements = append(*achievements, ELO_REACH_1200)
-	}
-	if newElo >= 1300 {
-		*achievements = append(*achievements, ELO_REACH_1300)
-	}
-	if newElo >= 1400 {
-		*achievements = append(*achievements, ELO_REACH_1400)
-	}
-	if newElo >= 1500 {
-		*achievements = append(*achievements, ELO_REACH_1500)
+		percentage := 100.0
+		if current < target {
+			percentage = max(0, math.Round((current-start)/(target-start)*1000)/10)
+		}
+		p.Current, p.Target, p.Percentage = &current, &target, &percentage
+		progress = append(progress, p)
 	}
-	return nil
+	return progress
+}
+
+// scoreMatches reports whether a game's score is the one a rule asks for, if it asks for one.
+func scoreMatches(want *int, score *int) bool {
+	return want == nil || (score != nil && *score == *want)
 }
@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
 	"github.com/jda5/luinc-pong/src/internal/models"
 )
 
@@ -24,30 +27,10 @@ func intPointer(n int) *int {
 	return &n
 }
 
-// gameToGameResult converts a models.Game to a models.GameResult for testing.
-func gameToGameResult(g models.Game) models.GameResult {
-	return models.GameResult{
-		WinnerID:    g.Winner.ID,
-		LoserID:     g.Loser.ID,
-		WinnerScore: g.WinnerScore,
-		LoserScore:  g.LoserScore,
-	}
-}
-
-// createStandardEloRatings provides default ELO ratings for a game.
-func createStandardEloRatings(g models.Game) (models.EloRatings, models.EloRatings) {
-	return models.EloRatings{
-			g.Winner.ID: 1000,
-			g.Loser.ID:  1000,
-		}, models.EloRatings{
-			g.Winner.ID: 1016,
-			g.Loser.ID:  984,
-		}
-}
-
 // containsAchievement checks if a specific achievement is present in the results.
-func containsAchievement(achievements []models.AchievementID, id models.AchievementID) bool {
-	return slices.Contains(achievements, id)
+func containsAchievement(achievements AchievementSet, id models.AchievementID) bool {
+	_, ok := achievements[id]
+	return ok
 }
 
 // -------------------------------------------------------------------------------- Test Fixtures
@@ -64,9 +47,31 @@ var opponent models.Player = models.Player{
 
 var TZ *time.Location = createTimeZone()
 
+// achievements in the built-in catalogue
+const (
+	warmingUp         models.AchievementID = 1
+	minimumViablePong models.AchievementID = 2
+	regular           models.AchievementID = 3
+	unicorn           models.AchievementID = 6
+	chocolate         models.AchievementID = 7
+	bottleJob         models.AchievementID = 8
+	streaky           models.AchievementID = 12
+	immortal          models.AchievementID = 14
+	hatTrick          models.AchievementID = 16
+	brutal            models.AchievementID = 17
+	rivalry           models.AchievementID = 19
+	socialButterfly   models.AchievementID = 20
+	doYouEvenWorkHere models.AchievementID = 22
+	dedicated         models.AchievementID = 24
+	addicted          models.AchievementID = 25
+	hostileTakeover   models.AchievementID = 26
+	bigShot           models.AchievementID = 28
+	titleCharge       models.AchievementID = 29
+)
+
 // -------------------------------------------------------------------------------- Base Tests
 
-func TestCalculatePlayersAchievementsReturnsPlayOne(t *testing.T) {
+func TestEarnGameAchievementsReturnsPlayOne(t *testing.T) {
 	playerGames := []models.Game{
 		{
 			ID:          1,
@@ -77,22 +82,10 @@ func TestCalculatePlayersAchievementsReturnsPlayOne(t *testing.T) {
 			CreatedAt:   time.Date(2023, 1, 2, 10, 0, 0, 0, TZ),
 		},
 	}
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings, newRating := createStandardEloRatings(lastGame)
-
-	achievements, err := calculatePlayersAchievements(
-		player.ID,
-		playerGames,
-		gameToGameResult(lastGame),
-		oldRatings,
-		newRating,
-	)
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
 
-	if !containsAchievement(achievements, PLAY_1) {
-		t.Errorf("expected achievement PLAY_1, but it was not found")
+	if !containsAchievement(achievements, warmingUp) {
+		t.Errorf("expected achievement Warming Up, but it was not found")
 	}
 }
 
@@ -111,16 +104,10 @@ func TestWin15Consecutive(t *testing.T) {
 		})
 	}
 
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings, newRating := createStandardEloRatings(lastGame)
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
 
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	if !containsAchievement(achievements, WIN_15_CONSECUTIVE) {
-		t.Errorf("expected achievement WIN_15_CONSECUTIVE, but it was not found")
+	if !containsAchievement(achievements, immortal) {
+		t.Errorf("expected achievement Immortal, but it was not found")
 	}
 }
 
@@ -137,16 +124,10 @@ func TestPlay500(t *testing.T) {
 		})
 	}
 
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings, newRating := createStandardEloRatings(lastGame)
-
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
 
-	if !containsAchievement(achievements, PLAY_500) {
-		t.Errorf("expected achievement PLAY_500, but it was not found")
+	if !containsAchievement(achievements, unicorn) {
+		t.Errorf("expected achievement Unicorn, but it was not found")
 	}
 }
 
@@ -164,20 +145,14 @@ func TestDailyWin5ConsecutiveAgainstSameOpponent(t *testing.T) {
 		})
 	}
 
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings, newRating := createStandardEloRatings(lastGame)
-
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
 
-	if !containsAchievement(achievements, DAILY_WIN_3_CONSECUTIVE_AGAINST_SAME_OPPONENT) {
-		t.Errorf("expected achievement DAILY_WIN_3_CONSECUTIVE_AGAINST_SAME_OPPONENT, but it was not found")
+	if !containsAchievement(achievements, hatTrick) {
+		t.Errorf("expected achievement Hat Trick, but it was not found")
 	}
 
-	if !containsAchievement(achievements, DAILY_WIN_5_CONSECUTIVE_AGAINST_SAME_OPPONENT) {
-		t.Errorf("expected achievement DAILY_WIN_5_CONSECUTIVE_AGAINST_SAME_OPPONENT, but it was not found")
+	if !containsAchievement(achievements, brutal) {
+		t.Errorf("expected achievement Brutal, but it was not found")
 	}
 }
 
@@ -201,16 +176,10 @@ func TestPlayOpponent25(t *testing.T) {
 		})
 	}
 
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings, newRating := createStandardEloRatings(lastGame)
-
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
 
-	if !containsAchievement(achievements, PLAY_OPPONENT_25) {
-		t.Errorf("expected achievement PLAY_OPPONENT_25, but it was not found")
+	if !containsAchievement(achievements, rivalry) {
+		t.Errorf("expected achievement Rivalry, but it was not found")
 	}
 }
 
@@ -228,16 +197,10 @@ func TestPlay5Opponents(t *testing.T) {
 		})
 	}
 
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings, newRating := createStandardEloRatings(lastGame)
-
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
 
-	if !containsAchievement(achievements, PLAY_5_OPPONENTS) {
-		t.Errorf("expected achievement PLAY_5_OPPONENTS, but it was not found")
+	if !containsAchievement(achievements, socialButterfly) {
+		t.Errorf("expected achievement Social Butterfly, but it was not found")
 	}
 }
 
@@ -255,68 +218,66 @@ func TestPlay10Day(t *testing.T) {
 		})
 	}
 
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings, newRating := createStandardEloRatings(lastGame)
-
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
 
-	if !containsAchievement(achievements, PLAY_10_DAY) {
-		t.Errorf("expected achievement PLAY_10_DAY, but it was not found")
+	if !containsAchievement(achievements, doYouEvenWorkHere) {
+		t.Errorf("expected achievement Do You Even Work Here?, but it was not found")
 	}
 }
 
 func TestWinUpset100Elo(t *testing.T) {
+	playedAt := time.Date(2023, 6, 1, 10, 0, 0, 0, TZ)
 	playerGames := []models.Game{
 		{
 			ID:          1,
+			Type:        models.SINGLES,
 			Winner:      player, // The underdog winner
 			Loser:       opponent,
 			WinnerScore: intPointer(12),
 			LoserScore:  intPointer(10),
-			CreatedAt:   time.Date(2023, 6, 1, 10, 0, 0, 0, TZ),
+			CreatedAt:   playedAt,
 		},
 	}
-	lastGame := playerGames[len(playerGames)-1]
-	// Player ELO is 100 less than opponent's ELO
-	oldRatings := models.EloRatings{player.ID: 1000, opponent.ID: 1100}
-	newRating := models.EloRatings{player.ID: 1025, opponent.ID: 1075}
-
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	// Player ELO is 100 less than opponent's ELO going into the game
+	changes := []models.RatingChange{
+		{PlayerID: player.ID, GameType: models.SINGLES, GameID: 1, RatingBefore: 1000, RatingAfter: 1025, PlayedAt: playedAt},
+		{PlayerID: opponent.ID, GameType: models.SINGLES, GameID: 1, RatingBefore: 1100, RatingAfter: 1075, PlayedAt: playedAt},
 	}
 
-	if !containsAchievement(achievements, WIN_UPSET_100_ELO) {
-		t.Errorf("expected achievement WIN_UPSET_100_ELO, but it was not found")
+	achievements, _ := earnPlayerAchievements(player.ID, playerGames, changes[:1], indexRatingChanges(changes))
+
+	if !achievements[hostileTakeover].Equal(playedAt) {
+		t.Errorf("expected achievement Hostile Takeover at %v, got %v", playedAt, achievements[hostileTakeover])
 	}
 }
 
 func TestEloReach1200(t *testing.T) {
-	playerGames := []models.Game{
-		{
-			ID:          1,
+	var playerGames []models.Game
+	var changes []models.RatingChange
+	startTime := time.Date(2023, 7, 1, 10, 0, 0, 0, TZ)
+	// Player's ELO crosses the 1200 threshold in the second game, then falls back below it
+	for i, rating := range []float64{1190, 1206, 1188} {
+		playerGames = append(playerGames, models.Game{
+			ID:          i + 1,
+			Type:        models.SINGLES,
 			Winner:      player,
 			Loser:       opponent,
 			WinnerScore: intPointer(11),
 			LoserScore:  intPointer(5),
-			CreatedAt:   time.Date(2023, 7, 1, 10, 0, 0, 0, TZ),
-		},
+			CreatedAt:   startTime.Add(time.Hour * time.Duration(i)),
+		})
+		changes = append(changes, models.RatingChange{
+			PlayerID: player.ID, GameType: models.SINGLES, GameID: i + 1, RatingAfter: rating, PlayedAt: startTime.Add(time.Hour * time.Duration(i)),
+		})
 	}
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings := models.EloRatings{player.ID: 1190, opponent.ID: 1050}
-	// Player's new ELO crosses the 1200 threshold
-	newRating := models.EloRatings{player.ID: 1206, opponent.ID: 1034}
 
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	achievements, _ := earnPlayerAchievements(player.ID, playerGames, changes, indexRatingChanges(changes))
 
-	if !containsAchievement(achievements, ELO_REACH_1200) {
-		t.Errorf("expected achievement ELO_REACH_1200, but it was not found")
+	if !achievements[bigShot].Equal(startTime.Add(time.Hour)) {
+		t.Errorf("expected achievement Big Shot at %v, got %v", startTime.Add(time.Hour), achievements[bigShot])
+	}
+	if _, ok := achievements[titleCharge]; ok {
+		t.Errorf("expected no achievement Title Charge")
 	}
 }
 
@@ -335,15 +296,155 @@ func TestPlay5DayStreak(t *testing.T) {
 		})
 	}
 
-	lastGame := playerGames[len(playerGames)-1]
-	oldRatings, newRating := createStandardEloRatings(lastGame)
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
 
-	achievements, err := calculatePlayersAchievements(player.ID, playerGames, gameToGameResult(lastGame), oldRatings, newRating)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	if !containsAchievement(achievements, addicted) {
+		t.Errorf("expected achievement Addicted, but it was not found")
 	}
+}
 
-	if !containsAchievement(achievements, PLAY_5_DAY_STREAK) {
-		t.Errorf("expected achievement PLAY_5_DAY_STREAK, but it was not found")
+func TestEarnGameAchievementsDatesEachAchievement(t *testing.T) {
+	var playerGames []models.Game
+	startTime := time.Date(2023, 8, 1, 12, 0, 0, 0, TZ)
+	for i := range 10 {
+		playerGames = append(playerGames, models.Game{
+			ID:          i + 1,
+			Winner:      player,
+			Loser:       opponent,
+			WinnerScore: intPointer(11),
+			LoserScore:  intPointer(i),
+			// Each game is on a consecutive day
+			CreatedAt: startTime.AddDate(0, 0, i),
+		})
+	}
+	// stores return a player's games newest first
+	slices.Reverse(playerGames)
+
+	achievements, _ := earnGameAchievements(player.ID, playerGames)
+
+	expected := map[models.AchievementID]time.Time{
+		warmingUp:         startTime,
+		chocolate:         startTime,
+		bottleJob:         startTime.AddDate(0, 0, 1),
+		dedicated:         startTime.AddDate(0, 0, 2),
+		streaky:           startTime.AddDate(0, 0, 4),
+		minimumViablePong: startTime.AddDate(0, 0, 9),
+	}
+	for id, at := range expected {
+		if !achievements[id].Equal(at) {
+			t.Errorf("expected achievement %v to be earned at %v, got %v", id, at, achievements[id])
+		}
+	}
+}
+
+func TestAchievementProgress(t *testing.T) {
+	var playerGames []models.Game
+	var changes []models.RatingChange
+	startTime := time.Date(2023, 10, 2, 12, 0, 0, 0, TZ)
+	for i := range 47 {
+		playedAt := startTime.AddDate(0, 0, i)
+		playerGames = append(playerGames, models.Game{
+			ID:          i + 1,
+			Type:        models.SINGLES,
+			Winner:      player,
+			Loser:       opponent,
+			WinnerScore: intPointer(11),
+			LoserScore:  intPointer(5),
+			CreatedAt:   playedAt,
+		})
+		// the player peaks at 1150, three quarters of the way to Big Shot
+		changes = append(changes, models.RatingChange{
+			PlayerID: player.ID, GameType: models.SINGLES, GameID: i + 1, RatingAfter: 1150 - float64(i), PlayedAt: playedAt,
+		})
+	}
+
+	earned, values := earnPlayerAchievements(player.ID, playerGames, changes, indexRatingChanges(changes))
+	progress := make(map[models.AchievementID]models.AchievementProgress)
+	for _, p := range achievementProgress(earned, values, 1000) {
+		progress[models.AchievementID(p.ID)] = p
+	}
+
+	if p := progress[warmingUp]; !p.Unlocked || !p.EarnedAt.Equal(startTime) || *p.Percentage != 100 {
+		t.Errorf("expected Warming Up to be unlocked by the first game, got %+v", p)
+	}
+	if p := progress[regular]; p.Unlocked || *p.Current != 47 || *p.Target != 50 || *p.Percentage != 94 {
+		t.Errorf("expected Regular to be 47 of 50 games, got %+v", p)
+	}
+	if p := progress[bigShot]; p.Unlocked || *p.Current != 1150 || *p.Target != 1200 || *p.Percentage != 75 {
+		t.Errorf("expected Big Shot to be 75%% of the way from 1000 to 1200, got %+v", p)
+	}
+	if p := progress[chocolate]; p.Unlocked || p.Current != nil || p.Percentage != nil {
+		t.Errorf("expected Chocolate to be locked without progress, got %+v", p)
+	}
+}
+
+func TestGroupAchievementFamilies(t *testing.T) {
+	achievement := func(id models.AchievementID, family string, tier int, unlocked bool) models.AchievementProgress {
+		return models.AchievementProgress{
+			Achievement: models.Achievement{ID: int(id), Family: family, Tier: tier},
+			Unlocked:    unlocked,
+		}
+	}
+	families := GroupAchievementFamilies([]models.AchievementProgress{
+		achievement(regular, "games", 3, false),
+		achievement(warmingUp, "games", 1, true),
+		achievement(chocolate, "", 0, true),
+		achievement(minimumViablePong, "games", 2, true),
+		achievement(streaky, "winStreak", 1, true),
+		achievement(immortal, "winStreak", 2, true),
+		achievement(bigShot, "rating", 1, false),
+	})
+
+	if len(families) != 3 {
+		t.Fatalf("expected 3 families, got %+v", families)
+	}
+	if f := families[0]; f.Family != "games" || f.Tier != 2 || f.Tiers != 3 || f.Highest.ID != int(minimumViablePong) || f.Next.ID != int(regular) {
+		t.Errorf("expected games to be at tier 2 of 3 working towards Regular, got %+v", f)
+	}
+	if f := families[1]; f.Family != "winStreak" || f.Tier != 2 || f.Highest.ID != int(immortal) || f.Next != nil {
+		t.Errorf("expected every winStreak tier to be unlocked, got %+v", f)
+	}
+	if f := families[2]; f.Family != "rating" || f.Tier != 0 || f.Highest != nil || f.Next.ID != int(bigShot) {
+		t.Errorf("expected no rating tier to be unlocked, got %+v", f)
+	}
+}
+
+func TestReadAchievementsReportsEveryProblem(t *testing.T) {
+	file := `[
+  {"id": 1, "title": "Warming Up", "rule": {"type": "gameCount", "count": 1}},
+  {"id": 1, "title": "", "rule": {"type": "winCount"}},
+  {"id": 2, "title": "Night Owl", "rule": {"type": "timeOfDay", "fromHour": 22, "toHour": 24}},
+  {"id": 3, "title": "Lucky", "rule": {"type": "luck"}},
+  {"id": 4, "title": "Regular", "family": "games", "tier": 1, "points": -5, "rule": {"type": "gameCount", "count": 50}},
+  {"id": 5, "title": "Centurion", "family": "games", "tier": 1, "rule": {"type": "gameCount", "count": 100}},
+  {"id": 6, "title": "Clutch", "tier": 2, "rule": {"type": "score", "winnerScore": 12, "loserScore": 10}}
+]`
+	_, err := ReadAchievements(strings.NewReader(file))
+
+	var validationErr *exceptions.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	expected := []string{"achievements[1].id", "achievements[1].title", "achievements[1].rule.count", "achievements[2].rule", "achievements[3].rule.type", "achievements[4].points", "achievements[5].tier", "achievements[6].tier"}
+	if len(validationErr.Fields) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), validationErr.Fields)
+	}
+	for i, field := range expected {
+		if validationErr.Fields[i].Field != field {
+			t.Errorf("expected error %d to be for %v, got %v", i, field, validationErr.Fields[i].Field)
+		}
+	}
+}
+
+func TestTimeOfDayRuleWrapsPastMidnight(t *testing.T) {
+	rule := AchievementRule{Type: RULE_TIME_OF_DAY, FromHour: 18, ToHour: 9}
+	record := &playerRecord{}
+
+	for hour, expected := range map[int]bool{8: true, 9: false, 17: false, 18: true, 23: true} {
+		game := models.Game{Winner: player, Loser: opponent, CreatedAt: time.Date(2023, 9, 1, hour, 30, 0, 0, TZ)}
+		if rule.earnedBy(record, player.ID, game) != expected {
+			t.Errorf("expected a game at %d:30 to meet the rule: %v", hour, expected)
+		}
 	}
 }
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// CreateArchive exports the whole record from a store.
+func CreateArchive(s models.Store) (models.Archive, error) {
+	a, err := s.ExportArchive()
+	if err != nil {
+		return a, err
+	}
+	a.Format = models.ARCHIVE_FORMAT
+	a.Version = models.ARCHIVE_VERSION
+	a.ExportedAt = time.Now().UTC()
+	return a, nil
+}
+
+// ReadArchive decodes an archive, checking that it is one this build can restore.
+func ReadArchive(r io.Reader) (models.Archive, error) {
+	var a models.Archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return a, fmt.Errorf("error reading archive: %v", err)
+	}
+	if a.Format != models.ARCHIVE_FORMAT {
+		return a, fmt.Errorf("not a %v file", models.ARCHIVE_FORMAT)
+	}
+	if a.Version < 1 || a.Version > models.ARCHIVE_VERSION {
+		return a, fmt.Errorf("unsupported archive version %d: expected 1 to %d", a.Version, models.ARCHIVE_VERSION)
+	}
+
+	// doubles games had no confirmation before version 2, so every one of them counted
+	if a.Version < 2 {
+		for i := range a.DoublesGames {
+			a.DoublesGames[i].Status = models.CONFIRMED
+		}
+	}
+
+	// games had no submission time before version 3, so they are taken as recorded when
+	// they were played
+	if a.Version < 3 {
+		for i := range a.Games {
+			a.Games[i].SubmittedAt = a.Games[i].CreatedAt
+		}
+		for i := range a.DoublesGames {
+			a.DoublesGames[i].SubmittedAt = a.DoublesGames[i].CreatedAt
+		}
+	}
+	return a, nil
+}
+
+// ValidateArchive checks that everything an archive refers to is in it, or, for the
+// achievements, in the catalogue of the store it is restored into.
+func ValidateArchive(a models.Archive, achievements []models.Achievement) error {
+	errs := &exceptions.ValidationError{}
+
+	players := make(map[int]bool)
+	names := make(map[string]bool)
+	for i, p := range a.Players {
+		field := fmt.Sprintf("players[%d]", i)
+		if players[p.ID] {
+			errs.Add(field+".id", fmt.Sprintf("Player %v appears more than once", p.ID))
+		}
+		if p.Name == "" || names[strings.ToLower(p.Name)] {
+			errs.Add(field+".name", fmt.Sprintf("'%v' is empty or not unique", p.Name))
+		}
+		players[p.ID] = true
+		names[strings.ToLower(p.Name)] = true
+	}
+
+	checkPlayers := func(field string, ids ...int) {
+		for _, id := range ids {
+			if !players[id] {
+				errs.Add(field, fmt.Sprintf("Player %v is not in the archive", id))
+			}
+		}
+	}
+
+	games := make(map[int]bool)
+	for i, g := range a.Games {
+		field := fmt.Sprintf("games[%d]", i)
+		if games[g.ID] {
+			errs.Add(field+".id", fmt.Sprintf("Game %v appears more than once", g.ID))
+		}
+		games[g.ID] = true
+
+		checkPlayers(field, g.WinnerID, g.LoserID)
+		if g.SubmittedBy != nil {
+			checkPlayers(field+".submittedBy", *g.SubmittedBy)
+		}
+		if !slices.Contains([]models.GameStatus{models.CONFIRMED, models.PENDING, models.DISPUTED}, g.Status) {
+			errs.Add(field+".status", fmt.Sprintf("unknown status '%v'", g.Status))
+		}
+	}
+
+	doublesGames := make(map[int]bool)
+	for i, g := range a.DoublesGames {
+		field := fmt.Sprintf("doublesGames[%d]", i)
+		if doublesGames[g.ID] {
+			errs.Add(field+".id", fmt.Sprintf("Doubles Game %v appears more than once", g.ID))
+		}
+		doublesGames[g.ID] = true
+
+		checkPlayers(field, g.WinnerIDs[0], g.WinnerIDs[1], g.LoserIDs[0], g.LoserIDs[1])
+		if g.SubmittedBy != nil {
+			checkPlayers(field+".submittedBy", *g.SubmittedBy)
+		}
+		if !slices.Contains([]models.GameStatus{models.CONFIRMED, models.PENDING, models.DISPUTED}, g.Status) {
+			errs.Add(field+".status", fmt.Sprintf("unknown status '%v'", g.Status))
+		}
+	}
+
+	for i, pa := range a.PlayerAchievements {
+		field := fmt.Sprintf("playerAchievements[%d]", i)
+		checkPlayers(field, pa.PlayerID)
+		if !slices.ContainsFunc(achievements, func(a models.Achievement) bool { return a.ID == pa.AchievementID }) {
+			errs.Add(field, fmt.Sprintf("Achievement %v does not exist", pa.AchievementID))
+		}
+	}
+
+	return errs.ErrOrNil()
+}
+
+// RestoreArchive loads an archive into a store without players, then replays every
+// rating from the restored games.
+func RestoreArchive(s models.Store, cfg config.Config, a models.Archive) error {
+	achievements, err := s.GetAchievements()
+	if err != nil {
+		return err
+	}
+	if err := ValidateArchive(a, achievements); err != nil {
+		return err
+	}
+
+	err = s.RestoreArchive(a, cfg.Ratings.Elo.StartRating)
+	if err != nil {
+		return err
+	}
+
+	return RecalculateRatings(s, cfg)
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+func TestReadArchiveChecksVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		valid bool
+	}{
+		{"current version", `{"format": "luinc-pong-archive", "version": 3}`, true},
+		{"earlier version", `{"format": "luinc-pong-archive", "version": 1}`, true},
+		{"newer version", `{"format": "luinc-pong-archive", "version": 4}`, false},
+		{"another format", `{"format": "something-else", "version": 1}`, false},
+		{"not JSON", `winner,loser`, false},
+	}
+
+	for _, test := range tests {
+		_, err := ReadArchive(strings.NewReader(test.file))
+		if test.valid && err != nil {
+			t.Errorf("%v: expected the archive to be read, got %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%v: expected the archive to be rejected", test.name)
+		}
+	}
+}
+
+func TestReadArchiveConfirmsVersion1DoublesGames(t *testing.T) {
+	file := `{"format": "luinc-pong-archive", "version": 1, "doublesGames": [{"id": 1, "winnerIds": [1, 2], "loserIds": [3, 4]}]}`
+	a, err := ReadArchive(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.DoublesGames[0].Status != models.CONFIRMED {
+		t.Errorf("expected a doubles game from before confirmation to be confirmed, got '%v'", a.DoublesGames[0].Status)
+	}
+}
+
+func TestReadArchiveDatesVersion2Submissions(t *testing.T) {
+	file := `{"format": "luinc-pong-archive", "version": 2, "games": [{"id": 1, "winnerId": 1, "loserId": 2, "createdAt": "2024-03-01T12:00:00Z"}]}`
+	a, err := ReadArchive(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !a.Games[0].SubmittedAt.Equal(a.Games[0].CreatedAt) {
+		t.Errorf("expected a game from before submission times to be submitted when played, got %v", a.Games[0].SubmittedAt)
+	}
+}
+
+func TestValidateArchive(t *testing.T) {
+	archive := models.Archive{
+		Players: []models.ArchivePlayer{{ID: 1, Name: "Alice"}, {ID: 2, Name: "alice"}},
+		Games: []models.ArchiveGame{
+			{ID: 1, WinnerID: 1, LoserID: 3, Status: models.CONFIRMED},
+			{ID: 2, WinnerID: 1, LoserID: 2, Status: "lost"},
+		},
+		DoublesGames: []models.ArchiveDoublesGame{
+			{ID: 1, WinnerIDs: [2]int{1, 2}, LoserIDs: [2]int{1, 2}, Status: models.PENDING, SubmittedBy: intPointer(5)},
+		},
+		PlayerAchievements: []models.PlayerAchievement{{PlayerID: 1, AchievementID: 999}},
+	}
+
+	err := ValidateArchive(archive, ACHIEVEMENTS)
+
+	var validationErr *exceptions.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	expected := []string{"players[1].name", "games[0]", "games[1].status", "doublesGames[0].submittedBy", "playerAchievements[0]"}
+	if len(validationErr.Fields) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), validationErr.Fields)
+	}
+	for i, field := range expected {
+		if validationErr.Fields[i].Field != field {
+			t.Errorf("expected error %d to be for %v, got %v", i, field, validationErr.Fields[i].Field)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+var auditActions = []models.AuditAction{
+	models.AUDIT_CREATE,
+	models.AUDIT_CONFIRM,
+	models.AUDIT_DISPUTE,
+	models.AUDIT_UPDATE,
+	models.AUDIT_DELETE,
+	models.AUDIT_RESTORE,
+	models.AUDIT_RECALCULATE,
+	models.AUDIT_IMPORT,
+}
+
+// ParseAuditLogFilter checks the query parameters of the audit log. An empty parameter
+// leaves that filter unset.
+func ParseAuditLogFilter(action string, gameType string, gameID string) (models.AuditLogFilter, error) {
+	var filter models.AuditLogFilter
+	errs := &exceptions.ValidationError{}
+
+	if action != "" {
+		if a := models.AuditAction(action); slices.Contains(auditActions, a) {
+			filter.Action = &a
+		} else {
+			errs.Add("action", "must be one of create, confirm, dispute, update, delete, restore, recalculate or import")
+		}
+	}
+
+	if gameType != "" {
+		if t := models.GameType(gameType); t == models.SINGLES || t == models.DOUBLES {
+			filter.GameType = &t
+		} else {
+			errs.Add("gameType", "must be singles or doubles")
+		}
+	}
+
+	if gameID != "" {
+		if id, err := strconv.Atoi(gameID); err == nil && id > 0 {
+			filter.GameID = &id
+		} else {
+			errs.Add("gameId", "must be a positive integer")
+		}
+	}
+
+	return filter, errs.ErrOrNil()
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+func TestParseAuditLogFilter(t *testing.T) {
+	filter, err := ParseAuditLogFilter("", "", "")
+	if err != nil || filter.Action != nil || filter.GameType != nil || filter.GameID != nil {
+		t.Errorf("expected empty parameters to match everything, got %+v, %v", filter, err)
+	}
+
+	filter, err = ParseAuditLogFilter("delete", "doubles", "7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *filter.Action != models.AUDIT_DELETE || *filter.GameType != models.DOUBLES || *filter.GameID != 7 {
+		t.Errorf("expected deletes of doubles game 7, got %+v", filter)
+	}
+
+	_, err = ParseAuditLogFilter("edit", "triples", "-1")
+	var validationErr *exceptions.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 3 {
+		t.Errorf("expected an error for each parameter, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// CalculateTeamRating returns the strength of a doubles team: the mean of the partners' ratings.
+func CalculateTeamRating(ratings models.EloRatings, ids [2]int) float64 {
+	return (ratings[ids[0]] + ratings[ids[1]]) / 2
+}
+
+// CalculateDoublesRatings computes the new ratings of the four players in a doubles game.
+//
+// The winning team's Elo delta is worked out from the team ratings as it would be for a
+// singles game. Each team then shares twice that delta between its partners, so a pair of
+// equally rated partners each move by exactly the singles amount. Uneven pairs split the
+// delta by rating: the weaker partner takes the larger share of a win and the stronger
+// partner the larger share of a loss.
+func CalculateDoublesRatings(ratings models.EloRatings, winnerIDs [2]int, loserIDs [2]int, k float64) models.EloRatings {
+	winnerTeam := CalculateTeamRating(ratings, winnerIDs)
+	loserTeam := CalculateTeamRating(ratings, loserIDs)
+
+	delta := k * (1 - CalculateExpectedScore(winnerTeam, loserTeam))
+
+	newRatings := make(models.EloRatings)
+	for i, id := range winnerIDs {
+		partner := ratings[winnerIDs[1-i]]
+		share := partner / (ratings[id] + partner)
+		newRatings[id] = ratings[id] + 2*delta*share
+	}
+	for _, id := range loserIDs {
+		share := ratings[id] / (ratings[loserIDs[0]] + ratings[loserIDs[1]])
+		newRatings[id] = ratings[id] - 2*delta*share
+	}
+	return newRatings
+}
+
+// UpdatePlayersDoublesEloRating applies a doubles result to the players' doubles ratings and
+// doubles rating history, and to their singles ratings and rating history as well when the
+// configuration asks for it.
+func UpdatePlayersDoublesEloRating(s models.Store, cfg config.Config, id int, r models.DoublesGameResult) error {
+	winnerIDs := [2]int{r.WinnerIDs[0], r.WinnerIDs[1]}
+	loserIDs := [2]int{r.LoserIDs[0], r.LoserIDs[1]}
+
+	ratings, err := s.GetPlayerDoublesEloRatings([4]int{winnerIDs[0], winnerIDs[1], loserIDs[0], loserIDs[1]})
+	if err != nil {
+		return err
+	}
+
+	newDoublesRatings := CalculateDoublesRatings(ratings, winnerIDs, loserIDs, cfg.Ratings.Elo.K)
+	err = s.UpdateDoublesEloRatings(newDoublesRatings)
+	if err != nil {
+		return err
+	}
+
+	err = s.InsertDoublesRatingHistory(ratingChanges(models.DOUBLES, id, ratings, newDoublesRatings))
+	if err != nil {
+		return err
+	}
+
+	if !cfg.Doubles.AffectsSingles {
+		return nil
+	}
+
+	winnerRatings, err := s.GetPlayerEloRatings(winnerIDs)
+	if err != nil {
+		return err
+	}
+	loserRatings, err := s.GetPlayerEloRatings(loserIDs)
+	if err != nil {
+		return err
+	}
+	for id, rating := range loserRatings {
+		winnerRatings[id] = rating
+	}
+
+	newRatings := CalculateDoublesRatings(winnerRatings, winnerIDs, loserIDs, cfg.Ratings.Elo.K)
+	err = s.UpdateEloRatings(newRatings)
+	if err != nil {
+		return err
+	}
+
+	return s.InsertRatingHistory(ratingChanges(models.DOUBLES, id, winnerRatings, newRatings))
+}
+
+// RecalculateDoublesEloRatings replays every doubles game from the configured starting rating.
+func RecalculateDoublesEloRatings(s models.Store, cfg config.Config) error {
+	players, err := s.GetPlayerBasicInfo()
+	if err != nil {
+		return err
+	}
+
+	ratingMap := make(models.EloRatings)
+	for _, player := range players {
+		ratingMap[player.ID] = cfg.Ratings.Elo.StartRating
+	}
+
+	games, err := s.GetDoublesGameResults()
+	if err != nil {
+		return err
+	}
+
+	history := make([]models.RatingChange, 0)
+	for _, game := range games {
+		newRatings := CalculateDoublesRatings(ratingMap, game.WinnerIDs, game.LoserIDs, cfg.Ratings.Elo.K)
+		before := make(models.EloRatings)
+		for id, rating := range newRatings {
+			before[id] = ratingMap[id]
+			ratingMap[id] = rating
+		}
+		history = append(history, ratingChanges(models.DOUBLES, game.ID, before, newRatings)...)
+	}
+
+	err = s.UpdateDoublesEloRatings(ratingMap)
+	if err != nil {
+		return err
+	}
+
+	return s.ReplaceDoublesRatingHistory(history)
+}
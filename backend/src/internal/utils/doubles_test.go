@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+func TestCalculateDoublesRatingsEqualPartners(t *testing.T) {
+	ratings := models.EloRatings{1: 1000, 2: 1000, 3: 1000, 4: 1000}
+
+	newRatings := CalculateDoublesRatings(ratings, [2]int{1, 2}, [2]int{3, 4}, 40)
+
+	// equally rated partners each move by the singles amount
+	for id, expected := range map[int]float64{1: 1020, 2: 1020, 3: 980, 4: 980} {
+		if math.Abs(newRatings[id]-expected) > 0.00001 {
+			t.Errorf("expected player %d to have a rating of %v, got %v", id, expected, newRatings[id])
+		}
+	}
+}
+
+func TestCalculateDoublesRatingsUnevenPartners(t *testing.T) {
+	ratings := models.EloRatings{1: 1200, 2: 800, 3: 1100, 4: 900}
+
+	newRatings := CalculateDoublesRatings(ratings, [2]int{1, 2}, [2]int{3, 4}, 40)
+
+	winnerGain := newRatings[1] - ratings[1] + newRatings[2] - ratings[2]
+	loserLoss := ratings[3] - newRatings[3] + ratings[4] - newRatings[4]
+	if math.Abs(winnerGain-loserLoss) > 0.00001 {
+		t.Errorf("expected the points won to equal the points lost, got %v and %v", winnerGain, loserLoss)
+	}
+
+	// the weaker winner gains more and the stronger loser loses more
+	if newRatings[2]-ratings[2] <= newRatings[1]-ratings[1] {
+		t.Errorf("expected the weaker winner to gain more than their partner")
+	}
+	if ratings[3]-newRatings[3] <= ratings[4]-newRatings[4] {
+		t.Errorf("expected the stronger loser to lose more than their partner")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"time"
 
+	"github.com/jda5/luinc-pong/src/internal/config"
 	"github.com/jda5/luinc-pong/src/internal/models"
 )
 
@@ -21,9 +22,108 @@ func CalculateNewRating(playerRating float64, opponentRating float64, score int,
 	return playerRating + float64(k)*(float64(score)-expectedScore)
 }
 
-func RecalculateEloRatings(s models.Store) error {
+// gameOutcome is a single result that moves two players' ratings. Margin is the points
+// margin of the win, or zero when the score was not recorded.
+type gameOutcome struct {
+	WinnerID int
+	LoserID  int
+	Margin   int
+}
+
+// EloK returns the K-factor for a player who had played gamesPlayed singles games before
+// this one.
+func EloK(cfg config.EloConfig, gamesPlayed int, rating float64) float64 {
+	if gamesPlayed < cfg.ProvisionalGames {
+		return cfg.ProvisionalK
+	}
+	if cfg.HighRatingThreshold > 0 && rating >= cfg.HighRatingThreshold {
+		return cfg.HighRatingK
+	}
+	return cfg.K
+}
+
+// MarginOfVictoryMultiplier scales the K-factor by how convincing a win was, following
+// FiveThirtyEight's Elo models: the multiplier grows with the log of the points margin and
+// is damped when the favourite wins, so that strong players' ratings do not inflate.
+func MarginOfVictoryMultiplier(margin int, winnerRating float64, loserRating float64) float64 {
+	return math.Log(float64(margin)+1) * 2.2 / ((winnerRating-loserRating)*0.001 + 2.2)
+}
+
+// rateGame applies a singles game or match to the rating map. gamesPlayed holds the number
+// of singles games each player had played before this one.
+func rateGame(ratingMap models.EloRatings, gamesPlayed map[int]int, cfg config.Config, game models.BaseGame) {
+	elo := cfg.Ratings.Elo
+
+	for _, o := range gameOutcomes(cfg, game) {
+		winnerRating := ratingMap[o.WinnerID]
+		loserRating := ratingMap[o.LoserID]
+
+		multiplier := 1.0
+		if elo.MarginOfVictory && o.Margin > 0 {
+			multiplier = MarginOfVictoryMultiplier(o.Margin, winnerRating, loserRating)
+		}
+
+		// the loser's expected score is one minus the winner's
+		expected := CalculateExpectedScore(winnerRating, loserRating)
+		ratingMap[o.WinnerID] = winnerRating + multiplier*EloK(elo, gamesPlayed[o.WinnerID], winnerRating)*(1-expected)
+		ratingMap[o.LoserID] = loserRating - multiplier*EloK(elo, gamesPlayed[o.LoserID], loserRating)*(1-expected)
+	}
+}
+
+// previousGameCounts returns how many singles games each player had played before the one
+// that has just been recorded. Only the provisional period depends on it, so the count
+// stops once a player is past it.
+func previousGameCounts(s models.Store, cfg config.Config, ids [2]int) (map[int]int, error) {
+	counts := make(map[int]int)
+	if cfg.Ratings.Elo.ProvisionalGames == 0 {
+		return counts, nil
+	}
+
+	for _, id := range ids {
+		games, err := s.GetPlayerGames(id, cfg.Ratings.Elo.ProvisionalGames+1)
+		if err != nil {
+			return counts, err
+		}
+		counts[id] = max(len(games)-1, 0)
+	}
+	return counts, nil
+}
+
+// ratingChanges lists how a game moved each of its players' ratings. before holds the
+// ratings of the game's players only.
+func ratingChanges(gameType models.GameType, gameID int, before models.EloRatings, after models.EloRatings) []models.RatingChange {
+	changes := make([]models.RatingChange, 0, len(before))
+	for id, rating := range before {
+		changes = append(changes, models.RatingChange{
+			PlayerID: id, GameType: gameType, GameID: gameID, RatingBefore: rating, RatingAfter: after[id],
+		})
+	}
+	return changes
+}
+
+// RecalculateRatings replays every singles and doubles game, bringing every Elo and
+// Glicko-2 rating up to date.
+func RecalculateRatings(s models.Store, cfg config.Config) error {
+	err := RecalculateEloRatings(s, cfg)
+	if err != nil {
+		return err
+	}
+
+	err = RecalculateDoublesEloRatings(s, cfg)
+	if err != nil {
+		return err
+	}
+
+	return RecalculateGlickoRatings(s, cfg)
+}
+
+// RecalculateEloRatings replays every singles game from the configured starting rating.
+// When doubles games are configured to affect singles ratings they are replayed in the
+// same chronological order. Best-of-N matches are rated as configured by cfg.Matches.
+func RecalculateEloRatings(s models.Store, cfg config.Config) error {
+	start := cfg.Ratings.Elo.StartRating
 
-	// initialize all player ratings to 1000
+	// initialize all player ratings to the starting rating
 	players, err := s.GetPlayerBasicInfo()
 	if err != nil {
 		return err
@@ -32,10 +132,12 @@ func RecalculateEloRatings(s models.Store) error {
 	ratingMap := make(map[int]float64)
 	highestRatingMap := make(map[int]float64)
 	lastPlayedMap := make(map[int]time.Time)
+	gamesPlayed := make(map[int]int)
+	history := make([]models.RatingChange, 0)
 
 	for _, player := range players {
-		ratingMap[player.ID] = 1000
-		highestRatingMap[player.ID] = 1000
+		ratingMap[player.ID] = start
+		highestRatingMap[player.ID] = start
 		lastPlayedMap[player.ID] = player.CreatedAt
 	}
 
@@ -45,32 +147,57 @@ func RecalculateEloRatings(s models.Store) error {
 		return err
 	}
 
-	for _, game := range games {
-		// Get current ratings (default to 1000 if new player)
-		winnerRating, ok := ratingMap[game.WinnerID]
-		if !ok {
-			winnerRating = 1000
+	doublesGames := make([]models.BaseDoublesGame, 0)
+	if cfg.Doubles.AffectsSingles {
+		doublesGames, err = s.GetDoublesGameResults()
+		if err != nil {
+			return err
 		}
-		loserRating, ok := ratingMap[game.LoserID]
-		if !ok {
-			loserRating = 1000
+	}
+
+	// track the highest Elo achieved and the last played time
+	record := func(id int, playedAt time.Time) {
+		if ratingMap[id] > highestRatingMap[id] {
+			highestRatingMap[id] = ratingMap[id]
 		}
+		lastPlayedMap[id] = playedAt
+	}
 
-		// Calculate and store new ratings
-		ratingMap[game.WinnerID] = CalculateNewRating(winnerRating, loserRating, 1, 40)
-		ratingMap[game.LoserID] = CalculateNewRating(loserRating, winnerRating, 0, 40)
+	// merge the singles and doubles games, which are both in chronological order
+	for len(games) > 0 || len(doublesGames) > 0 {
+		if len(doublesGames) > 0 && (len(games) == 0 || doublesGames[0].CreatedAt.Before(games[0].CreatedAt)) {
+			game := doublesGames[0]
+			doublesGames = doublesGames[1:]
 
-		// Track highest Elo achieved
-		if ratingMap[game.WinnerID] > highestRatingMap[game.WinnerID] {
-			highestRatingMap[game.WinnerID] = ratingMap[game.WinnerID]
+			for id, rating := range CalculateDoublesRatings(ratingMap, game.WinnerIDs, game.LoserIDs, cfg.Ratings.Elo.K) {
+				history = append(history, models.RatingChange{
+					PlayerID: id, GameType: models.DOUBLES, GameID: game.ID, RatingBefore: ratingMap[id], RatingAfter: rating,
+				})
+				ratingMap[id] = rating
+				record(id, game.CreatedAt)
+			}
+			continue
 		}
-		if ratingMap[game.LoserID] > highestRatingMap[game.LoserID] {
-			highestRatingMap[game.LoserID] = ratingMap[game.LoserID]
+
+		game := games[0]
+		games = games[1:]
+
+		// Get current ratings (default to the starting rating if new player)
+		for _, id := range []int{game.WinnerID, game.LoserID} {
+			if _, ok := ratingMap[id]; !ok {
+				ratingMap[id] = start
+			}
 		}
 
-		// Track last played time
-		lastPlayedMap[game.WinnerID] = game.CreatedAt
-		lastPlayedMap[game.LoserID] = game.CreatedAt
+		// Calculate and store new ratings, set by set for matches when configured
+		before := models.EloRatings{game.WinnerID: ratingMap[game.WinnerID], game.LoserID: ratingMap[game.LoserID]}
+		rateGame(ratingMap, gamesPlayed, cfg, game)
+		gamesPlayed[game.WinnerID]++
+		gamesPlayed[game.LoserID]++
+		history = append(history, ratingChanges(models.SINGLES, game.ID, before, ratingMap)...)
+
+		record(game.WinnerID, game.CreatedAt)
+		record(game.LoserID, game.CreatedAt)
 	}
 
 	err = s.UpdateEloRatings(ratingMap)
@@ -88,6 +215,11 @@ func RecalculateEloRatings(s models.Store) error {
 		return err
 	}
 
+	err = s.ReplaceRatingHistory(history)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -96,20 +228,39 @@ func RecalculateEloRatings(s models.Store) error {
 // so methods will correctly operate on the shared store instance.
 
 // Returns the players' old ratings, their new ratings and an error.
-func UpdatePlayersEloRating(s models.Store, winnerId int, loserId int) (models.EloRatings, models.EloRatings, error) {
+func UpdatePlayersEloRating(s models.Store, cfg config.Config, id int, r models.GameResult) (models.EloRatings, models.EloRatings, error) {
+	game := models.BaseGame{
+		ID:          id,
+		WinnerID:    r.WinnerID,
+		LoserID:     r.LoserID,
+		WinnerScore: r.WinnerScore,
+		LoserScore:  r.LoserScore,
+	}
+	return updatePlayersGameEloRating(s, cfg, game)
+}
+
+// updatePlayersGameEloRating applies a game that has just been recorded to the players'
+// ratings, with the same rules as RecalculateEloRatings, and adds it to their history.
+func updatePlayersGameEloRating(s models.Store, cfg config.Config, game models.BaseGame) (models.EloRatings, models.EloRatings, error) {
 
 	// fetch player elo rating
-	ratingMap, err := s.GetPlayerEloRatings([2]int{winnerId, loserId})
+	ratingMap, err := s.GetPlayerEloRatings([2]int{game.WinnerID, game.LoserID})
 	if err != nil {
 		return ratingMap, ratingMap, err
 	}
 
-	winnerRating := ratingMap[winnerId]
-	loserRating := ratingMap[loserId]
+	oldRatings := models.EloRatings{
+		game.WinnerID: ratingMap[game.WinnerID],
+		game.LoserID:  ratingMap[game.LoserID],
+	}
+
+	gamesPlayed, err := previousGameCounts(s, cfg, [2]int{game.WinnerID, game.LoserID})
+	if err != nil {
+		return ratingMap, ratingMap, err
+	}
 
 	// calculate new ratings
-	ratingMap[winnerId] = CalculateNewRating(winnerRating, loserRating, 1, 40)
-	ratingMap[loserId] = CalculateNewRating(loserRating, winnerRating, 0, 40)
+	rateGame(ratingMap, gamesPlayed, cfg, game)
 
 	// update the ratings
 	err = s.UpdateEloRatings(ratingMap)
@@ -117,10 +268,11 @@ func UpdatePlayersEloRating(s models.Store, winnerId int, loserId int) (models.E
 		return ratingMap, ratingMap, err
 	}
 
-	oldRatings := models.EloRatings{
-		winnerId: winnerRating,
-		loserId:  loserRating,
+	err = s.InsertRatingHistory(ratingChanges(models.SINGLES, game.ID, oldRatings, ratingMap))
+	if err != nil {
+		return ratingMap, ratingMap, err
 	}
+
 	return oldRatings, ratingMap, nil
 }
 
@@ -3,6 +3,8 @@ package utils
 import (
 	"math"
 	"testing"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
 )
 
 func TestCalculateNewRating(t *testing.T) {
@@ -49,3 +51,32 @@ func TestCalculateExpectedScoreSumToOne(t *testing.T) {
 		t.Errorf("Expected scores do not sum to 1, got: %v", sumExpected)
 	}
 }
+
+func TestEloKSchedule(t *testing.T) {
+	cfg := config.Default().Ratings.Elo
+	cfg.ProvisionalGames = 10
+	cfg.HighRatingThreshold = 1200
+
+	if k := EloK(cfg, 3, 1300); k != cfg.ProvisionalK {
+		t.Errorf("expected the provisional K-factor for a new player, got %v", k)
+	}
+	if k := EloK(cfg, 10, 1300); k != cfg.HighRatingK {
+		t.Errorf("expected the high rating K-factor, got %v", k)
+	}
+	if k := EloK(cfg, 10, 1100); k != cfg.K {
+		t.Errorf("expected the standard K-factor, got %v", k)
+	}
+}
+
+func TestMarginOfVictoryMultiplier(t *testing.T) {
+	narrow := MarginOfVictoryMultiplier(2, 1000, 1000)
+	blowout := MarginOfVictoryMultiplier(11, 1000, 1000)
+	if blowout <= narrow {
+		t.Errorf("expected a blowout to count for more than a narrow win, got %v and %v", blowout, narrow)
+	}
+
+	// an expected win by the favourite counts for less than an upset by the same margin
+	if MarginOfVictoryMultiplier(5, 1400, 1000) >= MarginOfVictoryMultiplier(5, 1000, 1400) {
+		t.Errorf("expected the multiplier to be damped for the favourite")
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// ToBaseGame returns the stored values of a singles game.
+func ToBaseGame(g models.Game) models.BaseGame {
+	return models.BaseGame{
+		ID:          g.ID,
+		WinnerID:    g.Winner.ID,
+		LoserID:     g.Loser.ID,
+		WinnerScore: g.WinnerScore,
+		LoserScore:  g.LoserScore,
+		Sets:        slices.Clone(g.Sets),
+		CreatedAt:   g.CreatedAt,
+	}
+}
+
+// ApplyGameUpdate returns a recorded game with a correction applied, checking that the
+// corrected game is one that could have been recorded.
+func ApplyGameUpdate(current models.Game, u models.GameUpdate, cfg config.Config) (models.BaseGame, error) {
+	g := ToBaseGame(current)
+
+	if u.WinnerID == nil && u.LoserID == nil && u.WinnerScore == nil && u.LoserScore == nil && u.Sets == nil && u.CreatedAt == nil {
+		return g, errors.New("nothing to update")
+	}
+
+	errs := &exceptions.ValidationError{}
+	isMatch := current.BestOf != nil
+
+	if u.WinnerID != nil {
+		g.WinnerID = *u.WinnerID
+	}
+	if u.LoserID != nil {
+		g.LoserID = *u.LoserID
+	}
+	if g.WinnerID == g.LoserID {
+		errs.Add("loserId", "must be a different player from the winner")
+	}
+
+	if isMatch && (u.WinnerScore != nil || u.LoserScore != nil) {
+		errs.Add("winnerScore", "a match is scored by its sets")
+	}
+	if !isMatch && u.Sets != nil {
+		errs.Add("sets", "only a match has sets")
+	}
+	if u.WinnerScore != nil {
+		g.WinnerScore = u.WinnerScore
+	}
+	if u.LoserScore != nil {
+		g.LoserScore = u.LoserScore
+	}
+	if u.Sets != nil {
+		g.Sets = slices.Clone(u.Sets)
+	}
+
+	if u.CreatedAt != nil {
+		if u.CreatedAt.After(time.Now()) {
+			errs.Add("createdAt", "cannot be in the future")
+		}
+		g.CreatedAt = *u.CreatedAt
+	}
+
+	if len(errs.Fields) > 0 {
+		return g, errs
+	}
+
+	if isMatch {
+		return g, ValidateMatch(models.MatchResult{WinnerID: g.WinnerID, LoserID: g.LoserID, BestOf: *current.BestOf, Sets: g.Sets}, cfg)
+	}
+	return g, ValidateGameResult(models.GameResult{WinnerScore: g.WinnerScore, LoserScore: g.LoserScore}, cfg)
+}
+
+// DiffGames lists the fields a correction changed, with their old and new values.
+func DiffGames(before models.BaseGame, after models.BaseGame) map[string]models.FieldChange {
+	changes := make(map[string]models.FieldChange)
+
+	if before.WinnerID != after.WinnerID {
+		changes["winnerId"] = models.FieldChange{From: before.WinnerID, To: after.WinnerID}
+	}
+	if before.LoserID != after.LoserID {
+		changes["loserId"] = models.FieldChange{From: before.LoserID, To: after.LoserID}
+	}
+	if !equalScores(before.WinnerScore, after.WinnerScore) {
+		changes["winnerScore"] = models.FieldChange{From: before.WinnerScore, To: after.WinnerScore}
+	}
+	if !equalScores(before.LoserScore, after.LoserScore) {
+		changes["loserScore"] = models.FieldChange{From: before.LoserScore, To: after.LoserScore}
+	}
+	if !slices.Equal(before.Sets, after.Sets) {
+		changes["sets"] = models.FieldChange{From: before.Sets, To: after.Sets}
+	}
+	if !before.CreatedAt.Equal(after.CreatedAt) {
+		changes["createdAt"] = models.FieldChange{From: before.CreatedAt, To: after.CreatedAt}
+	}
+	return changes
+}
+
+func equalScores(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
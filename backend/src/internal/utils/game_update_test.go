@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+func TestApplyGameUpdate(t *testing.T) {
+	playedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	game := models.Game{
+		ID:          1,
+		Winner:      models.Player{ID: 1},
+		Loser:       models.Player{ID: 2},
+		WinnerScore: intPointer(11),
+		LoserScore:  intPointer(7),
+		CreatedAt:   playedAt,
+	}
+	bestOf := 3
+	match := models.Game{
+		ID:        2,
+		Winner:    models.Player{ID: 1},
+		Loser:     models.Player{ID: 2},
+		BestOf:    &bestOf,
+		Sets:      []models.SetScore{{WinnerScore: 11, LoserScore: 5}, {WinnerScore: 11, LoserScore: 9}},
+		CreatedAt: playedAt,
+	}
+	earlier := playedAt.Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	// swapping the winner keeps the scores, which are from the winner's point of view
+	updated, err := ApplyGameUpdate(game, models.GameUpdate{WinnerID: intPointer(2), LoserID: intPointer(1), CreatedAt: &earlier}, config.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.WinnerID != 2 || updated.LoserID != 1 || *updated.WinnerScore != 11 || !updated.CreatedAt.Equal(earlier) {
+		t.Errorf("expected Bob's 11-7 win an hour earlier, got %+v", updated)
+	}
+
+	changes := DiffGames(ToBaseGame(game), updated)
+	if len(changes) != 3 || changes["winnerId"].From != 1 || changes["winnerId"].To != 2 {
+		t.Errorf("expected the players and time to have changed, got %+v", changes)
+	}
+
+	if _, err := ApplyGameUpdate(game, models.GameUpdate{}, config.Default()); err == nil {
+		t.Errorf("expected an error for an empty update")
+	}
+
+	tests := []struct {
+		name   string
+		game   models.Game
+		update models.GameUpdate
+		field  string
+	}{
+		{"same players", game, models.GameUpdate{LoserID: intPointer(1)}, "loserId"},
+		{"illegal score", game, models.GameUpdate{LoserScore: intPointer(10)}, "winnerScore"},
+		{"future time", game, models.GameUpdate{CreatedAt: &future}, "createdAt"},
+		{"sets on a game", game, models.GameUpdate{Sets: match.Sets}, "sets"},
+		{"score on a match", match, models.GameUpdate{WinnerScore: intPointer(11)}, "winnerScore"},
+		{"undecided match", match, models.GameUpdate{Sets: match.Sets[:1]}, "sets"},
+	}
+	for _, tt := range tests {
+		_, err := ApplyGameUpdate(tt.game, tt.update, config.Default())
+		var validationErr *exceptions.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != tt.field {
+			t.Errorf("%v: expected an error for %v, got %v", tt.name, tt.field, err)
+		}
+	}
+}
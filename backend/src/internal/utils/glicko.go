@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// Glicko-2 as described by Mark Glickman in "Example of the Glicko-2 system"
+// (http://www.glicko.net/glicko/glicko2.pdf). Ratings are kept on the Elo scale and
+// converted to the Glicko-2 scale only while they are being calculated. Only differences
+// between ratings matter, so players start at 1000 like they do for Elo.
+
+const (
+	GLICKO_START_RATING     float64 = 1000
+	GLICKO_START_DEVIATION  float64 = 350
+	GLICKO_START_VOLATILITY float64 = 0.06
+
+	// the system constant, which limits how quickly volatility can change
+	GLICKO_TAU float64 = 0.5
+
+	glickoScale     float64 = 173.7178
+	glickoTolerance float64 = 0.000001
+)
+
+// rating periods are counted from a fixed Monday so weekly periods start on Mondays
+var glickoEpoch = time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+
+// GlickoOutcome is the result of one game against an opponent, as rated at the start of
+// the rating period. Score is 1 for a win and 0 for a loss.
+type GlickoOutcome struct {
+	Opponent models.GlickoRating
+	Score    float64
+}
+
+// NewGlickoRating returns a rating with its 95% confidence interval filled in.
+func NewGlickoRating(rating float64, deviation float64, volatility float64) models.GlickoRating {
+	return models.GlickoRating{
+		Rating:     rating,
+		Deviation:  deviation,
+		Volatility: volatility,
+		Interval:   [2]float64{rating - 1.96*deviation, rating + 1.96*deviation},
+	}
+}
+
+// AgeGlickoRating widens a player's deviation for rating periods in which they did not
+// play. The deviation never exceeds that of a new player.
+func AgeGlickoRating(r models.GlickoRating, periods int) models.GlickoRating {
+	if periods <= 0 {
+		return r
+	}
+	phi := r.Deviation / glickoScale
+	phi = math.Sqrt(phi*phi + float64(periods)*r.Volatility*r.Volatility)
+	return NewGlickoRating(r.Rating, min(phi*glickoScale, GLICKO_START_DEVIATION), r.Volatility)
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func glickoE(mu float64, muJ float64, phiJ float64) float64 {
+	return 1 / (1 + math.Exp(-glickoG(phiJ)*(mu-muJ)))
+}
+
+// CalculateGlickoRating rates a player on every game they played in one rating period.
+func CalculateGlickoRating(player models.GlickoRating, outcomes []GlickoOutcome) models.GlickoRating {
+	if len(outcomes) == 0 {
+		return AgeGlickoRating(player, 1)
+	}
+
+	// step 2: convert to the Glicko-2 scale
+	mu := (player.Rating - GLICKO_START_RATING) / glickoScale
+	phi := player.Deviation / glickoScale
+	sigma := player.Volatility
+
+	// steps 3 and 4: the estimated variance and the estimated improvement
+	var vInverse, improvement float64
+	for _, o := range outcomes {
+		muJ := (o.Opponent.Rating - GLICKO_START_RATING) / glickoScale
+		phiJ := o.Opponent.Deviation / glickoScale
+		g := glickoG(phiJ)
+		e := glickoE(mu, muJ, phiJ)
+		vInverse += g * g * e * (1 - e)
+		improvement += g * (o.Score - e)
+	}
+	v := 1 / vInverse
+	delta := v * improvement
+
+	// step 5: the new volatility, found with the Illinois algorithm
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		return ex*(delta*delta-phi*phi-v-ex)/(2*math.Pow(phi*phi+v+ex, 2)) - (x-a)/(GLICKO_TAU*GLICKO_TAU)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*GLICKO_TAU) < 0 {
+			k++
+		}
+		B = a - k*GLICKO_TAU
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glickoTolerance {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA = fA / 2
+		}
+		B, fB = C, fC
+	}
+	newSigma := math.Exp(A / 2)
+
+	// steps 6 and 7: the new deviation and rating
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*improvement
+
+	// step 8: convert back to the Elo scale
+	return NewGlickoRating(newMu*glickoScale+GLICKO_START_RATING, newPhi*glickoScale, newSigma)
+}
+
+// glickoPeriod returns the index of the rating period a moment falls in.
+func glickoPeriod(t time.Time, days int) int {
+	length := time.Duration(days) * 24 * time.Hour
+	return int(math.Floor(float64(t.Sub(glickoEpoch)) / float64(length)))
+}
+
+// RecalculateGlickoRatings replays every singles game, period by period, and stores each
+// player's Glicko-2 rating. The current period is rated as if it ended now, so it is
+// provisional until the next replay after the period ends.
+func RecalculateGlickoRatings(s models.Store, cfg config.Config) error {
+	players, err := s.GetPlayerBasicInfo()
+	if err != nil {
+		return err
+	}
+
+	ratings := make(models.GlickoRatings)
+	lastPeriod := make(map[int]int)
+	for _, player := range players {
+		ratings[player.ID] = NewGlickoRating(GLICKO_START_RATING, GLICKO_START_DEVIATION, GLICKO_START_VOLATILITY)
+		lastPeriod[player.ID] = glickoPeriod(player.CreatedAt, cfg.Ratings.GlickoPeriodDays)
+	}
+
+	games, err := s.GetGameResults()
+	if err != nil {
+		return err
+	}
+
+	// games are in chronological order, so each period's games are contiguous
+	for len(games) > 0 {
+		period := glickoPeriod(games[0].CreatedAt, cfg.Ratings.GlickoPeriodDays)
+		end := slices.IndexFunc(games, func(g models.BaseGame) bool {
+			return glickoPeriod(g.CreatedAt, cfg.Ratings.GlickoPeriodDays) != period
+		})
+		if end == -1 {
+			end = len(games)
+		}
+
+		// every game in the period is rated against the opponent's rating at its start
+		outcomes := make(map[int][]GlickoOutcome)
+		for _, game := range games[:end] {
+			for _, o := range gameOutcomes(cfg, game) {
+				outcomes[o.WinnerID] = append(outcomes[o.WinnerID], GlickoOutcome{Opponent: ratings[o.LoserID], Score: 1})
+				outcomes[o.LoserID] = append(outcomes[o.LoserID], GlickoOutcome{Opponent: ratings[o.WinnerID], Score: 0})
+			}
+		}
+		games = games[end:]
+
+		for id, playerOutcomes := range outcomes {
+			rating := AgeGlickoRating(ratings[id], period-lastPeriod[id]-1)
+			ratings[id] = CalculateGlickoRating(rating, playerOutcomes)
+			lastPeriod[id] = period
+		}
+	}
+
+	// players grow less certain for every period since they last played
+	current := glickoPeriod(time.Now(), cfg.Ratings.GlickoPeriodDays)
+	for id, rating := range ratings {
+		ratings[id] = AgeGlickoRating(rating, current-lastPeriod[id])
+	}
+
+	return s.UpdateGlickoRatings(ratings)
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateGlickoRatingMatchesGlickmansExample(t *testing.T) {
+	player := NewGlickoRating(1500, 200, 0.06)
+	outcomes := []GlickoOutcome{
+		{Opponent: NewGlickoRating(1400, 30, 0.06), Score: 1},
+		{Opponent: NewGlickoRating(1550, 100, 0.06), Score: 0},
+		{Opponent: NewGlickoRating(1700, 300, 0.06), Score: 0},
+	}
+
+	r := CalculateGlickoRating(player, outcomes)
+
+	if math.Abs(r.Rating-1464.06) > 0.01 {
+		t.Errorf("expected a rating of 1464.06, got %v", r.Rating)
+	}
+	if math.Abs(r.Deviation-151.52) > 0.01 {
+		t.Errorf("expected a deviation of 151.52, got %v", r.Deviation)
+	}
+	if math.Abs(r.Volatility-0.05999) > 0.00001 {
+		t.Errorf("expected a volatility of 0.05999, got %v", r.Volatility)
+	}
+	if math.Abs(r.Interval[0]-(r.Rating-1.96*r.Deviation)) > 0.00001 {
+		t.Errorf("expected the interval to be rating ± 1.96 deviations, got %v", r.Interval)
+	}
+}
+
+func TestAgeGlickoRating(t *testing.T) {
+	r := NewGlickoRating(1500, 50, 0.06)
+
+	aged := AgeGlickoRating(r, 1)
+	if aged.Deviation <= r.Deviation || aged.Rating != r.Rating {
+		t.Errorf("expected the deviation alone to grow, got %+v", aged)
+	}
+
+	if AgeGlickoRating(r, 10000).Deviation != GLICKO_START_DEVIATION {
+		t.Errorf("expected the deviation to be capped at %v", GLICKO_START_DEVIATION)
+	}
+}
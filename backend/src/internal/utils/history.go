@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+type HistoryBucket string
+
+const (
+	BUCKET_GAME HistoryBucket = "game"
+	BUCKET_DAY  HistoryBucket = "day"
+	BUCKET_WEEK HistoryBucket = "week"
+)
+
+// ParseHistoryBucket checks a bucket query parameter, defaulting to one point per game.
+func ParseHistoryBucket(value string) (HistoryBucket, error) {
+	switch bucket := HistoryBucket(value); bucket {
+	case "":
+		return BUCKET_GAME, nil
+	case BUCKET_GAME, BUCKET_DAY, BUCKET_WEEK:
+		return bucket, nil
+	default:
+		return BUCKET_GAME, fmt.Errorf("invalid bucket '%v': expected game, day or week", value)
+	}
+}
+
+// bucketStart returns the start of the day or week (from Monday) a moment falls in, in
+// the moment's own time zone.
+func bucketStart(t time.Time, bucket HistoryBucket) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if bucket == BUCKET_WEEK {
+		daysSinceMonday := (int(start.Weekday()) + 6) % 7
+		start = start.AddDate(0, 0, -daysSinceMonday)
+	}
+	return start
+}
+
+// BuildRatingHistory turns a player's rating changes, in the order they were played, into
+// the points of a rating graph. Changes outside from and to are left out; a zero time
+// leaves that end of the range open.
+func BuildRatingHistory(changes []models.RatingChange, bucket HistoryBucket, from time.Time, to time.Time) []models.RatingHistoryPoint {
+	points := make([]models.RatingHistoryPoint, 0)
+
+	for _, c := range changes {
+		if (!from.IsZero() && c.PlayedAt.Before(from)) || (!to.IsZero() && c.PlayedAt.After(to)) {
+			continue
+		}
+
+		if bucket == BUCKET_GAME {
+			points = append(points, models.RatingHistoryPoint{
+				Time:     c.PlayedAt,
+				Rating:   c.RatingAfter,
+				Change:   c.RatingAfter - c.RatingBefore,
+				Games:    1,
+				GameType: c.GameType,
+				GameID:   c.GameID,
+			})
+			continue
+		}
+
+		start := bucketStart(c.PlayedAt, bucket)
+		if len(points) > 0 && points[len(points)-1].Time.Equal(start) {
+			last := &points[len(points)-1]
+			last.Rating = c.RatingAfter
+			last.Change += c.RatingAfter - c.RatingBefore
+			last.Games++
+			continue
+		}
+
+		points = append(points, models.RatingHistoryPoint{
+			Time:   start,
+			Rating: c.RatingAfter,
+			Change: c.RatingAfter - c.RatingBefore,
+			Games:  1,
+		})
+	}
+	return points
+}
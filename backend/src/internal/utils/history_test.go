@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+func TestBuildRatingHistoryBuckets(t *testing.T) {
+	// Sunday 5th, then Monday 6th twice, then Tuesday 7th
+	changes := []models.RatingChange{
+		{GameID: 1, RatingBefore: 1000, RatingAfter: 1016, PlayedAt: time.Date(2025, 1, 5, 18, 0, 0, 0, time.UTC)},
+		{GameID: 2, RatingBefore: 1016, RatingAfter: 1000, PlayedAt: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)},
+		{GameID: 3, RatingBefore: 1000, RatingAfter: 1015, PlayedAt: time.Date(2025, 1, 6, 17, 0, 0, 0, time.UTC)},
+		{GameID: 4, RatingBefore: 1015, RatingAfter: 1030, PlayedAt: time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		bucket  HistoryBucket
+		ratings []float64
+		games   []int
+	}{
+		{BUCKET_GAME, []float64{1016, 1000, 1015, 1030}, []int{1, 1, 1, 1}},
+		{BUCKET_DAY, []float64{1016, 1015, 1030}, []int{1, 2, 1}},
+		{BUCKET_WEEK, []float64{1016, 1030}, []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		points := BuildRatingHistory(changes, tt.bucket, time.Time{}, time.Time{})
+		if len(points) != len(tt.ratings) {
+			t.Fatalf("%v: expected %d points, got %+v", tt.bucket, len(tt.ratings), points)
+		}
+		for i, p := range points {
+			if p.Rating != tt.ratings[i] || p.Games != tt.games[i] {
+				t.Errorf("%v: expected point %d to be %v after %d games, got %+v", tt.bucket, i, tt.ratings[i], tt.games[i], p)
+			}
+		}
+	}
+
+	week := BuildRatingHistory(changes, BUCKET_WEEK, time.Time{}, time.Time{})
+	if !week[1].Time.Equal(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)) || week[1].Change != 14 {
+		t.Errorf("expected the second week to start on Monday 6th with a change of 14, got %+v", week[1])
+	}
+}
+
+func TestBuildRatingHistoryRange(t *testing.T) {
+	changes := []models.RatingChange{
+		{GameID: 1, RatingAfter: 1016, PlayedAt: time.Date(2025, 1, 5, 18, 0, 0, 0, time.UTC)},
+		{GameID: 2, RatingAfter: 1000, PlayedAt: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)},
+		{GameID: 3, RatingAfter: 1015, PlayedAt: time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)},
+	}
+
+	points := BuildRatingHistory(changes, BUCKET_GAME, time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), time.Time{})
+	if len(points) != 2 || points[0].GameID != 2 {
+		t.Errorf("expected games from the 6th onwards, got %+v", points)
+	}
+
+	points = BuildRatingHistory(changes, BUCKET_GAME, time.Time{}, time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC))
+	if len(points) != 2 || points[1].GameID != 2 {
+		t.Errorf("expected games up to and including 9am on the 6th, got %+v", points)
+	}
+}
+
+func TestParseHistoryBucket(t *testing.T) {
+	if bucket, err := ParseHistoryBucket(""); err != nil || bucket != BUCKET_GAME {
+		t.Errorf("expected an empty bucket to default to game, got %v, %v", bucket, err)
+	}
+	if _, err := ParseHistoryBucket("month"); err == nil {
+		t.Errorf("expected an error for an unknown bucket")
+	}
+}
@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+type ImportFormat string
+
+const (
+	IMPORT_CSV  ImportFormat = "csv"
+	IMPORT_JSON ImportFormat = "json"
+)
+
+// the layouts a played-at time may be given in, besides RFC 3339. They are read as UTC.
+var importTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// the columns of an import CSV file, named as in the JSON format
+var importColumns = []string{"winner", "loser", "winnerScore", "loserScore", "playedAt"}
+
+// importRecord is a game as written in an import file, before its values are checked.
+type importRecord struct {
+	Line        int     `json:"-"`
+	Winner      string  `json:"winner"`
+	Loser       string  `json:"loser"`
+	WinnerScore *string `json:"-"`
+	LoserScore  *string `json:"-"`
+	PlayedAt    string  `json:"playedAt"`
+}
+
+// ParseImportFormat reads the format of an import from a file extension or content type.
+func ParseImportFormat(value string) (ImportFormat, error) {
+	value = strings.ToLower(value)
+	switch {
+	case strings.HasSuffix(value, "csv"):
+		return IMPORT_CSV, nil
+	case strings.HasSuffix(value, "json"):
+		return IMPORT_JSON, nil
+	default:
+		return "", fmt.Errorf("unknown import format '%v': expected csv or json", value)
+	}
+}
+
+// ReadImport reads the games of an import file and checks every one of them, so that all
+// the problems with a file can be fixed at once. Each problem is reported against the line
+// it is on. The games are returned in the order they were played.
+func ReadImport(r io.Reader, format ImportFormat, cfg config.Config) ([]models.ImportGame, error) {
+	var records []importRecord
+	var err error
+	if format == IMPORT_CSV {
+		records, err = readImportCSV(r)
+	} else {
+		records, err = readImportJSON(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the file has no games to import")
+	}
+
+	errs := &exceptions.ValidationError{}
+	games := make([]models.ImportGame, 0, len(records))
+	for _, record := range records {
+		games = append(games, checkImportRecord(errs, record, cfg))
+	}
+	if len(errs.Fields) > 0 {
+		return nil, errs
+	}
+
+	// games played at the same time keep the order of the file
+	slices.SortStableFunc(games, func(a, b models.ImportGame) int {
+		return a.PlayedAt.Compare(b.PlayedAt)
+	})
+	return games, nil
+}
+
+// checkImportRecord converts a record to a game, adding any problems with it to errs.
+func checkImportRecord(errs *exceptions.ValidationError, record importRecord, cfg config.Config) models.ImportGame {
+	field := func(name string) string {
+		return fmt.Sprintf("line %d: %v", record.Line, name)
+	}
+
+	g := models.ImportGame{
+		Line:   record.Line,
+		Winner: strings.TrimSpace(record.Winner),
+		Loser:  strings.TrimSpace(record.Loser),
+	}
+
+	for _, player := range []struct{ name, value string }{{"winner", g.Winner}, {"loser", g.Loser}} {
+		switch {
+		case player.value == "":
+			errs.Add(field(player.name), "is required")
+		case len(player.value) > 63:
+			errs.Add(field(player.name), "cannot be longer than 63 characters")
+		}
+	}
+	if g.Winner != "" && strings.EqualFold(g.Winner, g.Loser) {
+		errs.Add(field("loser"), "must be a different player from the winner")
+	}
+
+	scoresValid := true
+	for _, score := range []struct {
+		name   string
+		value  *string
+		target **int
+	}{{"winnerScore", record.WinnerScore, &g.WinnerScore}, {"loserScore", record.LoserScore, &g.LoserScore}} {
+		if score.value == nil || strings.TrimSpace(*score.value) == "" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(*score.value))
+		if err != nil || n < 0 || n > 255 {
+			errs.Add(field(score.name), "must be a whole number from 0 to 255")
+			scoresValid = false
+			continue
+		}
+		*score.target = &n
+	}
+
+	// the back-dating window is for games entered as they are played, so it does not apply
+	if scoresValid {
+		var scoreErrs *exceptions.ValidationError
+		err := ValidateGameResult(models.GameResult{WinnerScore: g.WinnerScore, LoserScore: g.LoserScore}, cfg)
+		if errors.As(err, &scoreErrs) {
+			for _, f := range scoreErrs.Fields {
+				errs.Add(field(f.Field), f.Message)
+			}
+		}
+	}
+
+	playedAt, err := ParseGameTime(record.PlayedAt)
+	switch {
+	case strings.TrimSpace(record.PlayedAt) == "":
+		errs.Add(field("playedAt"), "is required")
+	case err != nil:
+		errs.Add(field("playedAt"), "must be an RFC 3339 time or of the form YYYY-MM-DD HH:MM[:SS]")
+	case playedAt.After(time.Now()):
+		errs.Add(field("playedAt"), "cannot be in the future")
+	}
+	g.PlayedAt = playedAt
+
+	return g
+}
+
+// ParseGameTime reads the time a game was played, given as an RFC 3339 time or of the
+// form YYYY-MM-DD HH:MM[:SS] in UTC.
+func ParseGameTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range importTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%v'", value)
+}
+
+// readImportCSV reads a CSV file whose header names its columns. The score columns may be
+// left out, or left blank for games without a recorded score.
+func readImportCSV(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV header: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(importColumns, name) {
+			return nil, fmt.Errorf("unknown CSV column '%v': expected %v", name, strings.Join(importColumns, ", "))
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"winner", "loser", "playedAt"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the CSV header is missing the '%v' column", name)
+		}
+	}
+
+	value := func(row []string, name string) *string {
+		i, ok := columns[name]
+		if !ok {
+			return nil
+		}
+		return &row[i]
+	}
+
+	records := make([]importRecord, 0)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, importRecord{
+			Line:        line,
+			Winner:      *value(row, "winner"),
+			Loser:       *value(row, "loser"),
+			WinnerScore: value(row, "winnerScore"),
+			LoserScore:  value(row, "loserScore"),
+			PlayedAt:    *value(row, "playedAt"),
+		})
+	}
+	return records, nil
+}
+
+// readImportJSON reads a JSON array of games. Each game's line is the line its object
+// starts on.
+func readImportJSON(r io.Reader) ([]importRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading JSON: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, errors.New("expected a JSON array of games")
+	}
+
+	records := make([]importRecord, 0)
+	for decoder.More() {
+		// the offset is at the end of the previous value, before the separating comma
+		start := int(decoder.InputOffset())
+		start += len(data[start:]) - len(bytes.TrimLeft(data[start:], ", \t\r\n"))
+		line := bytes.Count(data[:start], []byte("\n")) + 1
+
+		var game struct {
+			importRecord
+			WinnerScore *json.Number `json:"winnerScore"`
+			LoserScore  *json.Number `json:"loserScore"`
+		}
+		if err := decoder.Decode(&game); err != nil {
+			return nil, fmt.Errorf("error reading the game on line %d: %v", line, err)
+		}
+
+		record := game.importRecord
+		record.Line = line
+		record.WinnerScore = (*string)(game.WinnerScore)
+		record.LoserScore = (*string)(game.LoserScore)
+		records = append(records, record)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("error reading JSON: %v", err)
+	}
+	return records, nil
+}
+
+// ImportGames saves games read by ReadImport, then replays every rating and rebuilds the
+// achievements, as the imported games may come before others.
+func ImportGames(s models.Store, cfg config.Config, games []models.ImportGame) (models.ImportSummary, error) {
+	summary, err := s.ImportGames(games, cfg.Ratings.Elo.StartRating)
+	if err != nil {
+		return summary, err
+	}
+
+	err = RecalculateEloRatings(s, cfg)
+	if err != nil {
+		return summary, err
+	}
+
+	err = RecalculateGlickoRatings(s, cfg)
+	if err != nil {
+		return summary, err
+	}
+
+	return summary, RebuildAchievements(s)
+}
+
+// DescribeImport summarises an import for the audit log.
+func DescribeImport(summary models.ImportSummary) string {
+	return fmt.Sprintf("imported %d games and %d new players", summary.Games, len(summary.Players))
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+)
+
+func TestReadImportCSV(t *testing.T) {
+	file := `winner,loser,winnerScore,loserScore,playedAt
+Alice,Bob,11,5,2024-03-02 12:00
+Bob,Carol,,,2024-03-01T09:30:00Z
+`
+	games, err := ReadImport(strings.NewReader(file), IMPORT_CSV, config.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(games))
+	}
+
+	// games are returned in the order they were played
+	if games[0].Winner != "Bob" || games[0].Line != 3 || games[0].WinnerScore != nil {
+		t.Errorf("expected Bob's unscored win on line 3 first, got %+v", games[0])
+	}
+	if games[1].Winner != "Alice" || *games[1].WinnerScore != 11 || *games[1].LoserScore != 5 {
+		t.Errorf("expected Alice's 11–5 win second, got %+v", games[1])
+	}
+	if !games[1].PlayedAt.Equal(time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the game to be played at noon UTC, got %v", games[1].PlayedAt)
+	}
+}
+
+func TestReadImportJSON(t *testing.T) {
+	file := `[
+  {"winner": "Alice", "loser": "Bob", "winnerScore": 11, "loserScore": 9, "playedAt": "2024-03-01 12:00"},
+
+  {"winner": "Alice", "loser": "Bob", "playedAt": "2024-03-02 12:00"}
+]`
+	games, err := ReadImport(strings.NewReader(file), IMPORT_JSON, config.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 2 || games[0].Line != 2 || games[1].Line != 4 {
+		t.Fatalf("expected games on lines 2 and 4, got %+v", games)
+	}
+}
+
+func TestReadImportReportsEveryLine(t *testing.T) {
+	file := `winner,loser,winnerScore,loserScore,playedAt
+Alice,Bob,11,10,2024-03-01 12:00
+Alice,alice,11,5,2024-03-01 12:00
+,Bob,,,yesterday
+Alice,Bob,11,5,2024-03-01 12:00
+`
+	_, err := ReadImport(strings.NewReader(file), IMPORT_CSV, config.Default())
+
+	var validationErr *exceptions.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	expected := []string{"line 2: winnerScore", "line 3: loser", "line 4: winner", "line 4: playedAt"}
+	if len(validationErr.Fields) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), validationErr.Fields)
+	}
+	for i, field := range expected {
+		if validationErr.Fields[i].Field != field {
+			t.Errorf("expected error %d to be for %v, got %v", i, field, validationErr.Fields[i].Field)
+		}
+	}
+}
+
+func TestReadImportRejectsUnknownColumns(t *testing.T) {
+	file := "winner,loser,score,playedAt\nAlice,Bob,11,2024-03-01 12:00\n"
+	if _, err := ReadImport(strings.NewReader(file), IMPORT_CSV, config.Default()); err == nil {
+		t.Error("expected an unknown column to be rejected")
+	}
+}
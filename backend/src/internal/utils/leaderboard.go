@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+type LeaderboardSort string
+
+const (
+	SORT_BY_RATING       LeaderboardSort = "rating"
+	SORT_BY_ACHIEVEMENTS LeaderboardSort = "achievements"
+)
+
+// ParseLeaderboardSort reads the order the singles leaderboard is asked for in, defaulting
+// to the rating.
+func ParseLeaderboardSort(value string) (LeaderboardSort, error) {
+	switch sort := LeaderboardSort(value); sort {
+	case "":
+		return SORT_BY_RATING, nil
+	case SORT_BY_RATING, SORT_BY_ACHIEVEMENTS:
+		return sort, nil
+	default:
+		return "", fmt.Errorf("unknown sort '%v': expected rating or achievements", value)
+	}
+}
+
+// GetIndexPageData returns the leaderboards with each player's achievement score. The
+// singles leaderboard is ordered by the configured rating system, or by achievement score
+// with the rating breaking ties.
+func GetIndexPageData(s models.Store, cfg config.Config, includeInactive bool, sort LeaderboardSort) (models.IndexPageData, error) {
+	data, err := s.GetIndexPageData(includeInactive)
+	if err != nil {
+		return data, err
+	}
+
+	data.RatingSystem = string(cfg.Ratings.System)
+	data.Sort = string(sort)
+	if cfg.Ratings.System == config.GLICKO2 {
+		slices.SortStableFunc(data.Leaderboard, func(a, b models.LeaderboardRow) int {
+			return cmp.Compare(b.Glicko.Rating, a.Glicko.Rating)
+		})
+	}
+
+	scores, err := getAchievementScores(s)
+	if err != nil {
+		return data, err
+	}
+	for _, rows := range [][]models.LeaderboardRow{data.Leaderboard, data.DoublesLeaderboard} {
+		for i := range rows {
+			rows[i].AchievementScore = scores[rows[i].ID]
+		}
+	}
+	if sort == SORT_BY_ACHIEVEMENTS {
+		slices.SortStableFunc(data.Leaderboard, func(a, b models.LeaderboardRow) int {
+			return cmp.Compare(b.AchievementScore, a.AchievementScore)
+		})
+	}
+	return data, nil
+}
+
+// getAchievementScores adds up the points of every player's achievements.
+func getAchievementScores(s models.Store) (map[int]int, error) {
+	achievements, err := s.GetAchievements()
+	if err != nil {
+		return nil, err
+	}
+	awards, err := s.GetPlayerAchievements()
+	if err != nil {
+		return nil, err
+	}
+
+	points := make(map[int]int, len(achievements))
+	for _, a := range achievements {
+		points[a.ID] = a.Points
+	}
+	scores := make(map[int]int)
+	for _, pa := range awards {
+		scores[pa.PlayerID] += points[pa.AchievementID]
+	}
+	return scores, nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// gameOutcomes lists the results a singles game contributes to the players' ratings. A
+// match is a single win for the match winner, unless the configuration asks for every set
+// to be rated separately.
+func gameOutcomes(cfg config.Config, game models.BaseGame) []gameOutcome {
+	if cfg.Matches.RatingMode != config.RATE_PER_SET || len(game.Sets) == 0 {
+		margin := 0
+		if game.WinnerScore != nil && game.LoserScore != nil {
+			margin = *game.WinnerScore - *game.LoserScore
+		}
+		return []gameOutcome{{WinnerID: game.WinnerID, LoserID: game.LoserID, Margin: margin}}
+	}
+
+	outcomes := make([]gameOutcome, 0, len(game.Sets))
+	for _, set := range game.Sets {
+		if set.WinnerScore > set.LoserScore {
+			outcomes = append(outcomes, gameOutcome{game.WinnerID, game.LoserID, set.WinnerScore - set.LoserScore})
+		} else {
+			outcomes = append(outcomes, gameOutcome{game.LoserID, game.WinnerID, set.LoserScore - set.WinnerScore})
+		}
+	}
+	return outcomes
+}
+
+// UpdatePlayersMatchEloRating rates a best-of-N match and returns the players' ratings
+// from before the first set and after the last.
+func UpdatePlayersMatchEloRating(s models.Store, cfg config.Config, id int, r models.MatchResult) (models.EloRatings, models.EloRatings, error) {
+	return updatePlayersGameEloRating(s, cfg, models.BaseGame{ID: id, WinnerID: r.WinnerID, LoserID: r.LoserID, Sets: r.Sets})
+}
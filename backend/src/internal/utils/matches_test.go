@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// setScores builds the sets of a match from winner and loser score pairs.
+func setScores(scores ...[2]int) []models.SetScore {
+	sets := make([]models.SetScore, 0, len(scores))
+	for _, score := range scores {
+		sets = append(sets, models.SetScore{WinnerScore: score[0], LoserScore: score[1]})
+	}
+	return sets
+}
+
+func TestRateGamePerMatchAndPerSet(t *testing.T) {
+	sets := setScores([2]int{11, 7}, [2]int{8, 11}, [2]int{11, 9})
+
+	perMatch := models.EloRatings{1: 1000, 2: 1000}
+	rateGame(perMatch, nil, config.Default(), models.BaseGame{WinnerID: 1, LoserID: 2, Sets: sets})
+	if math.Round(perMatch[1]) != 1020 {
+		t.Errorf("expected a match to be rated as a single win, got %v", perMatch[1])
+	}
+
+	cfg := config.Default()
+	cfg.Matches.RatingMode = config.RATE_PER_SET
+	perSet := models.EloRatings{1: 1000, 2: 1000}
+	rateGame(perSet, nil, cfg, models.BaseGame{WinnerID: 1, LoserID: 2, Sets: sets})
+	if perSet[1] <= 1000 || perSet[1] >= perMatch[1] {
+		t.Errorf("expected a 2-1 match rated per set to gain less than a single win, got %v", perSet[1])
+	}
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// the rarity of an achievement held by less than the given percentage of active players,
+// rarest first. Anything more widely held is common.
+var rarityTiers = []struct {
+	below  float64
+	rarity models.Rarity
+}{
+	{5, models.LEGENDARY},
+	{15, models.EPIC},
+	{40, models.RARE},
+}
+
+// GetAchievementStats lists every achievement with how many players have unlocked it, the
+// first and most recent of them, and how rare it is among the active players.
+func GetAchievementStats(s models.Store) ([]models.AchievementStats, error) {
+	details, err := getAchievementDetails(s)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.AchievementStats, 0, len(details))
+	for _, d := range details {
+		stats = append(stats, d.AchievementStats)
+	}
+	return stats, nil
+}
+
+// GetAchievementDetail returns an achievement's stats with every player who holds it.
+func GetAchievementDetail(s models.Store, id int) (models.AchievementDetail, error) {
+	details, err := getAchievementDetails(s)
+	if err != nil {
+		return models.AchievementDetail{}, err
+	}
+
+	i := slices.IndexFunc(details, func(d models.AchievementDetail) bool { return d.ID == id })
+	if i == -1 {
+		return models.AchievementDetail{}, exceptions.ErrAchievementNotFound
+	}
+	return details[i], nil
+}
+
+// getAchievementDetails gathers the holders of every achievement from the awarded
+// achievements, and works out the stats from them.
+func getAchievementDetails(s models.Store) ([]models.AchievementDetail, error) {
+	achievements, err := s.GetAchievements()
+	if err != nil {
+		return nil, err
+	}
+	awards, err := s.GetPlayerAchievements()
+	if err != nil {
+		return nil, err
+	}
+	players, err := s.GetPlayerBasicInfo()
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.GetIndexPageData(false)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string, len(players))
+	for _, p := range players {
+		names[p.ID] = p.Name
+	}
+	active := make(map[int]bool, len(data.Leaderboard))
+	for _, row := range data.Leaderboard {
+		active[row.ID] = true
+	}
+
+	holders := make(map[int][]models.AchievementHolder)
+	for _, pa := range awards {
+		holders[pa.AchievementID] = append(holders[pa.AchievementID], models.AchievementHolder{
+			Player:   models.Player{ID: pa.PlayerID, Name: names[pa.PlayerID]},
+			EarnedAt: pa.CreatedAt,
+		})
+	}
+
+	details := make([]models.AchievementDetail, 0, len(achievements))
+	for _, a := range achievements {
+		h := holders[a.ID]
+		slices.SortFunc(h, func(x, y models.AchievementHolder) int {
+			return cmp.Or(x.EarnedAt.Compare(y.EarnedAt), cmp.Compare(x.ID, y.ID))
+		})
+
+		activeHolders := 0
+		for _, holder := range h {
+			if active[holder.ID] {
+				activeHolders++
+			}
+		}
+		percentage := 0.0
+		if len(active) > 0 {
+			percentage = math.Round(float64(activeHolders)/float64(len(active))*1000) / 10
+		}
+
+		d := models.AchievementDetail{
+			AchievementStats: models.AchievementStats{
+				Achievement:      a,
+				HolderCount:      len(h),
+				ActivePercentage: percentage,
+				Rarity:           rarity(percentage),
+			},
+			Holders: make([]models.AchievementHolder, 0, len(h)),
+		}
+		d.Holders = append(d.Holders, h...)
+		if len(h) > 0 {
+			first, latest := h[0], h[len(h)-1]
+			d.FirstUnlock, d.LatestUnlock = &first, &latest
+		}
+		details = append(details, d)
+	}
+	return details, nil
+}
+
+// rarity names how rare an achievement held by a percentage of the active players is.
+func rarity(percentage float64) models.Rarity {
+	for _, tier := range rarityTiers {
+		if percentage < tier.below {
+			return tier.rarity
+		}
+	}
+	return models.COMMON
+}
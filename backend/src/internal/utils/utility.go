@@ -17,19 +17,3 @@ func datesEqual(t1 time.Time, t2 time.Time) bool {
 	}
 	return equal
 }
-
-func boolToInt(b bool) int {
-	// The compiler currently only optimizes this form.
-	// See issue 6011.
-	var i int
-	if b {
-		i = 1
-	} else {
-		i = 0
-	}
-	return i
-}
-
-func notNilPointer[T any](p *T) bool {
-	return p != nil
-}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+// checkFinalScore explains why a game could not have finished with these scores, or
+// returns an empty string if it could. The first player to reach the target wins, but
+// from target-1 all play continues until one player leads by two.
+func checkFinalScore(winnerScore int, loserScore int, target int) string {
+	switch {
+	case winnerScore <= loserScore:
+		return fmt.Sprintf("%d–%d: the winner must score more points than the loser", winnerScore, loserScore)
+	case winnerScore < target:
+		return fmt.Sprintf("%d–%d: the winner must reach %d points", winnerScore, loserScore, target)
+	case winnerScore == target && loserScore > target-2:
+		return fmt.Sprintf(
+			"%d–%d: at %d–%d play continues until one player leads by two",
+			winnerScore, loserScore, target-1, target-1,
+		)
+	case winnerScore > target && winnerScore-loserScore != 2:
+		return fmt.Sprintf(
+			"%d–%d: a game that goes beyond %d points ends as soon as one player leads by two",
+			winnerScore, loserScore, target,
+		)
+	}
+	return ""
+}
+
+// checkPlayedAt records an error if a game's played-at time, if given, is in the future or
+// further back than the back-dating window allows.
+func checkPlayedAt(errs *exceptions.ValidationError, playedAt *time.Time, cfg config.Config) {
+	if playedAt == nil {
+		return
+	}
+	now := time.Now()
+	window := time.Duration(cfg.Backdating.WindowHours) * time.Hour
+	switch {
+	case playedAt.After(now):
+		errs.Add("playedAt", "cannot be in the future")
+	case playedAt.Before(now.Add(-window)):
+		errs.Add("playedAt", fmt.Sprintf("cannot be more than %d hours ago", cfg.Backdating.WindowHours))
+	}
+}
+
+// ValidateGameResult checks that a game's scores, if given, are a legal final score and
+// that its played-at time, if given, is within the back-dating window.
+func ValidateGameResult(r models.GameResult, cfg config.Config) error {
+	errs := &exceptions.ValidationError{}
+	target := cfg.Scoring.Target
+	checkPlayedAt(errs, r.PlayedAt, cfg)
+
+	// the achievement code reads both scores whenever the winner's score is set
+	if r.WinnerScore == nil && r.LoserScore != nil {
+		errs.Add("winnerScore", "must be given when loserScore is given")
+	}
+	if r.WinnerScore != nil && r.LoserScore == nil {
+		errs.Add("loserScore", "must be given when winnerScore is given")
+	}
+
+	if r.WinnerScore != nil && r.LoserScore != nil {
+		if message := checkFinalScore(*r.WinnerScore, *r.LoserScore, target); message != "" {
+			errs.Add("winnerScore", message)
+		}
+	}
+
+	return errs.ErrOrNil()
+}
+
+// ValidateNewAPIToken checks that a player token names the player it acts for.
+func ValidateNewAPIToken(t models.NewAPIToken) error {
+	errs := &exceptions.ValidationError{}
+	if t.Role == models.ROLE_PLAYER && t.PlayerID == nil {
+		errs.Add("playerId", "must be given for a player token")
+	}
+	return errs.ErrOrNil()
+}
+
+// ValidateMatch checks that every set score is legal and that the declared winner won
+// the majority of a best-of-N match, with no sets played after the match was decided. The
+// played-at time is checked as for a single game.
+func ValidateMatch(r models.MatchResult, cfg config.Config) error {
+	errs := &exceptions.ValidationError{}
+	target := cfg.Scoring.Target
+	checkPlayedAt(errs, r.PlayedAt, cfg)
+
+	if len(r.Sets) > r.BestOf {
+		errs.Add("sets", fmt.Sprintf("a best of %d match cannot have %d sets", r.BestOf, len(r.Sets)))
+		return errs
+	}
+
+	needed := r.BestOf/2 + 1
+	winnerSets := 0
+	loserSets := 0
+
+	for i, set := range r.Sets {
+		field := fmt.Sprintf("sets[%d]", i)
+
+		// set scores are from the match winner's point of view, so either side may have won
+		if message := checkFinalScore(max(set.WinnerScore, set.LoserScore), min(set.WinnerScore, set.LoserScore), target); message != "" {
+			errs.Add(field, message)
+		}
+		if winnerSets == needed || loserSets == needed {
+			errs.Add(field, "played after the match was decided")
+		}
+
+		if set.WinnerScore > set.LoserScore {
+			winnerSets++
+		} else {
+			loserSets++
+		}
+	}
+
+	if winnerSets != needed && len(errs.Fields) == 0 {
+		errs.Add(
+			"sets",
+			fmt.Sprintf("the winner must take %d sets of a best of %d match, but took %d", needed, r.BestOf, winnerSets),
+		)
+	}
+	return errs.ErrOrNil()
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/exceptions"
+	"github.com/jda5/luinc-pong/src/internal/models"
+)
+
+func TestValidateGameResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		winnerScore *int
+		loserScore  *int
+		target      int
+		valid       bool
+	}{
+		{"no scores", nil, nil, 11, true},
+		{"straightforward win", intPointer(11), intPointer(9), 11, true},
+		{"deuce", intPointer(14), intPointer(12), 11, true},
+		{"old-school game", intPointer(21), intPointer(15), 21, true},
+		{"no two point lead", intPointer(11), intPointer(10), 11, false},
+		{"winner did not reach the target", intPointer(9), intPointer(3), 11, false},
+		{"loser scored more", intPointer(7), intPointer(11), 11, false},
+		{"deuce won by more than two", intPointer(15), intPointer(12), 11, false},
+		{"eleven is not enough to 21", intPointer(11), intPointer(5), 21, false},
+		{"only the winner's score", intPointer(11), nil, 11, false},
+		{"only the loser's score", nil, intPointer(5), 11, false},
+	}
+
+	for _, test := range tests {
+		r := models.GameResult{WinnerID: 1, LoserID: 2, WinnerScore: test.winnerScore, LoserScore: test.loserScore}
+		cfg := config.Default()
+		cfg.Scoring.Target = test.target
+		err := ValidateGameResult(r, cfg)
+		if test.valid && err != nil {
+			t.Errorf("%v: expected the game to be valid, got %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%v: expected the game to be rejected", test.name)
+		}
+	}
+}
+
+func TestValidateGameResultReportsFields(t *testing.T) {
+	err := ValidateGameResult(models.GameResult{WinnerID: 1, LoserID: 2, LoserScore: intPointer(5)}, config.Default())
+
+	var validationErr *exceptions.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "winnerScore" {
+		t.Errorf("expected a single winnerScore error, got %v", validationErr.Fields)
+	}
+}
+
+func TestValidateGameResultPlayedAt(t *testing.T) {
+	cfg := config.Default()
+	cfg.Backdating.WindowHours = 24
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		playedAt time.Time
+		valid    bool
+	}{
+		{"an hour ago", now.Add(-time.Hour), true},
+		{"just inside the window", now.Add(-23 * time.Hour), true},
+		{"outside the window", now.Add(-25 * time.Hour), false},
+		{"in the future", now.Add(time.Hour), false},
+	}
+
+	for _, test := range tests {
+		err := ValidateGameResult(models.GameResult{WinnerID: 1, LoserID: 2, PlayedAt: &test.playedAt}, cfg)
+		if test.valid && err != nil {
+			t.Errorf("%v: expected the game to be valid, got %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%v: expected the game to be rejected", test.name)
+		}
+	}
+}
+
+func TestValidateMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		sets  []models.SetScore
+		valid bool
+	}{
+		{"straight sets", setScores([2]int{11, 5}, [2]int{11, 9}), true},
+		{"deciding set", setScores([2]int{11, 7}, [2]int{8, 11}, [2]int{14, 12}), true},
+		{"too few sets", setScores([2]int{11, 5}), false},
+		{"loser takes the match", setScores([2]int{5, 11}, [2]int{11, 9}, [2]int{3, 11}), false},
+		{"set after the match was decided", setScores([2]int{11, 5}, [2]int{11, 9}, [2]int{4, 11}), false},
+		{"too many sets", setScores([2]int{11, 5}, [2]int{5, 11}, [2]int{11, 5}, [2]int{11, 5}), false},
+		{"no two point lead", setScores([2]int{11, 10}, [2]int{11, 5}), false},
+		{"extended set won by three", setScores([2]int{15, 12}, [2]int{11, 5}), false},
+		{"nobody reached eleven", setScores([2]int{9, 7}, [2]int{11, 5}), false},
+	}
+
+	for _, test := range tests {
+		err := ValidateMatch(models.MatchResult{WinnerID: 1, LoserID: 2, BestOf: 3, Sets: test.sets}, config.Default())
+		if test.valid && err != nil {
+			t.Errorf("%v: expected the match to be valid, got %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%v: expected the match to be rejected", test.name)
+		}
+	}
+}
@@ -1,40 +1,101 @@
 package main
 
 import (
-	"time"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
 
-	"github.com/gin-gonic/gin"
-	"github.com/jda5/luinc-pong/src/internal/handlers"
+	"github.com/jda5/luinc-pong/src/internal/config"
+	"github.com/jda5/luinc-pong/src/internal/models"
 	"github.com/jda5/luinc-pong/src/internal/stores"
+	"github.com/jda5/luinc-pong/src/internal/utils"
 
-	"github.com/gin-contrib/cors"
 	_ "github.com/joho/godotenv/autoload"
 )
 
+// createStore opens the storage backend named by STORE_BACKEND, defaulting to MySQL. The
+// achievement catalogue in ACHIEVEMENTS_FILE is loaded first, so that the store seeds it.
+func createStore() models.Store {
+	if path := os.Getenv("ACHIEVEMENTS_FILE"); path != "" {
+		if err := utils.LoadAchievements(path); err != nil {
+			panic(fmt.Sprintf("error loading achievements from '%v': %v", path, err))
+		}
+	}
+
+	switch backend := os.Getenv("STORE_BACKEND"); backend {
+	case "", "mysql":
+		return stores.CreateMySQLDAO()
+	case "sqlite":
+		return stores.CreateSQLiteDAO()
+	case "memory":
+		return stores.CreateMemoryStore()
+	default:
+		panic(fmt.Sprintf("unknown STORE_BACKEND '%v': expected mysql, sqlite or memory", backend))
+	}
+}
+
+// loadConfig reads the configuration from the environment.
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return cfg, fmt.Errorf("error loading configuration: %v", err)
+	}
+	return cfg, nil
+}
+
+// commands are named by the first argument. The server is started when there is none.
+var commands = map[string]func(args []string) error{
+	"serve":                runServe,
+	"recalculate":          runRecalculate,
+	"rebuild-achievements": runRebuildAchievements,
+	"add-player":           runAddPlayer,
+	"add-game":             runAddGame,
+	"leaderboard":          runLeaderboard,
+	"stats":                runStats,
+	"import":               runImport,
+	"export":               runExport,
+	"restore":              runRestore,
+}
+
+const usage = `usage: main [COMMAND] [ARGS]
+
+commands:
+  serve                     start the API server (the default)
+  recalculate               replay every game to bring the ratings up to date
+  rebuild-achievements      work out every achievement again from the games
+  add-player NAME           add a player
+  add-game WINNER LOSER [WINNER_SCORE LOSER_SCORE]
+                            record a singles game between two players, by name or id
+  leaderboard               print the leaderboard
+  stats                     print totals for the whole record
+  import FILE               load historical games from a CSV or JSON file
+  export                    write an archive of the whole record
+  restore FILE              load an archive into an empty database
+
+Run a command with -h to see its options.
+`
+
 func main() {
-	router := gin.Default()
-	router.Use(
-		cors.New(
-			cors.Config{
-				AllowOrigins:     []string{"*"},
-				AllowMethods:     []string{"GET", "POST", "DELETE", "OPTIONS"},
-				AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
-				AllowCredentials: true,
-				MaxAge:           12 * time.Hour,
-			},
-		),
-	)
-	h := handlers.APIHandler{Store: stores.CreateMySQLDAO()}
-
-	router.GET("/", h.GetIndexPage)
-	router.GET("/achievements", h.GetAchievements)
-	router.GET("/players/:id", h.GetPlayerProfile)
-	router.GET("/head-to-head", h.GetHeadToHead)
-	router.POST("/players", h.InsertPlayer)
-	router.GET("/games", h.GetGames)
-	router.DELETE("/games/:id", h.DeleteGame)
-	router.POST("/games", h.InsertGame)
-	router.GET("/recalculate", h.RecalculateElo)
-
-	router.Run(":8080")
+	name, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		name, args = os.Args[1], os.Args[2:]
+	}
+
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		if name != "help" && name != "-h" && name != "--help" {
+			os.Exit(2)
+		}
+		return
+	}
+
+	if err := command(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("%v failed: %v", name, err)
+	}
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/jda5/luinc-pong/src/internal/auth"
+	"github.com/jda5/luinc-pong/src/internal/events"
+	"github.com/jda5/luinc-pong/src/internal/handlers"
+	"github.com/jda5/luinc-pong/src/internal/models"
+	"github.com/jda5/luinc-pong/src/internal/utils"
+)
+
+// runServe starts the API server on port 8080:
+//
+//	go run . serve
+func runServe(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: serve")
+	}
+
+	router := gin.Default()
+	router.Use(
+		cors.New(
+			cors.Config{
+				AllowOrigins:     []string{"*"},
+				AllowMethods:     []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+				AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+				AllowCredentials: true,
+				MaxAge:           12 * time.Hour,
+			},
+		),
+	)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	h := handlers.APIHandler{Store: createStore(), Config: cfg, Events: events.CreateBroker()}
+
+	// Glicko-2 deviations grow with time, so bring them up to date on startup
+	if err := utils.RecalculateGlickoRatings(h.Store, cfg); err != nil {
+		return fmt.Errorf("error calculating Glicko-2 ratings: %v", err)
+	}
+
+	// the leaderboard as it stands, for later changes to be compared with
+	leaderboard, err := utils.GetIndexPageData(h.Store, cfg, false, utils.SORT_BY_RATING)
+	if err != nil {
+		return fmt.Errorf("error loading the leaderboard: %v", err)
+	}
+	h.Events.PublishLeaderboard(leaderboard.Leaderboard)
+
+	if cfg.Auth.AdminToken == "" {
+		log.Println("ADMIN_TOKEN is not set: admin routes can only be used with admin tokens already issued")
+	}
+	router.Use(auth.Authenticate(h.Store, cfg.Auth.AdminToken))
+
+	// pending games count once their opponent has had the chance to dispute them
+	go func() {
+		for range time.Tick(time.Minute) {
+			if err := h.ConfirmExpiredGames(); err != nil {
+				log.Printf("ERROR: automatic confirmation of pending games failed: %v", err)
+			}
+		}
+	}()
+
+	// read-only routes are open to everyone
+	router.GET("/", h.GetIndexPage)
+	router.GET("/achievements", h.GetAchievements)
+	router.GET("/achievements/:id", h.GetAchievement)
+	router.GET("/players/:id", h.GetPlayerProfile)
+	router.GET("/players/:id/rating-history", h.GetRatingHistory)
+	router.GET("/players/:id/achievements", h.GetPlayerAchievements)
+	router.GET("/players/:id/achievement-families", h.GetPlayerAchievementFamilies)
+	router.GET("/head-to-head", h.GetHeadToHead)
+	router.GET("/games", h.GetGames)
+	router.GET("/games/pending", h.GetPendingGames)
+	router.GET("/games/disputed", h.GetDisputedGames)
+	router.GET("/rating-config", h.GetRatingConfig)
+	router.GET("/events", h.StreamEvents)
+
+	players := router.Group("/", auth.Require(models.ROLE_PLAYER))
+	players.POST("/players", h.InsertPlayer)
+	players.POST("/games", h.InsertGame)
+	players.POST("/doubles-games", h.InsertDoublesGame)
+	players.POST("/matches", h.InsertMatch)
+	players.POST("/games/:id/confirm", h.ConfirmGame)
+	players.POST("/games/:id/dispute", h.DisputeGame)
+	players.POST("/doubles-games/:id/confirm", h.ConfirmDoublesGame)
+	players.POST("/doubles-games/:id/dispute", h.DisputeDoublesGame)
+
+	admin := router.Group("/", auth.Require(models.ROLE_ADMIN))
+	admin.PATCH("/games/:id", h.UpdateGame)
+	admin.DELETE("/games/:id", h.DeleteGame)
+	admin.DELETE("/doubles-games/:id", h.DeleteDoublesGame)
+	admin.GET("/games/deleted", h.GetDeletedGames)
+	admin.POST("/games/import", h.ImportGames)
+	admin.POST("/games/:id/restore", h.RestoreGame)
+	admin.POST("/doubles-games/:id/restore", h.RestoreDoublesGame)
+	admin.GET("/audit-log", h.GetAuditLog)
+	admin.GET("/export", h.ExportArchive)
+	admin.POST("/restore", h.RestoreArchive)
+	admin.GET("/recalculate", h.RecalculateElo)
+	admin.POST("/achievements/rebuild", h.RebuildAchievements)
+	admin.GET("/tokens", h.GetAPITokens)
+	admin.POST("/tokens", h.InsertAPIToken)
+	admin.DELETE("/tokens/:id", h.DeleteAPIToken)
+
+	return router.Run(":8080")
+}